@@ -0,0 +1,66 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestAcademyIntakePromoteAndRelease(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	token, players := getTokenAndPlayerIds(t, false)
+	team := strconv.Itoa(getTeamIdFromUser(t, token))
+
+	_, err := doPostRequest("admin/academy/intakes", token, map[string]interface{}{"season": 2026}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := doPostRequest("admin/academy/intakes", admin, map[string]interface{}{"season": 2026}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, report["prospects_generated"], report["teams"].(float64)*models.AcademyIntakeSize)
+	again, err := doPostRequest("admin/academy/intakes", admin, map[string]interface{}{"season": 2026}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, again["already_generated"], true)
+
+	resp, err := doGetRequest("teams/"+team+"/academy", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	academy := resp["players"].([]interface{})
+	tests.AssertEqual(t, len(academy), models.AcademyIntakeSize)
+	tests.AssertEqual(t, len(getPlayersFromToken(t, token)), len(players))
+	promoted := strconv.Itoa(int(academy[0].(map[string]interface{})["id"].(float64)))
+	released := strconv.Itoa(int(academy[1].(map[string]interface{})["id"].(float64)))
+
+	_, err = doPostRequest("transfers", token, map[string]interface{}{"player_id": academy[0].(map[string]interface{})["id"], "ask": 1000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("teams/"+team+"/academy/"+promoted+"/promote", token, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(getPlayersFromToken(t, token)), len(players)+1)
+	_, err = doPutRequest("teams/"+team+"/academy/"+released+"/release", token, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, int(academy[1].(map[string]interface{})["id"].(float64)))["free_agent"], true)
+
+	resp, err = doGetRequest("teams/"+team+"/academy", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["players"].([]interface{})), models.AcademyIntakeSize-2)
+	_, err = doPutRequest("teams/"+team+"/academy/"+promoted+"/promote", token, map[string]interface{}{}, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,10 +2,15 @@ package app
 
 import (
 	_ "../docs"
+	"./bots"
 	"./controller"
+	"./feed"
+	"./jobs"
 	"./middleware"
 	"./migrations"
 	"./repos"
+	"./season"
+	"./valuation"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -16,6 +21,9 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 )
 
 // A struct holding of our server info
@@ -23,6 +31,8 @@ type App struct {
 	address   string
 	db        *gorm.DB
 	router    *gin.Engine
+	scheduler *jobs.Scheduler
+	feed      *feed.Broker
 	IsRunning bool
 }
 
@@ -32,6 +42,19 @@ func (a *App) Configure() {
 	r := gin.Default()
 
 	c := controller.NewController(repo)
+	c.Valuation = valuationFromEnv()
+	c.Bots = botsFromEnv()
+	c.Season = seasonFromEnv()
+	a.feed = c.Feed
+
+	a.scheduler = jobs.NewScheduler()
+	a.scheduler.Add("return expired loans", time.Minute, c.ReturnExpiredLoans)
+	a.scheduler.Add("expire listings", time.Minute, c.ExpireListings)
+	a.scheduler.Add("settle auctions", time.Minute, c.SettleAuctions)
+	a.scheduler.Add("run bot teams", botIntervalFromEnv(), c.RunBots)
+	a.scheduler.Add("scan for collusion", 10*time.Minute, c.ScanForCollusion)
+	a.scheduler.Add("roll over seasons", time.Hour, c.RolloverCurrentSeason)
+	a.scheduler.Add("run payroll", time.Hour, c.RunPayroll)
 
 	api := r.Group("/api")
 	{
@@ -44,6 +67,15 @@ func (a *App) Configure() {
 			me.GET("/team/players", c.GetMyPlayers)
 			me.GET("/team/players/:playerId", c.GetMyPlayer)
 			me.PATCH("/team/players/:playerId", c.EditMyPlayer)
+			me.GET("/searches", c.ListMySavedSearches)
+			me.POST("/searches", c.CreateSavedSearch)
+			me.DELETE("/searches/:searchId", c.DeleteSavedSearch)
+			me.GET("/watchlist", c.ListMyWatchlist)
+			me.POST("/watchlist", c.WatchPlayer)
+			me.DELETE("/watchlist/:playerId", c.UnwatchPlayer)
+			me.GET("/notifications", c.ListMyNotifications)
+			me.PUT("/notifications", c.ReadAllMyNotifications)
+			me.PUT("/notifications/:notificationId/read", c.ReadMyNotification)
 		}
 		users := api.Group("/users")
 		{
@@ -61,11 +93,16 @@ func (a *App) Configure() {
 		team := api.Group("/teams")
 		{
 			team.GET("/:teamId/players", c.ListTeamPlayers)
+			team.GET("/:teamId/transfers", c.ListTeamTransfers)
 			team.GET("/:teamId/players/:playerId", c.GetMyPlayerFromTeam)
 			team.PATCH("/:teamId/players/:playerId", c.EditMyPlayerFromTeam)
 			team.GET("/:teamId", c.ShowTeam)
+			team.GET("/:teamId/academy", c.ListAcademy)
 			team.Use(middleware.Auth(repo))
 			team.PATCH("/:teamId", c.UpdateTeam)
+			team.PUT("/:teamId/lineup", c.UpdateLineup)
+			team.PUT("/:teamId/academy/:playerId/promote", c.PromoteProspect)
+			team.PUT("/:teamId/academy/:playerId/release", c.ReleaseProspect)
 			team.Use(middleware.Admin())
 			team.POST("/:teamId/players", c.CreateNewPlayerOnTeam)
 			team.POST("", c.CreateTeam)
@@ -74,20 +111,118 @@ func (a *App) Configure() {
 		players := api.Group("/players")
 		{
 			players.GET("/:playerId", c.ShowPlayer)
+			players.GET("/:playerId/history", c.ShowPlayerHistory)
+			players.GET("/:playerId/contract", c.ShowContract)
 			players.Use(middleware.Auth(repo))
 			players.PATCH("/:playerId", c.UpdatePlayer)
+			players.PUT("/:playerId/contract", c.RenewContract)
+			players.POST("/:playerId/release-clause", c.TriggerReleaseClause)
 			players.Use(middleware.Admin())
 			players.DELETE("/:playerId", c.DeletePlayer)
+			players.PUT("/:playerId/availability", c.UpdatePlayerAvailability)
+			players.POST("/:playerId/release", c.ReleasePlayer)
+		}
+		freeAgents := api.Group("/free-agents")
+		{
+			freeAgents.GET("", c.ListFreeAgents)
+			freeAgents.Use(middleware.Auth(repo))
+			freeAgents.POST("/:playerId/sign", c.SignFreeAgent)
 		}
 		transfers := api.Group("/transfers")
 		{
 			transfers.GET("", c.ListTransfers)
+			transfers.GET("/stream", c.StreamTransfers)
 			transfers.GET("/:transferId", c.ShowTransfer)
 			transfers.Use(middleware.Auth(repo))
 			transfers.DELETE("/:transferId", c.DeleteTransfer)
 			transfers.PATCH("/:transferId", c.UpdateTransfer)
 			transfers.POST("", c.CreateTransfer)
+			transfers.POST("/bulk", c.CreateBulkTransfers)
+			transfers.DELETE("/bulk", c.DeleteBulkTransfers)
 			transfers.PUT("/:transferId/buy", c.BuyTransfer)
+			transfers.POST("/:transferId/bids", c.CreateBid)
+			transfers.PUT("/:transferId/close", c.CloseAuction)
+			transfers.GET("/:transferId/offers", c.ListOffers)
+			transfers.POST("/:transferId/offers", c.CreateOffer)
+			transfers.PUT("/:transferId/offers/:offerId/counter", c.CounterOffer)
+			transfers.PUT("/:transferId/offers/:offerId/accept", c.AcceptOffer)
+			transfers.PUT("/:transferId/offers/:offerId/reject", c.RejectOffer)
+			transfers.PUT("/:transferId/offers/:offerId/withdraw", c.WithdrawOffer)
+		}
+		loans := api.Group("/loans")
+		{
+			loans.GET("/:loanId", c.ShowLoan)
+			loans.Use(middleware.Auth(repo))
+			loans.POST("", c.CreateLoan)
+			loans.PUT("/:loanId/accept", c.AcceptLoan)
+			loans.DELETE("/:loanId", c.DeleteLoan)
+		}
+		trades := api.Group("/trades")
+		{
+			trades.Use(middleware.Auth(repo))
+			trades.GET("", c.ListMyTrades)
+			trades.POST("", c.CreateTrade)
+			trades.GET("/:tradeId", c.ShowTrade)
+			trades.PUT("/:tradeId/accept", c.AcceptTrade)
+			trades.PUT("/:tradeId/reject", c.RejectTrade)
+			trades.PUT("/:tradeId/withdraw", c.WithdrawTrade)
+		}
+		windows := api.Group("/transfer-windows")
+		{
+			windows.GET("", c.ListTransferWindows)
+			windows.GET("/:windowId", c.ShowTransferWindow)
+			windows.Use(middleware.Auth(repo))
+			windows.Use(middleware.Admin())
+			windows.POST("", c.CreateTransferWindow)
+			windows.PATCH("/:windowId", c.UpdateTransferWindow)
+			windows.DELETE("/:windowId", c.DeleteTransferWindow)
+		}
+		squadRules := api.Group("/squad-rules")
+		{
+			squadRules.GET("", c.ShowSquadRules)
+			squadRules.Use(middleware.Auth(repo))
+			squadRules.Use(middleware.Admin())
+			squadRules.PATCH("", c.UpdateSquadRules)
+		}
+		economicRules := api.Group("/economic-rules")
+		{
+			economicRules.GET("", c.ShowEconomicRules)
+			economicRules.Use(middleware.Auth(repo))
+			economicRules.Use(middleware.Admin())
+			economicRules.PATCH("", c.UpdateEconomicRules)
+		}
+		treasury := api.Group("/treasury")
+		{
+			treasury.Use(middleware.Auth(repo))
+			treasury.Use(middleware.Admin())
+			treasury.GET("", c.ShowTreasury)
+		}
+		botTeams := api.Group("/bot-teams")
+		{
+			botTeams.Use(middleware.Auth(repo))
+			botTeams.Use(middleware.Admin())
+			botTeams.POST("", c.CreateBotTeam)
+		}
+		admin := api.Group("/admin")
+		{
+			admin.Use(middleware.Auth(repo))
+			admin.Use(middleware.Admin())
+			admin.GET("/flags", c.ListFlags)
+			admin.POST("/flags/scan", c.ScanFlags)
+			admin.PUT("/flags/:flagId/dismiss", c.DismissFlag)
+			admin.PUT("/flags/:flagId/freeze", c.FreezeFlag)
+			admin.GET("/seasons", c.ListSeasons)
+			admin.POST("/seasons/rollover", c.RolloverSeason)
+			admin.POST("/teams/:teamId/matches", c.RecordMatch)
+			admin.POST("/academy/intakes", c.CreateAcademyIntake)
+		}
+		market := api.Group("/market")
+		{
+			market.GET("/stats", c.ShowMarketStats)
+		}
+		completedTransfers := api.Group("/completed-transfers")
+		{
+			completedTransfers.GET("", c.ListCompletedTransfers)
 		}
 	}
 	url := ginSwagger.URL("http://" + a.address + "/swagger/doc.json")
@@ -95,6 +230,54 @@ func (a *App) Configure() {
 	a.router = r
 }
 
+// Select the valuation model from the VALUATION_MODEL and VALUATION_SEED environment variables
+func valuationFromEnv() valuation.Model {
+	seed, err := strconv.ParseInt(os.Getenv("VALUATION_SEED"), 10, 64)
+	if err != nil {
+		seed = time.Now().UnixNano()
+	}
+	model, err := valuation.New(os.Getenv("VALUATION_MODEL"), seed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return model
+}
+
+// Select the bot teams strategy from the BOT_STRATEGY and BOT_SEED environment variables
+func botsFromEnv() bots.Strategy {
+	seed, err := strconv.ParseInt(os.Getenv("BOT_SEED"), 10, 64)
+	if err != nil {
+		seed = time.Now().UnixNano()
+	}
+	strategy, err := bots.New(os.Getenv("BOT_STRATEGY"), seed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strategy
+}
+
+// Get how often bot teams trade from the BOT_INTERVAL environment variable, every minute by default
+func botIntervalFromEnv() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("BOT_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
+
+// Configure the season rollover from the SEASON_RETIREMENT_AGE and SEASON_START_MONTH environment variables.
+// Seasons are only rolled over by administrators unless a start month from 1 to 12 is set.
+func seasonFromEnv() season.Config {
+	config := season.DefaultConfig()
+	if age, err := strconv.Atoi(os.Getenv("SEASON_RETIREMENT_AGE")); err == nil && age > 0 {
+		config.RetirementAge = age
+	}
+	if month, err := strconv.Atoi(os.Getenv("SEASON_START_MONTH")); err == nil && month >= 1 && month <= 12 {
+		config.StartMonth = time.Month(month)
+	}
+	return config
+}
+
 // Create a new app with the given parameters
 func CreateApp(address, host, user, password, dbname, port string) (*App, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
@@ -118,6 +301,7 @@ func CreateApp(address, host, user, password, dbname, port string) (*App, error)
 	app.address = address
 	app.db = db
 	app.Configure()
+	app.scheduler.Start()
 	return &app, nil
 }
 
@@ -136,6 +320,9 @@ func (a *App) Run() {
 
 // Close the app and all it's resources
 func (a *App) Close() {
+	a.scheduler.Stop()
+	// Ends the open streams of the live feed
+	a.feed.Close()
 	sqlDB, err := a.db.DB()
 	if err != nil {
 		log.Fatalln(err)
@@ -41,6 +41,23 @@ func getAdminUserToken(t *testing.T, email string) string {
 }
 
 func truncateDb() {
+	app.db.Unscoped().Where("1 = 1").Delete(&models.AcademyIntake{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Season{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Flag{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.EconomicRules{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.TreasuryEntry{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.SaleLineItem{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.TradePlayer{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Trade{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Notification{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.WatchedPlayer{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.SavedSearch{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.SquadRules{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.TransferWindow{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Loan{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.CompletedTransfer{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Offer{})
+	app.db.Unscoped().Where("1 = 1").Delete(&models.Bid{})
 	app.db.Unscoped().Where("1 = 1").Delete(&models.Transfer{})
 	app.db.Unscoped().Where("1 = 1").Delete(&models.Player{})
 	app.db.Unscoped().Where("1 = 1").Delete(&models.Team{})
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestInjuredPlayersAreFlaggedAndCantBePicked(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	token, players := getTokenAndPlayerIds(t, false)
+	team := strconv.Itoa(getTeamIdFromUser(t, token))
+	injuredId := createTransferUsing(t, 10000, token, players[0])
+	_ = createTransferUsing(t, 10000, token, players[1])
+
+	injury := map[string]interface{}{
+		"status":        "injured",
+		"injured_until": time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	_, err := doPutRequest("players/"+strconv.Itoa(players[0])+"/availability", token, injury, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("players/"+strconv.Itoa(players[0])+"/availability", admin, injury, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["availability"], "injured")
+
+	resp, err := doGetRequest("transfers/"+strconv.Itoa(injuredId), token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["injured"], true)
+	resp, err = doGetRequest("transfers?available_only=true", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["transfers"].([]interface{})), 1)
+
+	_, err = doPutRequest("teams/"+team+"/lineup", token, map[string]interface{}{
+		"player_ids": []int{players[0], players[1]},
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("teams/"+team+"/lineup", token, map[string]interface{}{
+		"player_ids": []int{players[1]},
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, players[1])["in_lineup"], true)
+}
+
+func TestSuspensionsAreServedAfterMatches(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	token, players := getTokenAndPlayerIds(t, false)
+	team := strconv.Itoa(getTeamIdFromUser(t, token))
+
+	_, err := doPutRequest("players/"+strconv.Itoa(players[0])+"/availability", admin, map[string]interface{}{
+		"status":            "suspended",
+		"suspended_matches": 1,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["availability"], "suspended")
+
+	_, err = doPostRequest("admin/teams/"+team+"/matches", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["availability"], "fit")
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"./controller"
+	"./repos"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestBotTeamBuysUndervaluedListing(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	resp, err := doPostRequest("bot-teams", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	botTeam := int(resp["id"].(float64))
+
+	resp, err = doGetRequest("teams/"+strconv.Itoa(botTeam), admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["is_bot"], true)
+	tests.AssertEqual(t, len(resp["players"].([]interface{})), 20)
+
+	// Teams are created with 3 goalkeepers listed first, bots keep a spare one
+	seller, players := getTokenAndPlayerIds(t, false)
+	createTransferUsing(t, 1000, seller, players[0])
+
+	err = controller.NewController(repos.RepositorySQL{Db: app.db}).RunBots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doGetRequest("teams/"+strconv.Itoa(botTeam), admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["players"].([]interface{})), 21)
+	tests.AssertEqual(t, hasPlayer(resp, players[0]), true)
+}
+
+func TestOnlyAdminsCreateBotTeams(t *testing.T) {
+	setupTest()
+	token := getUserToken(t, "test@gmail.com")
+	_, err := doPostRequest("bot-teams", token, map[string]interface{}{}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
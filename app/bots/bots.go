@@ -0,0 +1,89 @@
+package bots
+
+import (
+	"../models"
+	"fmt"
+)
+
+const (
+	ValueStrategyName  = "value"
+	RandomStrategyName = "random"
+	// Extra players of a position a bot keeps on top of the starting squad
+	spareSlots = 1
+)
+
+// Everything a bot team knows about the market when deciding what to do
+type Market struct {
+	Team models.Team
+	// Every player of the team
+	Squad []models.Player
+	// Players of the team that can be listed, they have no open transfer and are not on loan
+	Sellable []models.Player
+	// Fixed price listings of other teams
+	Listings []models.Transfer
+	// Money the team can spend
+	Funds int
+	Rules models.SquadRules
+}
+
+// A player a bot team wants to list and its ask
+type Sale struct {
+	Player models.Player
+	Ask    int
+}
+
+// What a bot team wants to do on the market, every action is validated again before running it
+type Decision struct {
+	Sell []Sale
+	// Listings to buy, in order of preference
+	Buy []models.Transfer
+}
+
+// A strategy that decides how a bot team trades
+type Strategy interface {
+	Decide(market Market) Decision
+}
+
+// Create a strategy by name, the seed is used for the random choices of the strategy
+func New(name string, seed int64) (Strategy, error) {
+	switch name {
+	case "", ValueStrategyName:
+		return NewValueStrategy(seed), nil
+	case RandomStrategyName:
+		return NewRandomStrategy(seed), nil
+	}
+	return nil, fmt.Errorf("unknown bot strategy %v", name)
+}
+
+// Count the players of each position
+func countPositions(players []models.Player) map[int]int {
+	counts := make(map[int]int)
+	for _, p := range players {
+		counts[p.Position]++
+	}
+	return counts
+}
+
+// Returns a bool that tells if a squad with the given position counts needs or can take another player of a position
+func hasRoomFor(rules models.SquadRules, counts map[int]int, position int) bool {
+	room := models.SquadComposition()[position] + spareSlots
+	if limit := maxOfPosition(rules, position); limit > 0 && limit < room {
+		room = limit
+	}
+	return counts[position] < room
+}
+
+// Get the most players of a position a squad can have, 0 if there is no limit
+func maxOfPosition(rules models.SquadRules, position int) int {
+	switch position {
+	case models.Goalkeeper:
+		return rules.MaxGoalkeepers
+	case models.Defender:
+		return rules.MaxDefenders
+	case models.Midfielder:
+		return rules.MaxMidfielders
+	case models.Attacker:
+		return rules.MaxAttackers
+	}
+	return 0
+}
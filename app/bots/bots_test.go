@@ -0,0 +1,85 @@
+package bots
+
+import (
+	"../models"
+	"fmt"
+	"testing"
+)
+
+// Get a squad with the starting composition plus the given extra players
+func squadWith(extra ...models.Player) []models.Player {
+	squad := make([]models.Player, 0)
+	for position, count := range models.SquadComposition() {
+		for i := 0; i < count; i++ {
+			squad = append(squad, models.Player{Position: position, Age: 25, MarketValue: 1000})
+		}
+	}
+	return append(squad, extra...)
+}
+
+func TestNewSelectsStrategyByName(t *testing.T) {
+	if s, err := New("", 0); err != nil || s == nil {
+		t.Errorf("expected the value strategy, got %v", err)
+	}
+	if s, _ := New(RandomStrategyName, 0); s == nil {
+		t.Error("expected the random strategy")
+	}
+	if _, err := New("unknown", 0); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestValueStrategySellsSurplus(t *testing.T) {
+	old := models.Player{Position: models.Attacker, Age: 33, MarketValue: 1000}
+	squad := squadWith(old)
+	decision := NewValueStrategy(1).Decide(Market{Squad: squad, Sellable: squad})
+
+	if len(decision.Sell) != 1 || decision.Sell[0].Player.Age != 33 {
+		t.Fatalf("expected to sell the old attacker, got %v", decision.Sell)
+	}
+	if ask := decision.Sell[0].Ask; ask < 1100 || ask > 1300 {
+		t.Errorf("ask %v out of range", ask)
+	}
+}
+
+func TestValueStrategyBuysUndervaluedPlayersWithinBudget(t *testing.T) {
+	listing := func(id uint, position int, ask int) models.Transfer {
+		t := models.Transfer{Ask: ask, Player: models.Player{Position: position, MarketValue: 1000}}
+		t.ID = id
+		return t
+	}
+	market := Market{
+		Squad: squadWith(models.Player{Position: models.Goalkeeper}),
+		Listings: []models.Transfer{
+			listing(1, models.Defender, 950),
+			listing(2, models.Defender, 700),
+			listing(3, models.Defender, 500),
+			listing(4, models.Goalkeeper, 100),
+			listing(5, models.Midfielder, 2000),
+		},
+		Funds: 750,
+	}
+	decision := NewValueStrategy(1).Decide(market)
+
+	// 4 is the best deal but the squad has a spare goalkeeper already, 3 is the next one and leaves
+	// no room for another defender, while 1 and 5 are not undervalued
+	ids := make([]uint, 0)
+	for _, t := range decision.Buy {
+		ids = append(ids, t.ID)
+	}
+	if fmt.Sprint(ids) != "[3]" {
+		t.Errorf("expected to buy listing 3, got %v", ids)
+	}
+}
+
+func TestRandomStrategyIsSeeded(t *testing.T) {
+	squad := squadWith()
+	market := Market{Squad: squad, Sellable: squad}
+	a, b := NewRandomStrategy(7), NewRandomStrategy(7)
+	for i := 0; i < 10; i++ {
+		da, db := a.Decide(market), b.Decide(market)
+		if fmt.Sprint(da) != fmt.Sprint(db) {
+			t.Fatalf("same seed gave %v and %v", da, db)
+		}
+	}
+}
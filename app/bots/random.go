@@ -0,0 +1,41 @@
+package bots
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Chance of a random strategy listing or buying a player on a round
+const randomActionChance = 0.3
+
+// Strategy that lists a random player at a random price and buys a random listing it can afford
+type RandomStrategy struct {
+	rng   *rand.Rand
+	mutex sync.Mutex
+}
+
+// Create a random strategy from a seed
+func NewRandomStrategy(seed int64) *RandomStrategy {
+	return &RandomStrategy{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Decide what to buy and what to sell
+func (s *RandomStrategy) Decide(market Market) Decision {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	decision := Decision{}
+	if len(market.Sellable) > 0 && s.rng.Float64() < randomActionChance {
+		p := market.Sellable[s.rng.Intn(len(market.Sellable))]
+		multiplier := 0.8 + s.rng.Float64()*0.7
+		decision.Sell = append(decision.Sell, Sale{Player: p, Ask: int(float64(p.MarketValue) * multiplier)})
+	}
+
+	if len(market.Listings) > 0 && s.rng.Float64() < randomActionChance {
+		t := market.Listings[s.rng.Intn(len(market.Listings))]
+		if t.Ask <= market.Funds && hasRoomFor(market.Rules, countPositions(market.Squad), t.Player.Position) {
+			decision.Buy = append(decision.Buy, t)
+		}
+	}
+	return decision
+}
@@ -0,0 +1,83 @@
+package bots
+
+import (
+	"../models"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	// Listings asking less than this share of the market value are undervalued
+	undervaluedRatio = 0.9
+	// Bounds of the markup over the market value bots ask for their players
+	minMarkup = 1.1
+	maxMarkup = 1.3
+)
+
+// Strategy that buys undervalued players for the positions the squad has room for
+// and lists the players it has on top of the starting squad with a random markup
+type ValueStrategy struct {
+	rng   *rand.Rand
+	mutex sync.Mutex
+}
+
+// Create a value strategy from a seed
+func NewValueStrategy(seed int64) *ValueStrategy {
+	return &ValueStrategy{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Decide what to buy and what to sell
+func (s *ValueStrategy) Decide(market Market) Decision {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	composition := models.SquadComposition()
+	counts := countPositions(market.Squad)
+	decision := Decision{}
+
+	// List the oldest players of the positions with more players than the starting squad
+	sellable := append([]models.Player{}, market.Sellable...)
+	sort.SliceStable(sellable, func(i, j int) bool { return sellable[i].Age > sellable[j].Age })
+	surplus := make(map[int]int)
+	for position, count := range counts {
+		surplus[position] = count - composition[position]
+	}
+	for _, p := range sellable {
+		if surplus[p.Position] <= 0 {
+			continue
+		}
+		surplus[p.Position]--
+		markup := minMarkup + s.rng.Float64()*(maxMarkup-minMarkup)
+		decision.Sell = append(decision.Sell, Sale{Player: p, Ask: int(float64(p.MarketValue) * markup)})
+	}
+
+	// Buy the most undervalued listings the team can afford and has room for
+	listings := make([]models.Transfer, 0)
+	for _, t := range market.Listings {
+		if float64(t.Ask) <= float64(t.Player.MarketValue)*undervaluedRatio {
+			listings = append(listings, t)
+		}
+	}
+	sort.SliceStable(listings, func(i, j int) bool {
+		return discount(listings[i]) > discount(listings[j])
+	})
+	funds := market.Funds
+	for _, t := range listings {
+		if t.Ask > funds || !hasRoomFor(market.Rules, counts, t.Player.Position) {
+			continue
+		}
+		funds -= t.Ask
+		counts[t.Player.Position]++
+		decision.Buy = append(decision.Buy, t)
+	}
+	return decision
+}
+
+// Get the share of the market value a listing is below it
+func discount(t models.Transfer) float64 {
+	if t.Player.MarketValue <= 0 {
+		return 0
+	}
+	return 1 - float64(t.Ask)/float64(t.Player.MarketValue)
+}
@@ -0,0 +1,247 @@
+package collusion
+
+import (
+	"../models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Thresholds of the detectors, a zero value turns a detector off
+type Config struct {
+	// Only the sales made within this time are scanned
+	Window time.Duration
+	// Sales between the same pair of teams, in either direction, that look suspicious
+	RepeatedTrades int
+	// How many times its market value a player has to be sold for to look inflated
+	InflatedPriceRatio float64
+	// A player that goes back to a team that sold it within this time makes a round trip
+	RoundTripWindow time.Duration
+	// Flag pairs of teams that traded and whose accounts share a signup IP or email address
+	SharedSignup bool
+}
+
+// The thresholds used by the league unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		Window:             30 * 24 * time.Hour,
+		RepeatedTrades:     3,
+		InflatedPriceRatio: 2,
+		RoundTripWindow:    14 * 24 * time.Hour,
+		SharedSignup:       true,
+	}
+}
+
+// The account that owns a team
+type Account struct {
+	UserID   uint
+	Email    string
+	SignupIP string
+	// Sales between two bot teams are run by the league and never flagged
+	Bot bool
+}
+
+// A suspicious pattern found in the sales
+type Finding struct {
+	// Identifies the pattern, scanning the same sales again finds the same keys while a newer sale gives a new one
+	Key         string
+	Kind        string
+	TeamID      uint
+	OtherTeamID uint
+	PlayerID    uint
+	// The most recent sale behind the finding
+	CompletedTransferID uint
+	Details             string
+}
+
+// Scan the sales for suspicious patterns, accounts maps the id of every team to its owner
+func Scan(sales []models.CompletedTransfer, accounts map[uint]Account, config Config) []Finding {
+	scanned := make([]models.CompletedTransfer, 0, len(sales))
+	for _, s := range sales {
+		if !accounts[s.SellerTeamID].Bot || !accounts[s.BuyerTeamID].Bot {
+			scanned = append(scanned, s)
+		}
+	}
+	sort.SliceStable(scanned, func(i, j int) bool {
+		if scanned[i].CreatedAt.Equal(scanned[j].CreatedAt) {
+			return scanned[i].ID < scanned[j].ID
+		}
+		return scanned[i].CreatedAt.Before(scanned[j].CreatedAt)
+	})
+
+	findings := make([]Finding, 0)
+	findings = append(findings, repeatedTrades(scanned, config)...)
+	findings = append(findings, roundTrips(scanned, config)...)
+	findings = append(findings, inflatedPrices(scanned, config)...)
+	findings = append(findings, sharedSignups(scanned, accounts, config)...)
+	return findings
+}
+
+// Two teams in ascending id order, so both directions of a sale give the same pair
+type pair struct {
+	a, b uint
+}
+
+func pairOf(s models.CompletedTransfer) pair {
+	if s.SellerTeamID < s.BuyerTeamID {
+		return pair{s.SellerTeamID, s.BuyerTeamID}
+	}
+	return pair{s.BuyerTeamID, s.SellerTeamID}
+}
+
+// Get the key of a finding about the pair up to its latest sale, so a dismissed pair is flagged again when it keeps trading
+func (p pair) key(kind string, latest models.CompletedTransfer) string {
+	return fmt.Sprintf("%v:%v-%v:%v", kind, p.a, p.b, latest.ID)
+}
+
+// Group the sales by the pair of teams, the pairs are in order of their first sale
+func groupByPair(sales []models.CompletedTransfer) ([]pair, map[pair][]models.CompletedTransfer) {
+	pairs := make([]pair, 0)
+	groups := make(map[pair][]models.CompletedTransfer)
+	for _, s := range sales {
+		p := pairOf(s)
+		if _, ok := groups[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		groups[p] = append(groups[p], s)
+	}
+	return pairs, groups
+}
+
+// Pairs of teams that keep selling players to each other
+func repeatedTrades(sales []models.CompletedTransfer, config Config) []Finding {
+	findings := make([]Finding, 0)
+	if config.RepeatedTrades <= 0 {
+		return findings
+	}
+	pairs, groups := groupByPair(sales)
+	for _, p := range pairs {
+		group := groups[p]
+		if len(group) < config.RepeatedTrades {
+			continue
+		}
+		total := 0
+		for _, s := range group {
+			total += s.Price
+		}
+		last := group[len(group)-1]
+		findings = append(findings, Finding{
+			Key:                 p.key(models.FlagRepeatedTrades, last),
+			Kind:                models.FlagRepeatedTrades,
+			TeamID:              p.a,
+			OtherTeamID:         p.b,
+			CompletedTransferID: last.ID,
+			Details:             fmt.Sprintf("Teams %v and %v made %v sales with each other for a total of %v", p.a, p.b, len(group), total),
+		})
+	}
+	return findings
+}
+
+// Players that go back to a team that sold them shortly before
+func roundTrips(sales []models.CompletedTransfer, config Config) []Finding {
+	findings := make([]Finding, 0)
+	if config.RoundTripWindow <= 0 {
+		return findings
+	}
+	for i, s := range sales {
+		// Look for the most recent time the buyer sold the same player
+		for j := i - 1; j >= 0; j-- {
+			previous := sales[j]
+			if previous.PlayerID != s.PlayerID || previous.SellerTeamID != s.BuyerTeamID {
+				continue
+			}
+			elapsed := s.CreatedAt.Sub(previous.CreatedAt)
+			if elapsed <= config.RoundTripWindow {
+				findings = append(findings, Finding{
+					Key:                 fmt.Sprintf("%v:%v", models.FlagRoundTrip, s.ID),
+					Kind:                models.FlagRoundTrip,
+					TeamID:              s.BuyerTeamID,
+					OtherTeamID:         previous.BuyerTeamID,
+					PlayerID:            s.PlayerID,
+					CompletedTransferID: s.ID,
+					Details: fmt.Sprintf("Team %v sold player %v to team %v for %v and bought it back for %v after %v",
+						s.BuyerTeamID, s.PlayerID, previous.BuyerTeamID, previous.Price, s.Price, elapsed.Round(time.Minute)),
+				})
+			}
+			break
+		}
+	}
+	return findings
+}
+
+// Sales far above the market value the player had
+func inflatedPrices(sales []models.CompletedTransfer, config Config) []Finding {
+	findings := make([]Finding, 0)
+	if config.InflatedPriceRatio <= 0 {
+		return findings
+	}
+	for _, s := range sales {
+		if s.ValueBefore <= 0 {
+			continue
+		}
+		ratio := float64(s.Price) / float64(s.ValueBefore)
+		if ratio <= config.InflatedPriceRatio {
+			continue
+		}
+		findings = append(findings, Finding{
+			Key:                 fmt.Sprintf("%v:%v", models.FlagInflatedPrice, s.ID),
+			Kind:                models.FlagInflatedPrice,
+			TeamID:              s.SellerTeamID,
+			OtherTeamID:         s.BuyerTeamID,
+			PlayerID:            s.PlayerID,
+			CompletedTransferID: s.ID,
+			Details: fmt.Sprintf("Team %v sold player %v to team %v for %v, %.1f times its market value of %v",
+				s.SellerTeamID, s.PlayerID, s.BuyerTeamID, s.Price, ratio, s.ValueBefore),
+		})
+	}
+	return findings
+}
+
+// Pairs of teams that traded and whose accounts look like they were registered by the same person
+func sharedSignups(sales []models.CompletedTransfer, accounts map[uint]Account, config Config) []Finding {
+	findings := make([]Finding, 0)
+	if !config.SharedSignup {
+		return findings
+	}
+	pairs, groups := groupByPair(sales)
+	for _, p := range pairs {
+		a, okA := accounts[p.a]
+		b, okB := accounts[p.b]
+		if !okA || !okB || a.UserID == b.UserID {
+			continue
+		}
+		var trait string
+		if a.SignupIP != "" && a.SignupIP == b.SignupIP {
+			trait = "the signup IP " + a.SignupIP
+		} else if email := normalizeEmail(a.Email); email != "" && email == normalizeEmail(b.Email) {
+			trait = "the email address " + email
+		} else {
+			continue
+		}
+		group := groups[p]
+		last := group[len(group)-1]
+		findings = append(findings, Finding{
+			Key:                 p.key(models.FlagSharedSignup, last),
+			Kind:                models.FlagSharedSignup,
+			TeamID:              p.a,
+			OtherTeamID:         p.b,
+			CompletedTransferID: last.ID,
+			Details:             fmt.Sprintf("The accounts of teams %v and %v share %v and made %v sales with each other", p.a, p.b, trait, len(group)),
+		})
+	}
+	return findings
+}
+
+// Reduce an email address to the mailbox it delivers to, dropping dots and plus tags of the local part
+func normalizeEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return ""
+	}
+	local, domain := strings.ToLower(email[:at]), strings.ToLower(email[at+1:])
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	return strings.ReplaceAll(local, ".", "") + "@" + domain
+}
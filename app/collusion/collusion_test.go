@@ -0,0 +1,131 @@
+package collusion
+
+import (
+	"../models"
+	"testing"
+	"time"
+)
+
+// Create a sale made some hours after a fixed start
+func sale(id uint, player uint, seller uint, buyer uint, price int, value int32, hours int) models.CompletedTransfer {
+	s := models.CompletedTransfer{PlayerID: player, SellerTeamID: seller, BuyerTeamID: buyer, Price: price, ValueBefore: value}
+	s.ID = id
+	s.CreatedAt = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(hours) * time.Hour)
+	return s
+}
+
+// Get the findings of a kind
+func findingsOf(findings []Finding, kind string) []Finding {
+	found := make([]Finding, 0)
+	for _, f := range findings {
+		if f.Kind == kind {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+func TestScanFindsRepeatedTradesInBothDirections(t *testing.T) {
+	sales := []models.CompletedTransfer{
+		sale(1, 10, 1, 2, 1000, 1000, 0),
+		sale(2, 11, 2, 1, 1000, 1000, 1),
+		sale(3, 12, 1, 2, 1000, 1000, 2),
+		sale(4, 13, 1, 3, 1000, 1000, 3),
+	}
+	found := findingsOf(Scan(sales, nil, Config{RepeatedTrades: 3}), models.FlagRepeatedTrades)
+	if len(found) != 1 || found[0].TeamID != 1 || found[0].OtherTeamID != 2 || found[0].CompletedTransferID != 3 {
+		t.Fatalf("unexpected findings %v", found)
+	}
+	if found[0].Key != "repeated_trades:1-2:3" {
+		t.Errorf("unexpected key %v", found[0].Key)
+	}
+
+	// Trading again gives a new key, so a dismissed pair gets flagged again
+	sales = append(sales, sale(5, 14, 2, 1, 1000, 1000, 4))
+	found = findingsOf(Scan(sales, nil, Config{RepeatedTrades: 3}), models.FlagRepeatedTrades)
+	if len(found) != 1 || found[0].Key != "repeated_trades:1-2:5" {
+		t.Errorf("unexpected findings %v", found)
+	}
+}
+
+func TestScanFindsRoundTripsWithinTheWindow(t *testing.T) {
+	sales := []models.CompletedTransfer{
+		// Sold back directly
+		sale(1, 10, 1, 2, 1000, 1000, 0),
+		sale(2, 10, 2, 1, 1500, 1500, 5),
+		// Sold back through a third team, but too late
+		sale(3, 11, 1, 2, 1000, 1000, 0),
+		sale(4, 11, 2, 3, 1000, 1000, 10),
+		sale(5, 11, 3, 1, 1000, 1000, 100),
+	}
+	found := findingsOf(Scan(sales, nil, Config{RoundTripWindow: 48 * time.Hour}), models.FlagRoundTrip)
+	if len(found) != 1 {
+		t.Fatalf("unexpected findings %v", found)
+	}
+	f := found[0]
+	if f.TeamID != 1 || f.OtherTeamID != 2 || f.PlayerID != 10 || f.CompletedTransferID != 2 {
+		t.Errorf("unexpected round trip %v", f)
+	}
+}
+
+func TestScanFindsInflatedPrices(t *testing.T) {
+	sales := []models.CompletedTransfer{
+		sale(1, 10, 1, 2, 2000, 1000, 0),
+		sale(2, 11, 1, 2, 2001, 1000, 1),
+		sale(3, 12, 1, 2, 5000, 0, 2),
+	}
+	found := findingsOf(Scan(sales, nil, Config{InflatedPriceRatio: 2}), models.FlagInflatedPrice)
+	if len(found) != 1 || found[0].CompletedTransferID != 2 || found[0].TeamID != 1 || found[0].OtherTeamID != 2 {
+		t.Errorf("unexpected findings %v", found)
+	}
+}
+
+func TestScanFindsSharedSignups(t *testing.T) {
+	sales := []models.CompletedTransfer{
+		sale(1, 10, 1, 2, 1000, 1000, 0),
+		sale(2, 11, 3, 4, 1000, 1000, 1),
+		sale(3, 12, 5, 6, 1000, 1000, 2),
+	}
+	accounts := map[uint]Account{
+		1: {UserID: 1, Email: "john@test.com", SignupIP: "10.0.0.1"},
+		2: {UserID: 2, Email: "other@test.com", SignupIP: "10.0.0.1"},
+		3: {UserID: 3, Email: "Jane.Doe@test.com", SignupIP: "10.0.0.2"},
+		4: {UserID: 4, Email: "janedoe+alt@test.com", SignupIP: "10.0.0.3"},
+		5: {UserID: 5, Email: "first@test.com"},
+		6: {UserID: 6, Email: "second@test.com"},
+	}
+	found := findingsOf(Scan(sales, accounts, Config{SharedSignup: true}), models.FlagSharedSignup)
+	if len(found) != 2 || found[0].Key != "shared_signup:1-2:1" || found[1].Key != "shared_signup:3-4:2" {
+		t.Errorf("unexpected findings %v", found)
+	}
+}
+
+func TestScanSkipsSalesBetweenBots(t *testing.T) {
+	sales := []models.CompletedTransfer{
+		sale(1, 10, 1, 2, 9000, 1000, 0),
+		sale(2, 11, 1, 3, 9000, 1000, 1),
+	}
+	accounts := map[uint]Account{
+		1: {UserID: 1, Bot: true},
+		2: {UserID: 2, Bot: true},
+		3: {UserID: 3},
+	}
+	found := Scan(sales, accounts, DefaultConfig())
+	if len(found) != 1 || found[0].CompletedTransferID != 2 {
+		t.Errorf("unexpected findings %v", found)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := map[string]string{
+		"John.Smith+market@Test.com": "johnsmith@test.com",
+		"john@test.com":              "john@test.com",
+		"invalid":                    "",
+		"@test.com":                  "",
+	}
+	for email, expected := range cases {
+		if got := normalizeEmail(email); got != expected {
+			t.Errorf("normalizing %v gave %v instead of %v", email, got, expected)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestSaleIsRecordedInHistory(t *testing.T) {
+	setupTest()
+	ask := 10000
+	seller, playerId, transferId := createTransfer(t, ask)
+	buyer := getUserToken(t, "hola@test.com")
+	_, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("players/"+strconv.Itoa(playerId)+"/history", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := resp["transfers"].([]interface{})
+	tests.AssertEqual(t, len(history), 1)
+	record := history[0].(map[string]interface{})
+	tests.AssertEqual(t, record["price"], ask)
+	tests.AssertEqual(t, record["seller_team_id"], getTeamIdFromUser(t, seller))
+	tests.AssertEqual(t, record["buyer_team_id"], getTeamIdFromUser(t, buyer))
+
+	for _, token := range []string{seller, buyer} {
+		resp, err = doGetRequest("teams/"+strconv.Itoa(getTeamIdFromUser(t, token))+"/transfers", token, http.StatusOK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests.AssertEqual(t, len(resp["transfers"].([]interface{})), 1)
+	}
+
+	resp, err = doGetRequest("completed-transfers?page=1&page_size=10", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["total"], 1)
+	tests.AssertEqual(t, len(resp["transfers"].([]interface{})), 1)
+}
+
+func TestInvalidHistoryPage(t *testing.T) {
+	setupTest()
+	_, err := doGetRequest("completed-transfers?page=0", "", http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRenewContract(t *testing.T) {
+	setupTest()
+	token, players := getTokenAndPlayerIds(t, false)
+	other := getUserToken(t, "other@test.com")
+	player := strconv.Itoa(players[0])
+
+	contract, err := doGetRequest("players/"+player+"/contract", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, contract["weekly_wage"], float64(2000))
+	tests.AssertEqual(t, contract["asking_wage"], float64(2000))
+	team, err := doGetRequest("me/team", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, team["wage_bill"], float64(20*2000))
+
+	endsAt := time.Now().AddDate(4, 0, 0).UTC().Truncate(time.Second)
+	renewal := map[string]interface{}{"weekly_wage": 1500, "ends_at": endsAt.Format(time.RFC3339)}
+	_, err = doPutRequest("players/"+player+"/contract", token, renewal, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewal["weekly_wage"] = 2500
+	_, err = doPutRequest("players/"+player+"/contract", other, renewal, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err = doPutRequest("players/"+player+"/contract", token, renewal, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, contract["weekly_wage"], float64(2500))
+	tests.AssertEqual(t, contract["ends_at"], endsAt.Format(time.RFC3339))
+}
+
+func TestBuyersAgreeContractTerms(t *testing.T) {
+	setupTest()
+	_, playerId, transferId := createTransfer(t, 10000)
+	buyer := getUserToken(t, "buyer@test.com")
+	buy := "transfers/" + strconv.Itoa(transferId) + "/buy"
+
+	_, err := doPutRequest(buy, buyer, map[string]interface{}{"weekly_wage": 100}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(buy, buyer, map[string]interface{}{
+		"weekly_wage":      3000,
+		"contract_ends_at": time.Now().AddDate(10, 0, 0).Format(time.RFC3339),
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(buy, buyer, map[string]interface{}{"weekly_wage": 3000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract, err := doGetRequest("players/"+strconv.Itoa(playerId)+"/contract", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, contract["team_id"], float64(getTeamIdFromUser(t, buyer)))
+	tests.AssertEqual(t, contract["weekly_wage"], float64(3000))
+}
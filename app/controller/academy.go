@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Handles GET requests to the academy of a team
+// @Summary List the academy of a team
+// @Description List the youth prospects of a team. They are not part of the squad until they are promoted.
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Success 200 {array} models.ShowPlayer
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /teams/{id}/academy [get]
+func (c *Controller) ListAcademy(ctx *gin.Context) {
+	team, err := c.getTeamFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	arr := make([]models.ShowPlayer, 0)
+	for _, p := range c.Repo.GetAcademyPlayers(team.ID) {
+		arr = append(arr, c.getPlayerPayload(p))
+	}
+	httputil.NoError(ctx, gin.H{"players": arr})
+}
+
+// Handles PUT requests to promote a prospect
+// @Summary Promote a prospect to the squad
+// @Description Move a prospect from the academy to the squad of the team, where it signs a contract at its asking wage
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Param playerId path int true "Player ID"
+// @Success 200 {object} models.ShowContract
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /teams/{id}/academy/{playerId}/promote [put]
+// @Security BearerAuth
+func (c *Controller) PromoteProspect(ctx *gin.Context) {
+	team, player, err := c.getProspectFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if !c.validateSquadChange(ctx, team.ID, nil, &player) {
+		return
+	}
+
+	var promoted models.Player
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var err error
+		promoted, err = c.promoteProspectIn(tx, player.ID, team.ID, time.Now())
+		return err
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoError(ctx, c.getContractPayload(promoted))
+}
+
+// Move a prospect of a team to its squad inside a transaction, where it signs a contract at its asking wage.
+// Fails with errSaleConflict if it left the academy or the squad filled up in the meantime.
+func (c *Controller) promoteProspectIn(tx repos.Repository, playerId uint, teamId uint, now time.Time) (models.Player, error) {
+	var player models.Player
+	if err := tx.Lock(&player, playerId); err != nil || !player.InAcademy || player.TeamID != teamId {
+		return player, errSaleConflict
+	}
+	if _, err := c.lockTeams(tx, teamId); err != nil {
+		return player, errSaleConflict
+	}
+	if c.checkSquadChangesIn(tx, teamId, nil, []models.Player{player}) != nil {
+		return player, errSaleConflict
+	}
+
+	player.InAcademy = false
+	player.SignContract(player.AskingWage(), now, now.AddDate(models.DefaultContractYears, 0, 0))
+	return player, tx.Update(&player)
+}
+
+// Handles PUT requests to release a prospect
+// @Summary Release a prospect
+// @Description Let a prospect leave the academy of the team, it becomes a free agent
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Param playerId path int true "Player ID"
+// @Success 200
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /teams/{id}/academy/{playerId}/release [put]
+// @Security BearerAuth
+func (c *Controller) ReleaseProspect(ctx *gin.Context) {
+	team, player, err := c.getProspectFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	_, err = c.releasePlayer(player, func(p models.Player) bool {
+		// It may have been promoted in the meantime
+		return p.InAcademy && p.TeamID == team.ID
+	}, nil)
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles POST requests to generate a youth intake
+// @Summary Generate the youth intake of a season
+// @Description Every team gets a batch of prospects in its academy, aged 16 to 18 with a low market value and a high potential.
+// @Description Everything happens in a single transaction. Generating the intake of the same season again changes nothing and returns its report.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param intake body models.CreateAcademyIntake true "Season of the intake"
+// @Success 200 {object} models.ShowAcademyIntake
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/academy/intakes [post]
+// @Security BearerAuth[admin]
+func (c *Controller) CreateAcademyIntake(ctx *gin.Context) {
+	var t models.CreateAcademyIntake
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	report, generated, err := c.generateAcademyIntake(t.Season)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	payload := c.getAcademyIntakePayload(report)
+	payload.AlreadyGenerated = !generated
+	httputil.NoError(ctx, payload)
+}
+
+// Generate the youth intake of a season in a single transaction and save its report. Returns the report and true
+// when the intake was generated now, or the report of the previous intake and false when it was already done.
+func (c *Controller) generateAcademyIntake(season int) (models.AcademyIntake, bool, error) {
+	if previous, err := c.Repo.GetAcademyIntake(season); err == nil {
+		return previous, false, nil
+	}
+
+	var report models.AcademyIntake
+	err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		report = models.AcademyIntake{Season: season}
+		// The season is unique, so a concurrent intake of the same season fails here
+		if err := tx.Create(&report); err != nil {
+			return err
+		}
+		for _, team := range tx.GetTeams() {
+			for i := 0; i < models.AcademyIntakeSize; i++ {
+				prospect := models.RandomProspect(rand.Intn(len(models.PositionNames)))
+				prospect.TeamID = team.ID
+				if err := tx.Create(&prospect); err != nil {
+					return err
+				}
+				report.ProspectsGenerated++
+			}
+			report.Teams++
+		}
+		return tx.Update(&report)
+	})
+	if err != nil {
+		// Another request generated the intake first
+		if previous, err := c.Repo.GetAcademyIntake(season); err == nil {
+			return previous, false, nil
+		}
+		return models.AcademyIntake{}, false, err
+	}
+	log.Printf("Youth intake of season %v generated: %v prospects for %v teams", season, report.ProspectsGenerated, report.Teams)
+	return report, true, nil
+}
+
+// Get the team and the academy prospect of a request, writing the error if the user doesn't own the team
+func (c *Controller) getProspectFromRequest(ctx *gin.Context) (models.Team, models.Player, error) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return models.Team{}, models.Player{}, err
+	}
+	team, err := c.getTeamFromRequest(ctx)
+	if err != nil {
+		return team, models.Player{}, err
+	}
+	if !user.IsAdmin() && !c.validateTeamOwner(ctx, user, team) {
+		return team, models.Player{}, fmt.Errorf("unauthorized")
+	}
+	player, err := c.getPlayerFromRequest(ctx)
+	if err != nil {
+		return team, player, err
+	}
+	if !player.InAcademy || player.TeamID != team.ID {
+		httputil.NewError(ctx, http.StatusNotFound, "Player is not in the academy of the team")
+		return team, player, fmt.Errorf("player is not in the academy")
+	}
+	return team, player, nil
+}
+
+// Get the payload of a youth intake report
+func (c *Controller) getAcademyIntakePayload(intake models.AcademyIntake) models.ShowAcademyIntake {
+	return models.ShowAcademyIntake{
+		ID:                 intake.ID,
+		Season:             intake.Season,
+		Teams:              intake.Teams,
+		ProspectsGenerated: intake.ProspectsGenerated,
+		GeneratedAt:        intake.CreatedAt,
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+	"time"
+)
+
+func TestGenerateAcademyIntake(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	user, _ := repo.CreateUser("owner@test.com", []byte{}, 0)
+	team, _ := repo.GetUserTeam(user)
+	other := models.Team{}
+	_ = repo.Create(&other)
+	squad := len(repo.GetPlayers(team.ID))
+
+	report, generated, err := c.generateAcademyIntake(2026)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, generated, true)
+	tests.AssertEqual(t, report.Teams, 2)
+	tests.AssertEqual(t, report.ProspectsGenerated, 2*models.AcademyIntakeSize)
+
+	// Prospects are held apart from the squad
+	tests.AssertEqual(t, len(repo.GetPlayers(team.ID)), squad)
+	academy := repo.GetAcademyPlayers(team.ID)
+	tests.AssertEqual(t, len(academy), models.AcademyIntakeSize)
+	for _, p := range academy {
+		if p.Age < models.MinProspectAge || p.Age > models.MaxProspectAge || p.Potential <= p.Overall || p.WeeklyWage != 0 {
+			t.Errorf("unexpected prospect %+v", p)
+		}
+	}
+
+	// Generating the intake of the same season again changes nothing
+	again, generated, err := c.generateAcademyIntake(2026)
+	tests.AssertEqual(t, err, nil)
+	tests.AssertEqual(t, generated, false)
+	tests.AssertEqual(t, again.ID, report.ID)
+	tests.AssertEqual(t, len(repo.GetAcademyPlayers(team.ID)), models.AcademyIntakeSize)
+}
+
+func TestRandomProspect(t *testing.T) {
+	for position := range models.PositionNames {
+		p := models.RandomProspect(position)
+		tests.AssertEqual(t, p.InAcademy, true)
+		tests.AssertEqual(t, p.Overall, p.OverallRating(position))
+		if p.MarketValue >= models.RandomPlayer(position).MarketValue || p.Potential < p.Overall+20 && p.Potential != models.MaxAttribute {
+			t.Errorf("unexpected prospect %+v", p)
+		}
+	}
+}
+
+func TestPromoteProspectChecksTheLockedPlayer(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{}
+	_ = repo.Create(&team)
+	prospect := models.Player{TeamID: team.ID, InAcademy: true, MarketValue: 1000000}
+	released := models.Player{TeamID: team.ID, InAcademy: true}
+	_ = repo.Create(&prospect)
+	_ = repo.Create(&released)
+	now := time.Now()
+
+	promoted, err := c.promoteProspectIn(repo, prospect.ID, team.ID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, promoted.InAcademy, false)
+	tests.AssertEqual(t, promoted.WeeklyWage, prospect.AskingWage())
+
+	// It was released while the promotion was validated
+	released.ReleaseToFreeAgency()
+	_ = repo.Update(&released)
+	_, err = c.promoteProspectIn(repo, released.ID, team.ID, now)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+	agent, _ := repo.GetPlayer(released.ID)
+	tests.AssertEqual(t, agent.IsFreeAgent(), true)
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Most players a team can pick for a match
+const maxLineupSize = 11
+
+// Handles PUT requests to the availability of a player
+// @Summary Set the availability of a player
+// @Description Injure, suspend or clear a player. Injured players recover on their own at the given time and
+// @Description suspended ones after missing the given matches. Players that become unavailable leave the lineup.
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Param availability body models.UpdateAvailability true "Availability"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /players/{id}/availability [put]
+// @Security BearerAuth[admin]
+func (c *Controller) UpdatePlayerAvailability(ctx *gin.Context) {
+	player, err := c.getPlayerFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.UpdateAvailability
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	now := time.Now()
+	switch t.Status {
+	case models.PlayerFit:
+		player.InjuredUntil = nil
+		player.SuspendedMatches = 0
+	case models.PlayerInjured:
+		if t.InjuredUntil == nil || !t.InjuredUntil.After(now) {
+			httputil.NewError(ctx, http.StatusBadRequest, "Injuries need a recovery time in the future")
+			return
+		}
+		player.InjuredUntil = t.InjuredUntil
+	case models.PlayerSuspended:
+		if t.SuspendedMatches <= 0 {
+			httputil.NewError(ctx, http.StatusBadRequest, "Suspensions need a positive amount of matches")
+			return
+		}
+		player.SuspendedMatches = t.SuspendedMatches
+	default:
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid status")
+		return
+	}
+	if !player.IsAvailable(now) {
+		player.InLineup = false
+	}
+
+	if err := c.Repo.Update(&player); err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles POST requests to the matches of a team
+// @Summary Record a match played by a team
+// @Description Used by the match engine after every match, the suspended players of the team serve a match of their suspension
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/teams/{id}/matches [post]
+// @Security BearerAuth[admin]
+func (c *Controller) RecordMatch(ctx *gin.Context) {
+	team, err := c.getTeamFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.serveSuspensionsIn(tx, team.ID)
+	})
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles PUT requests to the lineup of a team
+// @Summary Pick the lineup of a team
+// @Description Pick the players of the team for the next match, replacing the previous lineup. Injured or suspended players can't be picked.
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Param lineup body models.UpdateLineup true "Lineup"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /teams/{id}/lineup [put]
+// @Security BearerAuth
+func (c *Controller) UpdateLineup(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	team, err := c.getTeamFromRequest(ctx)
+	if err != nil || (!user.IsAdmin() && !c.validateTeamOwner(ctx, user, team)) {
+		return
+	}
+
+	var t models.UpdateLineup
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if len(t.PlayerIDs) > maxLineupSize {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("A lineup can't have more than %v players", maxLineupSize))
+		return
+	}
+	players := c.Repo.GetPlayers(team.ID)
+	if !c.validateLineup(ctx, players, t.PlayerIDs) {
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.saveLineupIn(tx, team.ID, players, t.PlayerIDs, time.Now())
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Save the lineup of a team inside a transaction. Every player that changes is locked and checked again,
+// if a picked player left the team or became unavailable in the meantime it fails with errSaleConflict
+func (c *Controller) saveLineupIn(tx repos.Repository, teamId uint, squad []models.Player, ids []uint, now time.Time) error {
+	picked := make(map[uint]bool)
+	for _, id := range ids {
+		picked[id] = true
+	}
+	// Lock in ascending id order and check every player before saving any of them
+	sort.Slice(squad, func(i, j int) bool { return squad[i].ID < squad[j].ID })
+	changed := make([]models.Player, 0)
+	for _, p := range squad {
+		if p.InLineup == picked[p.ID] {
+			continue
+		}
+		var player models.Player
+		if err := tx.Lock(&player, p.ID); err != nil || player.TeamID != teamId || player.InAcademy {
+			if picked[p.ID] {
+				return errSaleConflict
+			}
+			// It left the team, so it is not in its lineup anymore
+			continue
+		}
+		if picked[p.ID] && player.Availability(now) != models.PlayerFit {
+			return errSaleConflict
+		}
+		player.InLineup = picked[p.ID]
+		changed = append(changed, player)
+	}
+	for i := range changed {
+		if err := tx.Update(&changed[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate every picked player is in the squad, picked once and available, and write the error if not
+func (c *Controller) validateLineup(ctx *gin.Context, squad []models.Player, ids []uint) bool {
+	byId := make(map[uint]models.Player)
+	for _, p := range squad {
+		byId[p.ID] = p
+	}
+	now := time.Now()
+	seen := make(map[uint]bool)
+	for _, id := range ids {
+		player, ok := byId[id]
+		if !ok {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Player %v is not in the team", id))
+			return false
+		}
+		if seen[id] {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Player %v is picked more than once", id))
+			return false
+		}
+		seen[id] = true
+		if availability := player.Availability(now); availability != models.PlayerFit {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Player %v is %v", id, availability))
+			return false
+		}
+	}
+	return true
+}
+
+// Take a match off the suspensions of the players of a team inside a transaction
+func (c *Controller) serveSuspensionsIn(tx repos.Repository, teamId uint) error {
+	for _, p := range tx.GetPlayers(teamId) {
+		if p.SuspendedMatches == 0 {
+			continue
+		}
+		p.SuspendedMatches--
+		if err := tx.Update(&p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/utils/tests"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPlayerAvailability(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	tests.AssertEqual(t, models.Player{}.Availability(now), models.PlayerFit)
+	tests.AssertEqual(t, models.Player{InjuredUntil: &past}.Availability(now), models.PlayerFit)
+	tests.AssertEqual(t, models.Player{InjuredUntil: &future}.Availability(now), models.PlayerInjured)
+	tests.AssertEqual(t, models.Player{SuspendedMatches: 1}.Availability(now), models.PlayerSuspended)
+	tests.AssertEqual(t, models.Player{InjuredUntil: &future, SuspendedMatches: 1}.Availability(now), models.PlayerInjured)
+
+	c := Controller{}
+	injured := c.getTransferPayload(models.Transfer{Player: models.Player{InjuredUntil: &future}})
+	tests.AssertEqual(t, injured.Injured, true)
+	tests.AssertEqual(t, injured.Player.InjuredUntil, &future)
+	recovered := c.getTransferPayload(models.Transfer{Player: models.Player{InjuredUntil: &past}})
+	tests.AssertEqual(t, recovered.Injured, false)
+	tests.AssertEqual(t, recovered.Player.InjuredUntil == nil, true)
+}
+
+func TestValidateLineupRefusesUnavailablePlayers(t *testing.T) {
+	c := Controller{}
+	future := time.Now().Add(time.Hour)
+	squad := []models.Player{{}, {InjuredUntil: &future}, {SuspendedMatches: 2}}
+	for i := range squad {
+		squad[i].ID = uint(i + 1)
+	}
+	cases := []struct {
+		ids   []uint
+		valid bool
+	}{
+		{[]uint{1}, true},
+		{[]uint{}, true},
+		{[]uint{1, 2}, false},
+		{[]uint{3}, false},
+		{[]uint{1, 1}, false},
+		{[]uint{4}, false},
+	}
+	for _, tc := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		tests.AssertEqual(t, c.validateLineup(ctx, squad, tc.ids), tc.valid)
+	}
+}
+
+func TestSaveLineupChecksTheLockedPlayers(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{Name: "Team"}
+	tests.AssertEqual(t, repo.Create(&team), nil)
+	squad := []models.Player{{TeamID: team.ID}, {TeamID: team.ID}, {TeamID: team.ID, InLineup: true}}
+	for i := range squad {
+		tests.AssertEqual(t, repo.Create(&squad[i]), nil)
+	}
+	now := time.Now()
+
+	// The second player got injured after the lineup was validated
+	injured := squad[1]
+	future := now.Add(time.Hour)
+	injured.InjuredUntil = &future
+	tests.AssertEqual(t, repo.Update(&injured), nil)
+	err := c.saveLineupIn(repo, team.ID, repo.GetPlayers(team.ID), []uint{squad[0].ID, squad[1].ID}, now)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+	for _, p := range repo.GetPlayers(team.ID) {
+		tests.AssertEqual(t, p.InLineup, p.ID == squad[2].ID)
+	}
+
+	// The first player left the team after the lineup was validated
+	validated := repo.GetPlayers(team.ID)
+	gone := squad[0]
+	gone.TeamID = 0
+	tests.AssertEqual(t, repo.Update(&gone), nil)
+	err = c.saveLineupIn(repo, team.ID, validated, []uint{squad[0].ID}, now)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+
+	tests.AssertEqual(t, c.saveLineupIn(repo, team.ID, repo.GetPlayers(team.ID), []uint{squad[1].ID}, now.Add(2*time.Hour)), nil)
+	for _, p := range repo.GetPlayers(team.ID) {
+		tests.AssertEqual(t, p.InLineup, p.ID == squad[1].ID)
+	}
+}
+
+func TestServeSuspensions(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{}
+	_ = repo.Create(&team)
+	suspended := models.Player{TeamID: team.ID, SuspendedMatches: 2}
+	_ = repo.Create(&suspended)
+
+	for _, left := range []int{1, 0, 0} {
+		if err := c.serveSuspensionsIn(repo, team.ID); err != nil {
+			t.Fatal(err)
+		}
+		p, _ := repo.GetPlayer(suspended.ID)
+		tests.AssertEqual(t, p.SuspendedMatches, left)
+	}
+}
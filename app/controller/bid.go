@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handles POST requests to the bids resource of a transfer
+// @Summary Bid on an auctioned transfer
+// @Description Places a bid on an open auction. The bid must reach the reserve price, beat the highest bid and be covered by the funds the team has not committed to other bids.
+// @Tags Transfers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param bid body models.CreateBid true "Create bid"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/bids [post]
+// @Security BearerAuth
+func (c *Controller) CreateBid(ctx *gin.Context) {
+	transfer, err1 := c.getTransferFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	var t models.CreateBid
+	err := ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	if !transfer.IsAuction() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Transfer is not an auction")
+		return
+	}
+	if transfer.HasEnded(time.Now()) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Auction has already ended")
+		return
+	}
+	if transfer.Player.Team.UserID == user.ID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Cannot bid on your own player")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+
+	bidder, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if t.Amount < transfer.ReservePrice {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Bid is below the reserve price (%v < %v)", t.Amount, transfer.ReservePrice))
+		return
+	}
+	highest := transfer.HighestBid()
+	if highest != nil && t.Amount <= highest.Amount {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Bid must be higher than the current highest bid (%v)", highest.Amount))
+		return
+	}
+	if !c.validateSquadChange(ctx, bidder.ID, nil, &transfer.Player) {
+		return
+	}
+	if available := c.availableFunds(bidder, transfer.ID); available < t.Amount {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to place the bid (%v < %v)", available, t.Amount))
+		return
+	}
+
+	var bid models.Bid
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var err error
+		bid, err = c.placeBidIn(tx, transfer.ID, bidder.ID, t.Amount, time.Now())
+		return err
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": bid.ID,
+	})
+}
+
+// Place a bid on an auction inside a transaction. Like a sale the auction and the bidder are locked and checked
+// again, if the auction ended or was outbid in the meantime or the bidder can no longer cover it, it fails with errSaleConflict
+func (c *Controller) placeBidIn(tx repos.Repository, transferId uint, bidderId uint, amount int, now time.Time) (models.Bid, error) {
+	var locked models.Transfer
+	if err := tx.Lock(&locked, transferId); err != nil || !locked.IsAuction() || locked.HasEnded(now) {
+		return models.Bid{}, errSaleConflict
+	}
+	var highest *models.Bid
+	for _, b := range tx.GetBids(locked.ID) {
+		if b.Active {
+			highest = &b
+			break
+		}
+	}
+	if amount < locked.ReservePrice || (highest != nil && amount <= highest.Amount) {
+		return models.Bid{}, errSaleConflict
+	}
+	teams, err := c.lockTeams(tx, bidderId)
+	if err != nil || c.availableFundsIn(tx, teams[bidderId], locked.ID) < amount {
+		return models.Bid{}, errSaleConflict
+	}
+
+	// The outbid team gets its committed funds back
+	if highest != nil {
+		highest.Active = false
+		if err := tx.Update(highest); err != nil {
+			return models.Bid{}, err
+		}
+	}
+	bid := models.Bid{
+		TransferID: locked.ID,
+		TeamID:     bidderId,
+		Amount:     amount,
+		Active:     true,
+	}
+	return bid, tx.Create(&bid)
+}
+
+// Handles PUT requests to close an auction
+// @Summary Close an auction
+// @Description Closes an auction after its end time. The player is sold to the highest bidder that can still pay for it, otherwise the listing is withdrawn.
+// @Tags Transfers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/close [put]
+// @Security BearerAuth
+func (c *Controller) CloseAuction(ctx *gin.Context) {
+	transfer, err1 := c.getTransferFromRequest(ctx)
+	_, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	if !transfer.IsAuction() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Transfer is not an auction")
+		return
+	}
+	if !transfer.HasEnded(time.Now()) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Auction is still open")
+		return
+	}
+
+	sold, err := c.settleAuction(&transfer)
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"sold": sold,
+	})
+}
+
+// Settle every auction that stopped taking bids, run as a background job
+func (c *Controller) SettleAuctions() error {
+	for _, transfer := range c.Repo.GetEndedAuctions(time.Now()) {
+		if _, err := c.settleAuction(&transfer); err != nil && !errors.Is(err, errSaleConflict) {
+			// A conflict means it was closed by hand in the meantime
+			return err
+		}
+	}
+	return nil
+}
+
+// Sell an ended auction to the highest bidder that can still afford it and fits the player in its squad,
+// or withdraw it if nobody can
+func (c *Controller) settleAuction(transfer *models.Transfer) (bool, error) {
+	player := transfer.Player
+	if c.checkSquadChange(player.TeamID, &player, nil) != nil {
+		return false, c.withdrawAuction(transfer)
+	}
+	for _, bid := range c.Repo.GetBids(transfer.ID) {
+		buyer, err := c.Repo.GetTeam(bid.TeamID)
+		if err != nil || c.availableFunds(buyer, transfer.ID) < bid.Amount || c.checkSquadChange(buyer.ID, nil, &player) != nil {
+			continue
+		}
+		return true, c.doExecuteTransfer(transfer, buyer, bid.Amount)
+	}
+	return false, c.withdrawAuction(transfer)
+}
+
+// Take an auction nobody won off the market
+func (c *Controller) withdrawAuction(transfer *models.Transfer) error {
+	if err := c.Repo.DeleteTransfer(transfer); err != nil {
+		return err
+	}
+	c.publishTransferEvent(models.TransferEventWithdrawn, *transfer)
+	return nil
+}
+
+// Get the budget of a team minus the funds held by its bids on other auctions
+func (c *Controller) availableFunds(team models.Team, excludeTransferId uint) int {
+	return c.availableFundsIn(c.Repo, team, excludeTransferId)
+}
+
+// Get the funds of a team that are not committed to active bids, reading from a given repository
+func (c *Controller) availableFundsIn(repo repos.Repository, team models.Team, excludeTransferId uint) int {
+	available := team.Budget
+	for _, b := range repo.GetActiveBidsOfTeam(team.ID) {
+		if b.TransferID != excludeTransferId {
+			available -= b.Amount
+		}
+	}
+	return available
+}
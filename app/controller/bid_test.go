@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAvailableFundsExcludesCommittedBids(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{Budget: 1000}
+	team.ID = 7
+
+	bids := []models.Bid{
+		{TransferID: 1, TeamID: team.ID, Amount: 100, Active: true},
+		{TransferID: 2, TeamID: team.ID, Amount: 200, Active: true},
+		{TransferID: 3, TeamID: team.ID, Amount: 400, Active: false},
+		{TransferID: 4, TeamID: team.ID + 1, Amount: 300, Active: true},
+	}
+	for i := range bids {
+		_ = repo.Create(&bids[i])
+	}
+
+	tests.AssertEqual(t, c.availableFunds(team, 0), 700)
+	tests.AssertEqual(t, c.availableFunds(team, 2), 900)
+}
+
+func TestHighestBidIgnoresReleasedBids(t *testing.T) {
+	transfer := models.Transfer{
+		Mode: models.TransferModeAuction,
+		Bids: []models.Bid{
+			{Amount: 500, Active: false},
+			{Amount: 300, Active: true},
+		},
+	}
+	tests.AssertEqual(t, transfer.HighestBid().Amount, 300)
+	tests.AssertEqual(t, models.Transfer{}.HighestBid() == nil, true)
+}
+
+func TestSettleAuctionsSettlesEndedAuctions(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	ended := models.Transfer{PlayerID: 1, Ask: 1000, Mode: models.TransferModeAuction, EndsAt: &past}
+	open := models.Transfer{PlayerID: 2, Ask: 1000, Mode: models.TransferModeAuction, EndsAt: &future}
+	_ = repo.Create(&ended)
+	_ = repo.Create(&open)
+
+	query := repos.TransferQuery{
+		Filters:  repos.TransferFilters{MinAgeFilter: -1, MinValueFilter: -1, MaxAgeFilter: math.MaxInt32, MaxValueFilter: math.MaxInt32},
+		ActiveAt: time.Now(),
+	}
+	if _, total := repo.QueryTransfers(query); total != 1 {
+		t.Errorf("expected the ended auction to be off the market, got %v transfers", total)
+	}
+
+	if err := c.SettleAuctions(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetTransfer(ended.ID); err == nil {
+		t.Error("ended auction without bids was not withdrawn")
+	}
+	if _, err := repo.GetTransfer(open.ID); err != nil {
+		t.Error("open auction was settled")
+	}
+}
+
+func TestPlaceBidChecksTheLockedAuction(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	first, second := models.Team{Budget: 1000}, models.Team{Budget: 1000}
+	_ = repo.Create(&first)
+	_ = repo.Create(&second)
+	future := time.Now().Add(time.Hour)
+	auction := models.Transfer{PlayerID: 1, Mode: models.TransferModeAuction, ReservePrice: 100, EndsAt: &future}
+	_ = repo.Create(&auction)
+
+	if _, err := c.placeBidIn(repo, auction.ID, first.ID, 500, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// A bid that no longer beats the highest one loses the race
+	_, err := c.placeBidIn(repo, auction.ID, second.ID, 400, time.Now())
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+	if _, err := c.placeBidIn(repo, auction.ID, second.ID, 600, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	active := 0
+	for _, b := range repo.GetBids(auction.ID) {
+		if b.Active {
+			active++
+		}
+	}
+	tests.AssertEqual(t, active, 1)
+
+	// The auction ended or was settled in the meantime
+	_, err = c.placeBidIn(repo, auction.ID, first.ID, 700, future)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+	_ = repo.DeleteTransfer(&auction)
+	_, err = c.placeBidIn(repo, auction.ID, first.ID, 700, time.Now())
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
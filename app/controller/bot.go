@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"../bots"
+	"../httputil"
+	"../models"
+	"../repos"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Handles POST requests to the bot teams resource
+// @Summary Create a bot team
+// @Description Create a random team managed by a bot that buys and sells players on the market on its own
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /bot-teams [post]
+// @Security BearerAuth[admin]
+func (c *Controller) CreateBotTeam(ctx *gin.Context) {
+	team, err := c.Repo.CreateBotTeam()
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": team.ID,
+	})
+}
+
+// Let every bot team trade once on the market if it is open
+func (c *Controller) RunBots() error {
+	if c.Bots == nil {
+		return nil
+	}
+	if open, _, _ := c.getMarketStatus(time.Now()); !open {
+		return nil
+	}
+	listings, _ := c.Repo.QueryTransfers(repos.TransferQuery{
+		Filters:  c.parseTransferFilters(url.Values{}),
+		Sort:     repos.SortByCreatedAt,
+		ActiveAt: time.Now(),
+	})
+	for _, team := range c.Repo.GetBotTeams() {
+		// An admin froze the account of the bot
+		if user, err := c.Repo.GetUserById(team.UserID); err == nil && user.Frozen {
+			continue
+		}
+		if err := c.runBot(team, listings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decide what a bot team does with the strategy and run it, actions that break a rule are skipped
+func (c *Controller) runBot(team models.Team, listings []models.Transfer) error {
+	decision := c.Bots.Decide(c.getBotMarket(team, listings))
+
+	for _, sale := range decision.Sell {
+		player := sale.Player
+		if c.checkSquadChangesIn(c.Repo, team.ID, []models.Player{player}, nil) != nil {
+			continue
+		}
+		transfer := models.Transfer{PlayerID: player.ID, Ask: sale.Ask, Mode: models.TransferModeFixed}
+		err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+			return c.doCreateTransferIn(tx, &transfer, team.ID)
+		})
+		if errors.Is(err, errSaleConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.publishTransferEvent(models.TransferEventListed, transfer)
+		if err := c.notifyTransferListed(c.Repo, transfer.ID); err != nil {
+			log.Println(err)
+		}
+	}
+
+	for _, transfer := range decision.Buy {
+		player := transfer.Player
+		if c.checkSquadChangesIn(c.Repo, player.TeamID, []models.Player{player}, nil) != nil ||
+			c.checkSquadChangesIn(c.Repo, team.ID, nil, []models.Player{player}) != nil {
+			continue
+		}
+		err := c.doExecuteTransfer(&transfer, team, transfer.Ask)
+		if errors.Is(err, errSaleConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get what a bot team knows about the market from the open listings
+func (c *Controller) getBotMarket(team models.Team, listings []models.Transfer) bots.Market {
+	market := bots.Market{
+		Team:     team,
+		Squad:    c.Repo.GetPlayers(team.ID),
+		Sellable: make([]models.Player, 0),
+		Listings: make([]models.Transfer, 0),
+		Funds:    c.availableFunds(team, 0),
+		Rules:    c.Repo.GetSquadRules(),
+	}
+	for _, p := range market.Squad {
+		if _, err := c.Repo.GetTransferWithPlayer(&p); err == nil {
+			continue
+		}
+		if _, err := c.Repo.GetActiveLoanOfPlayer(p.ID); err == nil {
+			continue
+		}
+		market.Sellable = append(market.Sellable, p)
+	}
+	for _, t := range listings {
+		if t.IsAuction() || t.Player.TeamID == team.ID {
+			continue
+		}
+		market.Listings = append(market.Listings, t)
+	}
+	return market
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"../bots"
+	"../models"
+	"../repos"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestBotsBuyUndervaluedPlayersAndListSurplus(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo, Bots: bots.NewValueStrategy(1)}
+	bot, err := repo.CreateBotTeam()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seller := models.Team{}
+	_ = repo.Create(&seller)
+	player := models.Player{TeamID: seller.ID, Position: models.Attacker, MarketValue: 1000000}
+	_ = repo.Create(&player)
+	_ = repo.Create(&models.Transfer{PlayerID: player.ID, Player: player, Ask: 500000, Mode: models.TransferModeFixed})
+
+	if err := c.RunBots(); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := repo.GetPlayer(player.ID)
+	tests.AssertEqual(t, p.TeamID, bot.ID)
+	b, _ := repo.GetTeam(bot.ID)
+	tests.AssertEqual(t, b.Budget, models.DefaultTeamBudget-500000)
+
+	// The bot has an attacker more than its starting squad now, so it lists one
+	if err := c.RunBots(); err != nil {
+		t.Fatal(err)
+	}
+	listed := 0
+	for _, p := range repo.GetPlayers(bot.ID) {
+		if _, err := repo.GetTransferWithPlayer(&p); err == nil {
+			listed++
+		}
+	}
+	tests.AssertEqual(t, listed, 1)
+}
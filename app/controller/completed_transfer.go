@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Handles GET requests to the completed transfers resource
+// @Summary Show the completed transfers feed
+// @Description Show every completed sale, most recent first, paginated
+// @Tags Transfers
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page to show, starting from 1"
+// @Param page_size query int false "Amount of sales per page, defaults to 20 and can't exceed 100"
+// @Success 200 {array} models.ShowCompletedTransfer
+// @Failure 400 {object} httputil.HTTPError
+// @Router /completed-transfers [get]
+func (c *Controller) ListCompletedTransfers(ctx *gin.Context) {
+	page, pageSize, err := c.parsePageFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	history, total := c.Repo.GetCompletedTransfers((page-1)*pageSize, pageSize)
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"transfers": c.getCompletedTransfersPayload(history),
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+// Handles GET requests to the history of a player
+// @Summary Show the transfer history of a player
+// @Description Show every completed sale of a player, most recent first
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Success 200 {array} models.ShowCompletedTransfer
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /players/{id}/history [get]
+func (c *Controller) ShowPlayerHistory(ctx *gin.Context) {
+	player, err := c.getPlayerFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"transfers": c.getCompletedTransfersPayload(c.Repo.GetPlayerHistory(player.ID)),
+	})
+}
+
+// Handles GET requests to the completed transfers of a team
+// @Summary List the completed transfers of a team
+// @Description List every completed sale where the team was the seller or the buyer, most recent first
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Team ID"
+// @Success 200 {array} models.ShowCompletedTransfer
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /teams/{id}/transfers [get]
+func (c *Controller) ListTeamTransfers(ctx *gin.Context) {
+	team, err := c.getTeamFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"transfers": c.getCompletedTransfersPayload(c.Repo.GetTeamHistory(team.ID)),
+	})
+}
+
+// Parse the page and page size query parameters
+func (c *Controller) parsePageFromRequest(ctx *gin.Context) (int, int, error) {
+	page, pageSize := 1, defaultPageSize
+	if v := ctx.Query("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid page")
+			return 0, 0, fmt.Errorf("invalid page")
+		}
+		page = p
+	}
+	if v := ctx.Query("page_size"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 || p > maxPageSize {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid page_size")
+			return 0, 0, fmt.Errorf("invalid page_size")
+		}
+		pageSize = p
+	}
+	return page, pageSize, nil
+}
+
+// Get the show payloads of a list of completed transfers
+func (c *Controller) getCompletedTransfersPayload(history []models.CompletedTransfer) []models.ShowCompletedTransfer {
+	arr := make([]models.ShowCompletedTransfer, 0)
+	for _, t := range history {
+		arr = append(arr, models.ShowCompletedTransfer{
+			ID:           t.ID,
+			PlayerID:     t.PlayerID,
+			SellerTeamID: t.SellerTeamID,
+			BuyerTeamID:  t.BuyerTeamID,
+			Price:        t.Price,
+			ValueBefore:  t.ValueBefore,
+			ValueAfter:   t.ValueAfter,
+			CompletedAt:  t.CreatedAt,
+			LineItems:    c.getSaleLineItemsPayload(t.LineItems),
+		})
+	}
+	return arr
+}
+
+// Get the payload of the line items of a sale
+func (c *Controller) getSaleLineItemsPayload(items []models.SaleLineItem) []models.ShowSaleLineItem {
+	arr := make([]models.ShowSaleLineItem, 0)
+	for _, item := range items {
+		arr = append(arr, models.ShowSaleLineItem{
+			Kind:   item.Kind,
+			Amount: item.Amount,
+			TeamID: item.TeamID,
+		})
+	}
+	return arr
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Terms of a new contract agreed with a player
+type contractTerms struct {
+	wage   int
+	endsAt time.Time
+}
+
+// Handles GET requests to the contract of a player
+// @Summary Show the contract of a player
+// @Description Show the wage and length of the contract of a player along with the lowest wage it accepts for a new one
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Success 200 {object} models.ShowContract
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /players/{id}/contract [get]
+func (c *Controller) ShowContract(ctx *gin.Context) {
+	player, err := c.getPlayerFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	httputil.NoError(ctx, c.getContractPayload(player))
+}
+
+// Handles PUT requests to the contract of a player
+// @Summary Renew the contract of a player
+// @Description Offer a player of the team a new contract from now on. The player turns down wages below its asking wage.
+// @Description Players on loan negotiate with their parent team. The wages owed so far are paid at the previous wage.
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Param contract body models.RenewContract true "Contract terms"
+// @Success 200 {object} models.ShowContract
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /players/{id}/contract [put]
+// @Security BearerAuth
+func (c *Controller) RenewContract(ctx *gin.Context) {
+	player, err1 := c.getPlayerFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+	if player.IsFreeAgent() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Free agents have no contract to renew")
+		return
+	}
+	ownerId := player.TeamID
+	if loan, err := c.Repo.GetActiveLoanOfPlayer(player.ID); err == nil {
+		ownerId = loan.ParentTeamID
+	}
+	owner, err := c.Repo.GetTeam(ownerId)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !user.IsAdmin() && !c.validateTeamOwner(ctx, user, owner) {
+		return
+	}
+
+	var t models.RenewContract
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	now := time.Now()
+	terms := contractTerms{wage: t.WeeklyWage, endsAt: t.EndsAt}
+	if !c.validateContractTerms(ctx, player, terms, now) {
+		return
+	}
+
+	var renewed models.Player
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var err error
+		renewed, err = c.renewContractIn(tx, player.ID, terms, now)
+		return err
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoError(ctx, c.getContractPayload(renewed))
+}
+
+// Get the terms of the contract a player signs with its new team, writing the error if they are not accepted
+func (c *Controller) getNewContractTerms(ctx *gin.Context, player models.Player, now time.Time) (contractTerms, bool) {
+	var t models.ContractOffer
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&t); err != nil {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+			return contractTerms{}, false
+		}
+	}
+	terms := contractTerms{wage: t.WeeklyWage, endsAt: now.AddDate(models.DefaultContractYears, 0, 0)}
+	if terms.wage == 0 {
+		terms.wage = player.AskingWage()
+	}
+	if t.ContractEndsAt != nil {
+		terms.endsAt = *t.ContractEndsAt
+	}
+	return terms, c.validateContractTerms(ctx, player, terms, now)
+}
+
+// Validate a player accepts the terms of a new contract starting at a time and write the error if not
+func (c *Controller) validateContractTerms(ctx *gin.Context, player models.Player, terms contractTerms, now time.Time) bool {
+	if terms.endsAt.Before(now.Add(models.MinContractLength)) || terms.endsAt.After(now.AddDate(models.MaxContractYears, 0, 0)) {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Contracts have to last between a week and %v years", models.MaxContractYears))
+		return false
+	}
+	if asking := player.AskingWage(); terms.wage < asking {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("%v %v asks for at least %v a week", player.FirstName, player.LastName, asking))
+		return false
+	}
+	return true
+}
+
+// Have a player sign a new contract with its team inside a transaction. The wages up to now have to be settled
+// already, as a sale does, since the new contract owes wages from its start
+func (c *Controller) signContractIn(tx repos.Repository, playerId uint, terms contractTerms, now time.Time) error {
+	var player models.Player
+	if err := tx.Lock(&player, playerId); err != nil {
+		return errSaleConflict
+	}
+	player.SignContract(terms.wage, now, terms.endsAt)
+	return tx.Update(&player)
+}
+
+// Renew the contract of a player inside a transaction. The whole weeks owed so far are paid at the previous wage
+// by the team the player plays for, the rest of the week is paid at the new wage.
+func (c *Controller) renewContractIn(tx repos.Repository, playerId uint, terms contractTerms, now time.Time) (models.Player, error) {
+	var player models.Player
+	if err := tx.Lock(&player, playerId); err != nil || player.IsFreeAgent() {
+		return player, errSaleConflict
+	}
+	teams, err := c.lockTeams(tx, player.TeamID)
+	if err != nil {
+		return player, errSaleConflict
+	}
+	team := teams[player.TeamID]
+
+	team.Budget -= player.PayOwedWages(now)
+	player.WeeklyWage = terms.wage
+	player.ContractStartsAt = &now
+	player.ContractEndsAt = &terms.endsAt
+	err1 := tx.Update(&player)
+	err2 := tx.Update(&team)
+	if err1 != nil || err2 != nil {
+		return player, fmt.Errorf("failed to save models")
+	}
+	return player, nil
+}
+
+// Pay the wages owed to the players and release the players whose contract expired, run as a background job
+func (c *Controller) RunPayroll() error {
+	now := time.Now()
+	owed := make(map[uint][]uint)
+	expired := make([]models.Player, 0)
+	for _, p := range c.Repo.GetAllPlayers() {
+		if p.IsFreeAgent() {
+			continue
+		}
+		if p.WeeksOwed(now) > 0 {
+			owed[p.TeamID] = append(owed[p.TeamID], p.ID)
+		}
+		if p.ContractExpired(now) {
+			expired = append(expired, p)
+		}
+	}
+
+	teamIds := make([]uint, 0, len(owed))
+	for id := range owed {
+		teamIds = append(teamIds, id)
+	}
+	sort.Slice(teamIds, func(i, j int) bool { return teamIds[i] < teamIds[j] })
+	for _, id := range teamIds {
+		if err := c.payWages(id, owed[id], now); err != nil {
+			return err
+		}
+	}
+	// Wages are paid before the players leave, so the last weeks of a contract are not lost
+	for _, p := range expired {
+		if err := c.expireContract(p, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Take the whole weeks of wages owed to some players of a team out of its budget
+func (c *Controller) payWages(teamId uint, playerIds []uint, now time.Time) error {
+	return c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		// Lock in the same order as a sale, the players before the team
+		players := make([]models.Player, 0, len(playerIds))
+		for _, id := range playerIds {
+			var player models.Player
+			if err := tx.Lock(&player, id); err != nil {
+				// It retired in the meantime
+				continue
+			}
+			players = append(players, player)
+		}
+		teams, err := c.lockTeams(tx, teamId)
+		if err != nil {
+			return err
+		}
+		team := teams[teamId]
+
+		for _, p := range players {
+			if p.TeamID != teamId || p.WeeksOwed(now) == 0 {
+				// It moved or got paid by another run in the meantime
+				continue
+			}
+			team.Budget -= p.PayOwedWages(now)
+			if err := tx.Update(&p); err != nil {
+				return err
+			}
+		}
+		return tx.Update(&team)
+	})
+}
+
+// Release a player whose contract expired into the free agent pool and notify the team it left
+func (c *Controller) expireContract(player models.Player, now time.Time) error {
+	released, err := c.releasePlayer(player, func(p models.Player) bool {
+		// It may have renewed in the meantime
+		return p.ContractExpired(now)
+	}, func(tx repos.Repository, p models.Player, ownerId uint) error {
+		owner, err := tx.GetTeam(ownerId)
+		if err != nil {
+			return nil
+		}
+		return c.notifyContractExpired(tx, p, owner)
+	})
+	if err == nil && released {
+		log.Printf("Contract of player %v expired, it is now a free agent", player.ID)
+	}
+	return err
+}
+
+// Get the contract payload of a player
+func (c *Controller) getContractPayload(player models.Player) models.ShowContract {
+	return models.ShowContract{
+		PlayerID:   player.ID,
+		TeamID:     player.TeamID,
+		WeeklyWage: player.WeeklyWage,
+		StartsAt:   player.ContractStartsAt,
+		EndsAt:     player.ContractEndsAt,
+		AskingWage: player.AskingWage(),
+	}
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/utils/tests"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunPayroll(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	owner := models.User{}
+	_ = repo.Create(&owner)
+	team := models.Team{Budget: 1000, UserID: owner.ID}
+	_ = repo.Create(&team)
+	now := time.Now()
+
+	// Owed two weeks of wages
+	signed := models.Player{TeamID: team.ID}
+	signed.SignContract(10, now.Add(-15*24*time.Hour), now.AddDate(1, 0, 0))
+	_ = repo.Create(&signed)
+	// Owed the last week before its contract ended
+	leaving := models.Player{TeamID: team.ID, InLineup: true}
+	leaving.SignContract(30, now.Add(-15*24*time.Hour), now.Add(-7*24*time.Hour-time.Hour))
+	_ = repo.Create(&leaving)
+	listing := models.Transfer{PlayerID: leaving.ID, Ask: 1000}
+	_ = repo.Create(&listing)
+
+	if err := c.RunPayroll(); err != nil {
+		t.Fatal(err)
+	}
+	paid, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, paid.Budget, 1000-2*10-30)
+	after, _ := repo.GetPlayer(signed.ID)
+	tests.AssertEqual(t, after.WagesPaidUntil.Equal(signed.WagesPaidUntil.Add(2*models.WageWeek)), true)
+
+	released, _ := repo.GetPlayer(leaving.ID)
+	tests.AssertEqual(t, released.IsFreeAgent(), true)
+	tests.AssertEqual(t, released.InLineup, false)
+	tests.AssertEqual(t, released.ContractEndsAt == nil, true)
+	if _, err := repo.GetTransfer(listing.ID); err == nil {
+		t.Error("the listing of the released player is still on the market")
+	}
+	notifications := repo.GetNotifications(owner.ID, true)
+	tests.AssertEqual(t, len(notifications), 1)
+	tests.AssertEqual(t, notifications[0].Kind, models.NotificationContractExpired)
+
+	// Nothing is owed until another week passes
+	if err := c.RunPayroll(); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, again.Budget, paid.Budget)
+}
+
+func TestRenewContractPaysOwedWagesAtThePreviousWage(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{Budget: 1000}
+	_ = repo.Create(&team)
+	now := time.Now()
+	player := models.Player{TeamID: team.ID}
+	player.SignContract(10, now.Add(-15*24*time.Hour), now.AddDate(1, 0, 0))
+	_ = repo.Create(&player)
+
+	renewed, err := c.renewContractIn(repo, player.ID, contractTerms{wage: 50, endsAt: now.AddDate(2, 0, 0)}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, renewed.WeeklyWage, 50)
+	tests.AssertEqual(t, renewed.WeeksOwed(now), 0)
+	paid, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, paid.Budget, 1000-2*10)
+}
+
+func TestSaleChargesTheSellerTheWagesUpToTheSale(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	seller, buyer := models.Team{Budget: 1000}, models.Team{Budget: 1000}
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	now := time.Now()
+	player := models.Player{TeamID: seller.ID, MarketValue: 1000}
+	player.SignContract(70, now.Add(-10*24*time.Hour), now.AddDate(1, 0, 0))
+	_ = repo.Create(&player)
+
+	if err := c.doExecuteSaleIn(repo, player.ID, nil, buyer.ID, 500); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := repo.GetTeam(seller.ID)
+	tests.AssertEqual(t, s.Budget, 1000+500-10*10)
+	sold, _ := repo.GetPlayer(player.ID)
+	tests.AssertEqual(t, sold.WeeksOwed(time.Now().Add(6*24*time.Hour)), 0)
+}
+
+func TestValidateContractTerms(t *testing.T) {
+	c := Controller{}
+	player := models.Player{MarketValue: 1000000}
+	now := time.Now()
+	asking := player.AskingWage()
+	tests.AssertEqual(t, asking, 2000)
+
+	for _, tt := range []struct {
+		terms contractTerms
+		valid bool
+	}{
+		{contractTerms{asking, now.AddDate(2, 0, 0)}, true},
+		{contractTerms{asking - 1, now.AddDate(2, 0, 0)}, false},
+		{contractTerms{asking, now.Add(24 * time.Hour)}, false},
+		{contractTerms{asking, now.AddDate(models.MaxContractYears+1, 0, 0)}, false},
+	} {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		tests.AssertEqual(t, c.validateContractTerms(ctx, player, tt.terms, now), tt.valid)
+	}
+}
@@ -1,23 +1,46 @@
 package controller
 
 import (
+	"../bots"
+	"../collusion"
+	"../feed"
 	"../httputil"
 	"../models"
 	"../repos"
+	"../season"
+	"../valuation"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // Controller example
 type Controller struct {
 	Repo repos.Repository
+	// Computes the market value of players after a sale, the default model is used when nil
+	Valuation valuation.Model
+	// Decides how bot teams trade, bot teams don't trade when nil
+	Bots bots.Strategy
+	// Thresholds used when scanning the sales for collusion, nothing is scanned with the zero value
+	Collusion collusion.Config
+	// Publishes the changes of the listings to the live feed, nothing is published when nil
+	Feed *feed.Broker
+	// Retirement age and scheduling of the season rollovers
+	Season season.Config
 }
 
 // Return a new controller with a given repository
 func NewController(repo repos.Repository) *Controller {
-	return &Controller{Repo: repo}
+	return &Controller{
+		Repo:      repo,
+		Valuation: valuation.NewDefaultModel(),
+		Bots:      bots.NewValueStrategy(time.Now().UnixNano()),
+		Collusion: collusion.DefaultConfig(),
+		Feed:      feed.NewBroker(feed.DefaultHistory),
+		Season:    season.DefaultConfig(),
+	}
 }
 
 // Get the user the request got authenticated with
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// Handles GET requests to the economic rules resource
+// @Summary Show the economic rules
+// @Description Show the market tax, listing fee, sell-on percentage, listing lifetime and release clause bounds the league applies on the transfer market
+// @Tags Economic rules
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.ShowEconomicRules
+// @Router /economic-rules [get]
+func (c *Controller) ShowEconomicRules(ctx *gin.Context) {
+	httputil.NoError(ctx, c.getEconomicRulesPayload(c.Repo.GetEconomicRules()))
+}
+
+// Handles PATCH requests to the economic rules resource
+// @Summary Update the economic rules
+// @Description Update the market tax, listing fee, sell-on percentage, listing lifetime and release clause bounds the league applies on the transfer market
+// @Tags Economic rules
+// @Accept  json
+// @Produce  json
+// @Param rules body models.ShowEconomicRules true "Economic rules"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /economic-rules [patch]
+// @Security BearerAuth[admin]
+func (c *Controller) UpdateEconomicRules(ctx *gin.Context) {
+	rules := c.Repo.GetEconomicRules()
+	t := c.getEconomicRulesPayload(rules)
+	err := ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	rules.MarketTaxPercent = t.MarketTaxPercent
+	rules.ListingFee = t.ListingFee
+	rules.SellOnPercent = t.SellOnPercent
+	rules.ListingLifetimeHours = t.ListingLifetimeHours
+	rules.MinReleaseClauseMultiple = t.MinReleaseClauseMultiple
+	rules.MaxReleaseClauseMultiple = t.MaxReleaseClauseMultiple
+	if !c.validEconomicRules(rules) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Fees, lifetimes and release clause bounds can't be negative, percentages can't add up to more than 100 and the minimum release clause can't be above the maximum")
+		return
+	}
+
+	err = c.Repo.Update(&rules)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles GET requests to the treasury resource
+// @Summary Show the league treasury
+// @Description Show the money the league collected through taxes and fees
+// @Tags Economic rules
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.ShowTreasury
+// @Failure 401 {object} httputil.HTTPError
+// @Router /treasury [get]
+// @Security BearerAuth[admin]
+func (c *Controller) ShowTreasury(ctx *gin.Context) {
+	totals := c.Repo.GetTreasuryTotals()
+	balance := 0
+	for _, amount := range totals {
+		balance += amount
+	}
+	httputil.NoError(ctx, models.ShowTreasury{
+		Balance: balance,
+		Totals:  totals,
+	})
+}
+
+// Get the club that sold a player to its current team, 0 if the player was never bought by it
+func (c *Controller) getPreviousClubIn(repo repos.Repository, player models.Player) uint {
+	history := repo.GetPlayerHistory(player.ID)
+	if len(history) == 0 || history[0].BuyerTeamID != player.TeamID || history[0].SellerTeamID == player.TeamID {
+		return 0
+	}
+	if _, err := repo.GetTeam(history[0].SellerTeamID); err != nil {
+		return 0
+	}
+	return history[0].SellerTeamID
+}
+
+// Returns a bool that tells if the economic rules are consistent
+func (c *Controller) validEconomicRules(r models.EconomicRules) bool {
+	return r.MarketTaxPercent >= 0 && r.SellOnPercent >= 0 && r.ListingFee >= 0 && r.ListingLifetimeHours >= 0 &&
+		r.MarketTaxPercent+r.SellOnPercent <= 100 && r.MinReleaseClauseMultiple >= 0 && r.MaxReleaseClauseMultiple >= 0 &&
+		(r.MaxReleaseClauseMultiple == 0 || r.MinReleaseClauseMultiple <= r.MaxReleaseClauseMultiple)
+}
+
+// Get the economic rules payload
+func (c *Controller) getEconomicRulesPayload(r models.EconomicRules) models.ShowEconomicRules {
+	return models.ShowEconomicRules{
+		MarketTaxPercent:         r.MarketTaxPercent,
+		ListingFee:               r.ListingFee,
+		SellOnPercent:            r.SellOnPercent,
+		ListingLifetimeHours:     r.ListingLifetimeHours,
+		MinReleaseClauseMultiple: r.MinReleaseClauseMultiple,
+		MaxReleaseClauseMultiple: r.MaxReleaseClauseMultiple,
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestExecuteTransferSplitsThePrice(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	_ = repo.Create(&models.EconomicRules{MarketTaxPercent: 10, SellOnPercent: 5})
+	previous, seller, buyer := models.Team{}, models.Team{}, models.Team{Budget: 1000}
+	_ = repo.Create(&previous)
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	player := models.Player{TeamID: seller.ID, MarketValue: 1000}
+	_ = repo.Create(&player)
+	_ = repo.Create(&models.CompletedTransfer{PlayerID: player.ID, SellerTeamID: previous.ID, BuyerTeamID: seller.ID})
+	transfer := models.Transfer{PlayerID: player.ID, Player: player, Ask: 1000, ListingFee: 30}
+	_ = repo.Create(&transfer)
+
+	if err := c.doExecuteTransfer(&transfer, buyer, 1000); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := repo.GetTeam(previous.ID)
+	s, _ := repo.GetTeam(seller.ID)
+	b, _ := repo.GetTeam(buyer.ID)
+	tests.AssertEqual(t, p.Budget, 50)
+	tests.AssertEqual(t, s.Budget, 850)
+	tests.AssertEqual(t, b.Budget, 0)
+	tests.AssertEqual(t, repo.GetTreasuryTotals()[models.TreasuryMarketTax], 100)
+
+	history := repo.GetPlayerHistory(player.ID)
+	items := make(map[string]models.SaleLineItem)
+	for _, item := range history[0].LineItems {
+		items[item.Kind] = item
+	}
+	tests.AssertEqual(t, items[models.LineItemMarketTax].Amount, 100)
+	tests.AssertEqual(t, items[models.LineItemSellOn].Amount, 50)
+	tests.AssertEqual(t, items[models.LineItemSellOn].TeamID, previous.ID)
+	tests.AssertEqual(t, items[models.LineItemSellerNet].Amount, 850)
+	tests.AssertEqual(t, items[models.LineItemListingFee].Amount, 30)
+}
+
+func TestCreateTransferChargesListingFee(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	_ = repo.Create(&models.EconomicRules{ListingFee: 300})
+	team := models.Team{Budget: 500}
+	_ = repo.Create(&team)
+	player := models.Player{TeamID: team.ID}
+	_ = repo.Create(&player)
+
+	transfer := models.Transfer{PlayerID: player.ID, Ask: 1000}
+	if err := c.doCreateTransferIn(repo, &transfer, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	tm, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, tm.Budget, 200)
+	tests.AssertEqual(t, repo.GetTreasuryTotals()[models.TreasuryListingFee], 300)
+
+	// The team can't afford a second listing
+	other := models.Player{TeamID: team.ID}
+	_ = repo.Create(&other)
+	err := c.doCreateTransferIn(repo, &models.Transfer{PlayerID: other.ID, Ask: 1000}, team.ID)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
+
+func TestValidEconomicRules(t *testing.T) {
+	c := Controller{}
+	tests.AssertEqual(t, c.validEconomicRules(models.EconomicRules{MarketTaxPercent: 5, SellOnPercent: 10, ListingFee: 100}), true)
+	tests.AssertEqual(t, c.validEconomicRules(models.EconomicRules{MarketTaxPercent: 60, SellOnPercent: 50}), false)
+	tests.AssertEqual(t, c.validEconomicRules(models.EconomicRules{MarketTaxPercent: -1}), false)
+	tests.AssertEqual(t, c.validEconomicRules(models.EconomicRules{ListingFee: -1}), false)
+}
+
+func TestEconomicRulesAllowsReleaseClause(t *testing.T) {
+	unbounded := models.EconomicRules{}
+	tests.AssertEqual(t, unbounded.AllowsReleaseClause(1, 1000), true)
+
+	rules := models.EconomicRules{MinReleaseClauseMultiple: 1.5, MaxReleaseClauseMultiple: 3}
+	tests.AssertEqual(t, rules.AllowsReleaseClause(1499, 1000), false)
+	tests.AssertEqual(t, rules.AllowsReleaseClause(1500, 1000), true)
+	tests.AssertEqual(t, rules.AllowsReleaseClause(3000, 1000), true)
+	tests.AssertEqual(t, rules.AllowsReleaseClause(3001, 1000), false)
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"../feed"
+	"../httputil"
+	"../models"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Comments sent to idle Server-Sent Events streams so proxies don't close them
+const feedHeartbeat = 30 * time.Second
+
+// Handles GET requests to the live market feed
+// @Summary Stream market events
+// @Description Push an event every time a listing is created, re-priced, sold, withdrawn or expires, only for the listings that match the filters.
+// @Description Events are sent as Server-Sent Events, or as JSON messages when the request upgrades to a WebSocket.
+// @Description A client that reconnects gets the events it missed by sending the id of the last event it got.
+// @Tags Transfers
+// @Produce  text/event-stream
+// @Param country query string false "Filter by the player's country"
+// @Param team_name query string false "Filter by the player's team name"
+// @Param player_name query string false "Filter by the player's complete name"
+// @Param min_age query string false "Filter by the player's age"
+// @Param max_age query string false "Filter by the player's age"
+// @Param min_value query string false "Filter by the transfer ask value"
+// @Param max_value query string false "Filter by the transfer ask value"
+// @Param min_overall query int false "Filter by the player's overall rating"
+// @Param max_overall query int false "Filter by the player's overall rating"
+// @Param value_type query string false "Type of value to filter by. Can be 'market' or 'ask'. Defaults to 'ask'"
+// @Param last_event_id query int false "Resume after this event, the Last-Event-ID header takes precedence"
+// @Success 200 {object} models.ShowTransferEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 503 {object} httputil.HTTPError
+// @Router /transfers/stream [get]
+func (c *Controller) StreamTransfers(ctx *gin.Context) {
+	if c.Feed == nil {
+		httputil.NewError(ctx, http.StatusServiceUnavailable, "Live feed is not available")
+		return
+	}
+	lastId, err := parseLastEventId(ctx)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid last event id")
+		return
+	}
+	filters := c.parseTransferFilters(ctx.Request.URL.Query())
+	missed, sub := c.Feed.Subscribe(lastId, func(e feed.Event) bool {
+		return filters.Matches(e.Transfer)
+	})
+	defer sub.Close()
+
+	if ctx.IsWebsocket() {
+		c.streamWebSocket(ctx, missed, sub)
+	} else {
+		c.streamEvents(ctx, missed, sub)
+	}
+}
+
+// Write the events as Server-Sent Events until the client goes away or the feed closes
+func (c *Controller) streamEvents(ctx *gin.Context, missed []feed.Event, sub *feed.Subscription) {
+	w := ctx.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, "retry: 3000\n\n")
+	for _, e := range missed {
+		if writeServerSentEvent(w, c.getTransferEventPayload(e)) != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if writeServerSentEvent(w, c.getTransferEventPayload(e)) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Request.Context().Done():
+			return
+		}
+		w.Flush()
+	}
+}
+
+// Write an event in the Server-Sent Events format
+func writeServerSentEvent(w io.Writer, payload models.ShowTransferEvent) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", payload.ID, payload.Kind, data)
+	return err
+}
+
+// Upgrade the request and send every event as a JSON message until the client goes away or the feed closes
+func (c *Controller) streamWebSocket(ctx *gin.Context, missed []feed.Event, sub *feed.Subscription) {
+	// The feed is public and read only, so any origin can open it
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		gone := make(chan bool)
+		go func() {
+			// Clients don't send anything, reading only tells when they close the connection
+			_, _ = io.Copy(ioutil.Discard, ws)
+			close(gone)
+		}()
+		for _, e := range missed {
+			if websocket.JSON.Send(ws, c.getTransferEventPayload(e)) != nil {
+				return
+			}
+		}
+		for {
+			select {
+			case e, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if websocket.JSON.Send(ws, c.getTransferEventPayload(e)) != nil {
+					return
+				}
+			case <-gone:
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// Parse the id of the last event a client got from the Last-Event-ID header or the last_event_id parameter, zero if there is none
+func parseLastEventId(ctx *gin.Context) (uint64, error) {
+	v := ctx.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = ctx.Query("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// Publish a change of a listing on the live feed
+func (c *Controller) publishTransferEvent(kind string, transfer models.Transfer) {
+	if c.Feed == nil {
+		return
+	}
+	c.Feed.Publish(feed.Event{Kind: kind, Transfer: c.getFeedListing(transfer)})
+}
+
+// Publish the sale of a listing on the live feed, the listing has to be loaded with getFeedListing before the sale
+func (c *Controller) publishTransferSold(listing models.Transfer, buyerId uint, price int) {
+	if c.Feed == nil {
+		return
+	}
+	c.Feed.Publish(feed.Event{Kind: models.TransferEventSold, Transfer: listing, Price: price, BuyerTeamID: buyerId})
+}
+
+// Load the player and the team of a listing when they are missing, subscribers filter events by them
+func (c *Controller) getFeedListing(transfer models.Transfer) models.Transfer {
+	if transfer.Player.ID == 0 {
+		transfer.Player, _ = c.Repo.GetPlayer(transfer.PlayerID)
+	}
+	if transfer.Player.Team.ID == 0 && transfer.Player.TeamID != 0 {
+		transfer.Player.Team, _ = c.Repo.GetTeam(transfer.Player.TeamID)
+	}
+	return transfer
+}
+
+// Get the payload of a feed event
+func (c *Controller) getTransferEventPayload(e feed.Event) models.ShowTransferEvent {
+	return models.ShowTransferEvent{
+		ID:          e.ID,
+		Kind:        e.Kind,
+		Transfer:    c.getTransferPayload(e.Transfer),
+		Price:       e.Price,
+		BuyerTeamID: e.BuyerTeamID,
+		At:          e.At,
+	}
+}
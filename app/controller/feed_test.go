@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"../feed"
+	"../models"
+	"../repos"
+	"bufio"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSalePublishesTheListingAsItWas(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo, Feed: feed.NewBroker(10)}
+	seller, buyer := models.Team{Name: "sellers", Budget: 0}, models.Team{Name: "buyers", Budget: 1000}
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	player := models.Player{TeamID: seller.ID, MarketValue: 1000}
+	_ = repo.Create(&player)
+	transfer := models.Transfer{PlayerID: player.ID, Ask: 500}
+	_ = repo.Create(&transfer)
+
+	filters := c.parseTransferFilters(map[string][]string{"team_name": {"sellers"}})
+	_, sub := c.Feed.Subscribe(0, func(e feed.Event) bool { return filters.Matches(e.Transfer) })
+	defer sub.Close()
+	if err := c.doExecuteTransfer(&transfer, buyer, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-sub.Events():
+		tests.AssertEqual(t, e.Kind, models.TransferEventSold)
+		tests.AssertEqual(t, e.Price, 500)
+		tests.AssertEqual(t, e.BuyerTeamID, buyer.ID)
+		tests.AssertEqual(t, e.Transfer.Player.TeamID, seller.ID)
+	default:
+		t.Fatal("the sale was not published")
+	}
+}
+
+func TestStreamTransfersResumesFromTheLastEvent(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo, Feed: feed.NewBroker(10)}
+	listed := c.Feed.Publish(feed.Event{Kind: models.TransferEventListed, Transfer: models.Transfer{Ask: 500}})
+	repriced := c.Feed.Publish(feed.Event{Kind: models.TransferEventRepriced, Transfer: models.Transfer{Ask: 400}})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", c.StreamTransfers)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(listed.ID, 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	tests.AssertEqual(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	expected := []string{"id: " + strconv.FormatUint(repriced.ID, 10), "event: " + models.TransferEventRepriced}
+	for len(expected) > 0 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("the stream ended")
+			}
+			if strings.HasPrefix(line, "id:") || strings.HasPrefix(line, "event:") {
+				tests.AssertEqual(t, line, expected[0])
+				expected = expected[1:]
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the missed event was not sent")
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"../collusion"
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handles GET requests to the review queue of suspicious sales
+// @Summary List the flags raised on suspicious sales
+// @Description List the flags raised by the collusion scans, most recent first. Only the open ones unless a status is given.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param status query string false "open, dismissed, frozen or all, defaults to open"
+// @Success 200 {array} models.ShowFlag
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Router /admin/flags [get]
+// @Security BearerAuth[admin]
+func (c *Controller) ListFlags(ctx *gin.Context) {
+	status := ctx.DefaultQuery("status", models.FlagOpen)
+	switch status {
+	case "all":
+		status = ""
+	case models.FlagOpen, models.FlagDismissed, models.FlagFrozen:
+	default:
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	arr := make([]models.ShowFlag, 0)
+	for _, flag := range c.Repo.GetFlags(status) {
+		arr = append(arr, c.getFlagPayload(flag))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"flags": arr,
+	})
+}
+
+// Handles POST requests to scan the sales for collusion
+// @Summary Scan the sales for collusion
+// @Description Scan the recent sales for suspicious patterns right away instead of waiting for the background job, returns how many flags were raised
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/flags/scan [post]
+// @Security BearerAuth[admin]
+func (c *Controller) ScanFlags(ctx *gin.Context) {
+	created, err := c.scanForCollusion(time.Now())
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"created": created,
+	})
+}
+
+// Handles PUT requests to dismiss a flag
+// @Summary Dismiss a flag
+// @Description An admin reviewed the flag and found nothing wrong
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Flag ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/flags/{id}/dismiss [put]
+// @Security BearerAuth[admin]
+func (c *Controller) DismissFlag(ctx *gin.Context) {
+	admin, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	flag, err := c.getOpenFlagFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	flag.Status = models.FlagDismissed
+	flag.ReviewedBy = admin.ID
+	if err := c.Repo.Update(&flag); err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles PUT requests to freeze the accounts behind a flag
+// @Summary Freeze the accounts of a flag
+// @Description Freeze the account owning one of the teams of the flag, or every account of the flag when no team is given. Frozen accounts can't make authenticated requests until an admin unfreezes them.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Flag ID"
+// @Param freeze body models.FreezeFlag false "Team whose account is frozen"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/flags/{id}/freeze [put]
+// @Security BearerAuth[admin]
+func (c *Controller) FreezeFlag(ctx *gin.Context) {
+	admin, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	flag, err := c.getOpenFlagFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	var t models.FreezeFlag
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&t); err != nil {
+			httputil.NewError(ctx, http.StatusBadRequest, "Incorrect body parameters")
+			return
+		}
+	}
+	teams := flag.TeamIDs()
+	if t.TeamID != 0 {
+		if t.TeamID != flag.TeamID && t.TeamID != flag.OtherTeamID {
+			httputil.NewError(ctx, http.StatusBadRequest, "Team is not part of the flag")
+			return
+		}
+		teams = []uint{t.TeamID}
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		for _, teamId := range teams {
+			if err := c.freezeTeamOwnerIn(tx, teamId); err != nil {
+				return err
+			}
+		}
+		flag.Status = models.FlagFrozen
+		flag.ReviewedBy = admin.ID
+		return tx.Update(&flag)
+	})
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Scan the recent sales for collusion, runs as a background job
+func (c *Controller) ScanForCollusion() error {
+	_, err := c.scanForCollusion(time.Now())
+	return err
+}
+
+// Scan the sales made within the configured window and raise a flag for every new finding, returns how many were raised
+func (c *Controller) scanForCollusion(now time.Time) (int, error) {
+	if c.Collusion.Window <= 0 {
+		return 0, nil
+	}
+	sales := c.Repo.GetCompletedTransfersSince(now.Add(-c.Collusion.Window))
+	created := 0
+	for _, finding := range collusion.Scan(sales, c.getCollusionAccounts(sales), c.Collusion) {
+		if _, err := c.Repo.GetFlagByKey(finding.Key); err == nil {
+			continue
+		}
+		flag := models.Flag{
+			Key:                 finding.Key,
+			Kind:                finding.Kind,
+			Status:              models.FlagOpen,
+			TeamID:              finding.TeamID,
+			OtherTeamID:         finding.OtherTeamID,
+			PlayerID:            finding.PlayerID,
+			CompletedTransferID: finding.CompletedTransferID,
+			Details:             finding.Details,
+		}
+		if err := c.Repo.Create(&flag); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// Get the owners of the teams that took part of the sales, teams that no longer exist are left out
+func (c *Controller) getCollusionAccounts(sales []models.CompletedTransfer) map[uint]collusion.Account {
+	accounts := make(map[uint]collusion.Account)
+	for _, s := range sales {
+		for _, teamId := range []uint{s.SellerTeamID, s.BuyerTeamID} {
+			if _, ok := accounts[teamId]; ok {
+				continue
+			}
+			team, err := c.Repo.GetTeam(teamId)
+			if err != nil {
+				continue
+			}
+			user, err := c.Repo.GetUserById(team.UserID)
+			if err != nil {
+				continue
+			}
+			accounts[teamId] = collusion.Account{
+				UserID:   user.ID,
+				Email:    user.Email,
+				SignupIP: user.SignupIP,
+				Bot:      team.IsBot,
+			}
+		}
+	}
+	return accounts
+}
+
+// Freeze the account owning a team
+func (c *Controller) freezeTeamOwnerIn(tx repos.Repository, teamId uint) error {
+	team, err := tx.GetTeam(teamId)
+	if err != nil {
+		// The team was deleted since the flag was raised
+		return nil
+	}
+	user, err := tx.GetUserById(team.UserID)
+	if err != nil {
+		return nil
+	}
+	user.Frozen = true
+	return tx.Update(&user)
+}
+
+// Parse an open flag from the request parameters or return an error if not found
+func (c *Controller) getOpenFlagFromRequest(ctx *gin.Context) (models.Flag, error) {
+	id, err := c.parseIdFromRequest(ctx, "flagId")
+	if err != nil {
+		return models.Flag{}, err
+	}
+	flag, err := c.Repo.GetFlag(id)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Flag not found")
+		return models.Flag{}, err
+	}
+	if flag.Status != models.FlagOpen {
+		httputil.NewError(ctx, http.StatusBadRequest, "Flag is "+flag.Status)
+		return models.Flag{}, fmt.Errorf("flag was already reviewed")
+	}
+	return flag, nil
+}
+
+// Get the flag payload
+func (c *Controller) getFlagPayload(flag models.Flag) models.ShowFlag {
+	return models.ShowFlag{
+		ID:                  flag.ID,
+		Kind:                flag.Kind,
+		Status:              flag.Status,
+		TeamID:              flag.TeamID,
+		OtherTeamID:         flag.OtherTeamID,
+		PlayerID:            flag.PlayerID,
+		CompletedTransferID: flag.CompletedTransferID,
+		Details:             flag.Details,
+		ReviewedBy:          flag.ReviewedBy,
+		CreatedAt:           flag.CreatedAt,
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"../collusion"
+	"../models"
+	"../repos"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+	"time"
+)
+
+func TestScanForCollusionRaisesEachFlagOnce(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo, Collusion: collusion.DefaultConfig()}
+	seller, _ := repo.CreateUser("seller@test.com", []byte{}, 0)
+	buyer, _ := repo.CreateUser("buyer@test.com", []byte{}, 0)
+	sellerTeam, _ := repo.GetUserTeam(seller)
+	buyerTeam, _ := repo.GetUserTeam(buyer)
+	_ = repo.Create(&models.CompletedTransfer{PlayerID: 1, SellerTeamID: sellerTeam.ID, BuyerTeamID: buyerTeam.ID, Price: 5000, ValueBefore: 1000})
+	old := models.CompletedTransfer{PlayerID: 2, SellerTeamID: sellerTeam.ID, BuyerTeamID: buyerTeam.ID, Price: 5000, ValueBefore: 1000}
+	old.CreatedAt = time.Now().AddDate(0, 0, -60)
+	_ = repo.Create(&old)
+
+	created, err := c.scanForCollusion(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, created, 1)
+	flags := repo.GetFlags(models.FlagOpen)
+	tests.AssertEqual(t, len(flags), 1)
+	tests.AssertEqual(t, flags[0].Kind, models.FlagInflatedPrice)
+	tests.AssertEqual(t, flags[0].TeamID, sellerTeam.ID)
+
+	created, _ = c.scanForCollusion(time.Now())
+	tests.AssertEqual(t, created, 0)
+}
+
+func TestFreezeTeamOwner(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	user, _ := repo.CreateUser("alt@test.com", []byte{}, 0)
+	team, _ := repo.GetUserTeam(user)
+
+	if err := c.freezeTeamOwnerIn(repo, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	frozen, _ := repo.GetUserById(user.ID)
+	tests.AssertEqual(t, frozen.Frozen, true)
+	// Teams deleted since the flag was raised are skipped
+	tests.AssertEqual(t, c.freezeTeamOwnerIn(repo, team.ID+1000), nil)
+}
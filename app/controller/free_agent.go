@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handles GET requests to the free agent pool
+// @Summary Show the free agents
+// @Description Show the players that belong to no team and filter them like the transfers. The signing fee takes the place of the ask.
+// @Tags Free agents
+// @Accept  json
+// @Produce  json
+// @Param country query string false "Filter by the player's country"
+// @Param player_name query string false "Filter by the player's complete name"
+// @Param min_age query string false "Filter by the player's age"
+// @Param max_age query string false "Filter by the player's age"
+// @Param min_value query string false "Filter by the signing fee"
+// @Param max_value query string false "Filter by the signing fee"
+// @Param min_overall query int false "Filter by the player's overall rating"
+// @Param max_overall query int false "Filter by the player's overall rating"
+// @Param value_type query string false "Type of value to filter by. Can be 'market' or 'ask'. Defaults to 'ask'"
+// @Param available_only query bool false "Leave out injured and suspended players"
+// @Success 200 {array} models.ShowFreeAgent
+// @Router /free-agents [get]
+func (c *Controller) ListFreeAgents(ctx *gin.Context) {
+	filters := c.parseTransferFilters(ctx.Request.URL.Query())
+
+	arr := make([]models.ShowFreeAgent, 0)
+	for _, p := range c.Repo.GetFreeAgents(filters) {
+		arr = append(arr, c.getFreeAgentPayload(p))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"free_agents": arr,
+	})
+}
+
+// Handles POST requests to sign a free agent
+// @Summary Sign a free agent
+// @Description Add a free agent to the team of the user. The signing fee is paid from the budget of the team to the league treasury and
+// @Description the player signs a contract, turning down wages below its asking wage.
+// @Tags Free agents
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Param contract body models.ContractOffer false "Contract terms"
+// @Success 200 {object} models.ShowContract
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /free-agents/{id}/sign [post]
+// @Security BearerAuth
+func (c *Controller) SignFreeAgent(ctx *gin.Context) {
+	player, err1 := c.getPlayerFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+	if !player.IsFreeAgent() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player is not a free agent")
+		return
+	}
+	team, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if !c.validateSquadChange(ctx, team.ID, nil, &player) {
+		return
+	}
+	fee := player.SigningFee()
+	if available := c.availableFunds(team, 0); available < fee {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to sign the player (%v < %v)", available, fee))
+		return
+	}
+	now := time.Now()
+	terms, ok := c.getNewContractTerms(ctx, player, now)
+	if !ok {
+		return
+	}
+
+	var signed models.Player
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var err error
+		signed, err = c.signFreeAgentIn(tx, player.ID, team.ID, fee, terms, now)
+		return err
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoError(ctx, c.getContractPayload(signed))
+}
+
+// Handles POST requests to release a player
+// @Summary Release a player
+// @Description Move a player into the free agent pool instead of deleting it. Its contract ends, its loan ends and
+// @Description it is taken off the market.
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /players/{id}/release [post]
+// @Security BearerAuth[admin]
+func (c *Controller) ReleasePlayer(ctx *gin.Context) {
+	player, err := c.getPlayerFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if player.IsFreeAgent() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player is already a free agent")
+		return
+	}
+	if !c.validateSquadChange(ctx, player.TeamID, &player, nil) {
+		return
+	}
+
+	if _, err := c.releasePlayer(player, nil, nil); err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Add a free agent to a team inside a transaction, the team pays the signing fee to the treasury and the player signs a contract
+func (c *Controller) signFreeAgentIn(tx repos.Repository, playerId uint, teamId uint, fee int, terms contractTerms, now time.Time) (models.Player, error) {
+	var player models.Player
+	if err := tx.Lock(&player, playerId); err != nil || !player.IsFreeAgent() {
+		return player, errSaleConflict
+	}
+	teams, err := c.lockTeams(tx, teamId)
+	if err != nil {
+		return player, errSaleConflict
+	}
+	team := teams[teamId]
+	if c.availableFundsIn(tx, team, 0) < fee {
+		return player, errSaleConflict
+	}
+
+	team.Budget -= fee
+	movePlayer(&player, team)
+	player.SignContract(terms.wage, now, terms.endsAt)
+	err1 := tx.Update(&player)
+	err2 := tx.Update(&team)
+	if err1 != nil || err2 != nil {
+		return player, fmt.Errorf("failed to save models")
+	}
+	// Free agents have no seller, the fee goes to the league treasury
+	return player, tx.Create(&models.TreasuryEntry{Kind: models.TreasurySigningFee, Amount: fee, TeamID: teamId})
+}
+
+// Release a player into the free agent pool in a single transaction. Its listing is withdrawn and it is detached
+// from its teams, then the given function runs in the same transaction with the team that owned it. Nothing
+// changes when the locked player is already a free agent or fails the check, then false is returned.
+// The check and the function can be nil.
+func (c *Controller) releasePlayer(player models.Player, check func(models.Player) bool, then func(tx repos.Repository, released models.Player, ownerId uint) error) (bool, error) {
+	released := false
+	var withdrawn *models.Transfer
+	err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		// Lock in the same order as a sale, starting with the listing of the player if it has one
+		released, withdrawn = false, nil
+		var listing *models.Transfer
+		if open, err := tx.GetTransferWithPlayer(&player); err == nil {
+			var locked models.Transfer
+			if err := tx.Lock(&locked, open.ID); err != nil {
+				return errSaleConflict
+			}
+			listing = &locked
+		}
+		var locked models.Player
+		if err := tx.Lock(&locked, player.ID); err != nil || locked.IsFreeAgent() || (check != nil && !check(locked)) {
+			// It retired, left or changed in the meantime
+			return nil
+		}
+
+		if listing != nil {
+			listing.Player = locked
+			event := c.getFeedListing(*listing)
+			withdrawn = &event
+			if err := tx.DeleteTransfer(listing); err != nil {
+				return err
+			}
+		}
+		ownerId, err := c.detachPlayerIn(tx, locked)
+		if err != nil {
+			return err
+		}
+		locked.ReleaseToFreeAgency()
+		if err := tx.Update(&locked); err != nil {
+			return err
+		}
+		released = true
+		if then == nil {
+			return nil
+		}
+		return then(tx, locked, ownerId)
+	})
+	if err != nil {
+		return false, err
+	}
+	if withdrawn != nil {
+		c.publishTransferEvent(models.TransferEventWithdrawn, *withdrawn)
+	}
+	return released, nil
+}
+
+// Get the payload of a player in the free agent pool
+func (c *Controller) getFreeAgentPayload(p models.Player) models.ShowFreeAgent {
+	return models.ShowFreeAgent{
+		ShowPlayer: c.getPlayerPayload(p),
+		SigningFee: p.SigningFee(),
+		AskingWage: p.AskingWage(),
+	}
+}
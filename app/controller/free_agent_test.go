@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+	"time"
+)
+
+func TestSignFreeAgent(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{Budget: 200000}
+	_ = repo.Create(&team)
+	player := models.Player{MarketValue: 1000000}
+	_ = repo.Create(&player)
+	now := time.Now()
+	terms := contractTerms{wage: player.AskingWage(), endsAt: now.AddDate(2, 0, 0)}
+
+	signed, err := c.signFreeAgentIn(repo, player.ID, team.ID, player.SigningFee(), terms, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, signed.TeamID, team.ID)
+	tests.AssertEqual(t, signed.WeeklyWage, terms.wage)
+	paid, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, paid.Budget, 200000-100000)
+	tests.AssertEqual(t, repo.GetTreasuryTotals()[models.TreasurySigningFee], 100000)
+
+	// Someone else signed the player first
+	_, err = c.signFreeAgentIn(repo, player.ID, team.ID, player.SigningFee(), terms, now)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
+
+func TestReleasePlayerIntoFreeAgency(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team := models.Team{}
+	_ = repo.Create(&team)
+	player := models.Player{TeamID: team.ID, ReleaseClause: 5000000}
+	player.SignRandomContract(time.Now())
+	_ = repo.Create(&player)
+	listing := models.Transfer{PlayerID: player.ID, Ask: 1000}
+	_ = repo.Create(&listing)
+
+	released, err := c.releasePlayer(player, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, released, true)
+	agent, _ := repo.GetPlayer(player.ID)
+	tests.AssertEqual(t, agent.IsFreeAgent(), true)
+	tests.AssertEqual(t, agent.ReleaseClause, 0)
+	tests.AssertEqual(t, agent.WeeklyWage, 0)
+	if _, err := repo.GetTransfer(listing.ID); err == nil {
+		t.Error("the listing of the released player is still on the market")
+	}
+
+	// Releasing a free agent changes nothing
+	released, err = c.releasePlayer(agent, nil, nil)
+	tests.AssertEqual(t, err, nil)
+	tests.AssertEqual(t, released, false)
+}
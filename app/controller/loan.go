@@ -0,0 +1,315 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handles GET requests to the loans resource
+// @Summary Show a loan
+// @Description Get a loan by ID
+// @Tags Loans
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Loan ID"
+// @Success 200 {object} models.ShowLoan
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /loans/{id} [get]
+func (c *Controller) ShowLoan(ctx *gin.Context) {
+	loan, err := c.getLoanFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	httputil.NoError(ctx, c.getLoanPayload(loan))
+}
+
+// Handles POST requests to the loans resource
+// @Summary Propose a loan
+// @Description Proposes lending a player to another team until a given time. The borrowing team has to accept the loan and pay the fee.
+// @Tags Loans
+// @Accept  json
+// @Produce  json
+// @Param loan body models.CreateLoan true "Create loan"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /loans [post]
+// @Security BearerAuth
+func (c *Controller) CreateLoan(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateLoan
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil || t.Fee < 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if !t.EndsAt.After(time.Now()) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Loans need an end time in the future")
+		return
+	}
+
+	player, err := c.Repo.GetPlayer(t.PlayerID)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Player not found")
+		return
+	}
+	if !user.IsAdmin() && player.Team.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to loan a player not owned")
+		return
+	}
+	if player.TeamID == t.TeamID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Cannot loan a player to its own team")
+		return
+	}
+	if player.InAcademy {
+		httputil.NewError(ctx, http.StatusBadRequest, "Academy players have to be promoted before they are loaned")
+		return
+	}
+	if _, err := c.Repo.GetTeam(t.TeamID); err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return
+	}
+	if _, err := c.Repo.GetActiveLoanOfPlayer(player.ID); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player is already on loan")
+		return
+	}
+	if _, err := c.Repo.GetTransferWithPlayer(&player); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player has an open transfer")
+		return
+	}
+
+	loan := models.Loan{
+		PlayerID:       player.ID,
+		ParentTeamID:   player.TeamID,
+		BorrowerTeamID: t.TeamID,
+		Fee:            t.Fee,
+		EndsAt:         t.EndsAt,
+		Status:         models.LoanPending,
+	}
+	err = c.Repo.Create(&loan)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": loan.ID,
+	})
+}
+
+// Handles PUT requests to accept a loan
+// @Summary Accept a loan
+// @Description The borrowing team accepts a proposed loan, pays the fee and gets the player until the loan ends
+// @Tags Loans
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Loan ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /loans/{id}/accept [put]
+// @Security BearerAuth
+func (c *Controller) AcceptLoan(ctx *gin.Context) {
+	loan, err1 := c.getLoanFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	borrower, err := c.Repo.GetTeam(loan.BorrowerTeamID)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return
+	}
+	if borrower.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Only the borrowing team can accept a loan")
+		return
+	}
+	if loan.Status != models.LoanPending || !loan.EndsAt.After(time.Now()) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Loan can no longer be accepted")
+		return
+	}
+
+	player := loan.Player
+	if player.TeamID != loan.ParentTeamID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player no longer belongs to the lending team")
+		return
+	}
+	if _, err := c.Repo.GetTransferWithPlayer(&player); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player has an open transfer")
+		return
+	}
+	if !c.validateSaleSquadRules(ctx, player, borrower.ID) {
+		return
+	}
+	if available := c.availableFunds(borrower, 0); available < loan.Fee {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to pay the loan fee (%v < %v)", available, loan.Fee))
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		// Lock in the same order as a sale and check everything again
+		var locked models.Loan
+		if err := tx.Lock(&locked, loan.ID); err != nil || locked.Status != models.LoanPending {
+			return errSaleConflict
+		}
+		var player models.Player
+		if err := tx.Lock(&player, locked.PlayerID); err != nil || player.TeamID != locked.ParentTeamID {
+			return errSaleConflict
+		}
+		teams, err := c.lockTeams(tx, locked.ParentTeamID, locked.BorrowerTeamID)
+		if err != nil {
+			return errSaleConflict
+		}
+		parent, borrower := teams[locked.ParentTeamID], teams[locked.BorrowerTeamID]
+		if c.availableFundsIn(tx, borrower, 0) < locked.Fee {
+			return errSaleConflict
+		}
+
+		parent.Budget += locked.Fee
+		borrower.Budget -= locked.Fee
+		player.TeamID = borrower.ID
+		player.Team = borrower
+		player.InLineup = false
+		locked.Status = models.LoanActive
+
+		err1 := tx.Update(&player)
+		err2 := tx.Update(&parent)
+		err3 := tx.Update(&borrower)
+		err4 := tx.Update(&locked)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return fmt.Errorf("failed to save models")
+		}
+		return nil
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles DELETE requests to the loans resource
+// @Summary Cancel a loan proposal
+// @Description Cancels a loan that was not accepted yet
+// @Tags Loans
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Loan ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /loans/{id} [delete]
+// @Security BearerAuth
+func (c *Controller) DeleteLoan(ctx *gin.Context) {
+	loan, err1 := c.getLoanFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	if !user.IsAdmin() && loan.Player.Team.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to cancel a not owned loan")
+		return
+	}
+	if loan.Status != models.LoanPending {
+		httputil.NewError(ctx, http.StatusBadRequest, "Only loans that were not accepted can be cancelled")
+		return
+	}
+
+	err := c.Repo.Delete(&loan)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Return every player whose loan ended to its parent team
+func (c *Controller) ReturnExpiredLoans() error {
+	for _, loan := range c.Repo.GetExpiredLoans(time.Now()) {
+		if err := c.returnLoan(loan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send a loaned player back to its parent team and close the loan
+func (c *Controller) returnLoan(loan models.Loan) error {
+	return c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var locked models.Loan
+		if err := tx.Lock(&locked, loan.ID); err != nil || locked.Status != models.LoanActive {
+			// It was closed in the meantime
+			return nil
+		}
+		locked.Status = models.LoanReturned
+		if err := tx.Update(&locked); err != nil {
+			return err
+		}
+
+		var player models.Player
+		if err := tx.Lock(&player, locked.PlayerID); err != nil || player.TeamID != locked.BorrowerTeamID {
+			// The player was deleted or left the borrower while on loan
+			return nil
+		}
+		parent, err := tx.GetTeam(locked.ParentTeamID)
+		if err != nil {
+			// The parent team no longer exists so the player stays where it is
+			return nil
+		}
+		movePlayer(&player, parent)
+		return tx.Update(&player)
+	})
+}
+
+// Gets a loan model from the id in the request
+func (c *Controller) getLoanFromRequest(ctx *gin.Context) (models.Loan, error) {
+	id, err := c.parseIdFromRequest(ctx, "loanId")
+	if err != nil {
+		return models.Loan{}, err
+	}
+
+	loan, err := c.Repo.GetLoan(id)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Loan not found")
+		return models.Loan{}, err
+	}
+	return loan, nil
+}
+
+// Get a show loan payload from a loan
+func (c *Controller) getLoanPayload(loan models.Loan) models.ShowLoan {
+	return models.ShowLoan{
+		ID:             loan.ID,
+		PlayerID:       loan.PlayerID,
+		ParentTeamID:   loan.ParentTeamID,
+		BorrowerTeamID: loan.BorrowerTeamID,
+		Fee:            loan.Fee,
+		EndsAt:         loan.EndsAt,
+		Status:         loan.Status,
+	}
+}
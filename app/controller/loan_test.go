@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+	"time"
+)
+
+func TestGetTeamPlayersPayloadMarksLoans(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	team, players := models.RandomTeam()
+	team.ID = 1
+	for i := range players {
+		players[i].ID = uint(i + 1)
+		players[i].TeamID = team.ID
+	}
+
+	loanedIn := players[0]
+	loanedOut := models.RandomPlayer(3)
+	loanedOut.ID = 100
+	loanedOut.TeamID = 2
+	_ = repo.Create(&models.Loan{PlayerID: loanedIn.ID, Player: loanedIn, ParentTeamID: 3, BorrowerTeamID: team.ID, Status: models.LoanActive})
+	_ = repo.Create(&models.Loan{PlayerID: loanedOut.ID, Player: loanedOut, ParentTeamID: team.ID, BorrowerTeamID: 2, Status: models.LoanActive})
+	_ = repo.Create(&models.Loan{PlayerID: 200, ParentTeamID: team.ID, BorrowerTeamID: 2, Status: models.LoanPending})
+
+	payload := c.getTeamPlayersPayload(team, players)
+	tests.AssertEqual(t, len(payload), len(players)+1)
+	tests.AssertEqual(t, payload[0].Loan, models.LoanedIn)
+	tests.AssertEqual(t, payload[1].Loan, "")
+	tests.AssertEqual(t, payload[len(payload)-1].ID, loanedOut.ID)
+	tests.AssertEqual(t, payload[len(payload)-1].Loan, models.LoanedOut)
+}
+
+func TestReturnLoanSkipsPlayersThatLeftTheBorrower(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	parent, borrower, buyer := models.Team{}, models.Team{}, models.Team{}
+	_ = repo.Create(&parent)
+	_ = repo.Create(&borrower)
+	_ = repo.Create(&buyer)
+	loaned := models.Player{TeamID: borrower.ID, InLineup: true}
+	sold := models.Player{TeamID: buyer.ID}
+	_ = repo.Create(&loaned)
+	_ = repo.Create(&sold)
+	ended := time.Now().Add(-time.Minute)
+	back := models.Loan{PlayerID: loaned.ID, ParentTeamID: parent.ID, BorrowerTeamID: borrower.ID, Status: models.LoanActive, EndsAt: ended}
+	gone := models.Loan{PlayerID: sold.ID, ParentTeamID: parent.ID, BorrowerTeamID: borrower.ID, Status: models.LoanActive, EndsAt: ended}
+	_ = repo.Create(&back)
+	_ = repo.Create(&gone)
+
+	if err := c.ReturnExpiredLoans(); err != nil {
+		t.Fatal(err)
+	}
+	returned, _ := repo.GetPlayer(loaned.ID)
+	tests.AssertEqual(t, returned.TeamID, parent.ID)
+	tests.AssertEqual(t, returned.InLineup, false)
+	stayed, _ := repo.GetPlayer(sold.ID)
+	tests.AssertEqual(t, stayed.TeamID, buyer.ID)
+	closed, _ := repo.GetLoan(gone.ID)
+	tests.AssertEqual(t, closed.Status, models.LoanReturned)
+
+	// A loan that was already closed is left alone
+	stayed.TeamID = borrower.ID
+	_ = repo.Update(&stayed)
+	if err := c.returnLoan(gone); err != nil {
+		t.Fatal(err)
+	}
+	stayed, _ = repo.GetPlayer(sold.ID)
+	tests.AssertEqual(t, stayed.TeamID, borrower.ID)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Days of sales the market stats cover by default
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// Handles GET requests to the market stats resource
+// @Summary Show market stats
+// @Description Show the median and average ask of the open listings and price of the recent sales grouped by position, age band and country, along with the sell-through rate, the time to sale and a daily price index. Takes the same filters as the transfers listing.
+// @Tags Market
+// @Accept  json
+// @Produce  json
+// @Param country query string false "Filter by the player's country"
+// @Param team_name query string false "Filter by the player's team name, the seller's for sales"
+// @Param player_name query string false "Filter by the player's complete name"
+// @Param min_age query string false "Filter by the player's age"
+// @Param max_age query string false "Filter by the player's age"
+// @Param min_value query string false "Filter by the transfer ask value or the sale price"
+// @Param max_value query string false "Filter by the transfer ask value or the sale price"
+// @Param min_overall query int false "Filter by the player's overall rating"
+// @Param max_overall query int false "Filter by the player's overall rating"
+// @Param value_type query string false "Type of value to filter by. Can be 'market' or 'ask'. Defaults to 'ask'"
+// @Param days query int false "Days of sales to take into account. Defaults to 30, at most 365"
+// @Success 200 {object} models.ShowMarketStats
+// @Failure 400 {object} httputil.HTTPError
+// @Router /market/stats [get]
+func (c *Controller) ShowMarketStats(ctx *gin.Context) {
+	q := ctx.Request.URL.Query()
+	days := defaultStatsDays
+	if v := q.Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > maxStatsDays {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = d
+	}
+
+	now := time.Now()
+	query := repos.MarketStatsQuery{
+		Filters:  c.parseTransferFilters(q),
+		Since:    now.AddDate(0, 0, -days),
+		ActiveAt: now,
+	}
+	httputil.NoError(ctx, c.getMarketStatsPayload(query, c.Repo.GetMarketStats(query)))
+}
+
+// Get the market stats payload
+func (c *Controller) getMarketStatsPayload(query repos.MarketStatsQuery, stats repos.MarketStats) models.ShowMarketStats {
+	index := make([]models.ShowPriceIndexPoint, 0)
+	for _, p := range stats.PriceIndex {
+		index = append(index, models.ShowPriceIndexPoint{
+			Day:   p.Day.Format("2006-01-02"),
+			Index: p.Index,
+			Sales: p.Sales,
+		})
+	}
+	return models.ShowMarketStats{
+		Since:           query.Since,
+		Asks:            c.getPriceStatsPayload(stats.Asks),
+		Sales:           c.getPriceStatsPayload(stats.Sales),
+		ByPosition:      c.getMarketGroupsPayload(stats.ByPosition),
+		ByAgeBand:       c.getMarketGroupsPayload(stats.ByAgeBand),
+		ByCountry:       c.getMarketGroupsPayload(stats.ByCountry),
+		SellThroughRate: stats.SellThroughRate,
+		HoursToSale:     c.getPriceStatsPayload(stats.HoursToSale),
+		PriceIndex:      index,
+	}
+}
+
+// Get the payload of the stats of some groups
+func (c *Controller) getMarketGroupsPayload(groups []repos.MarketGroupStats) []models.ShowMarketGroupStats {
+	arr := make([]models.ShowMarketGroupStats, 0)
+	for _, g := range groups {
+		arr = append(arr, models.ShowMarketGroupStats{
+			Key:   g.Key,
+			Asks:  c.getPriceStatsPayload(g.Asks),
+			Sales: c.getPriceStatsPayload(g.Sales),
+		})
+	}
+	return arr
+}
+
+// Get the payload of the stats of some prices
+func (c *Controller) getPriceStatsPayload(s repos.PriceStats) models.ShowPriceStats {
+	return models.ShowPriceStats{
+		Count:   s.Count,
+		Average: s.Average,
+		Median:  s.Median,
+	}
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// Handles GET requests to the notifications of the logged in user
+// @Summary Show my notifications
+// @Description Show the notification inbox of the logged in user, most recent first
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param unread query bool false "Only show the notifications that were not read"
+// @Success 200 {array} models.ShowNotification
+// @Failure 401 {object} httputil.HTTPError
+// @Router /me/notifications [get]
+// @Security BearerAuth
+func (c *Controller) ListMyNotifications(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	arr := make([]models.ShowNotification, 0)
+	for _, n := range c.Repo.GetNotifications(user.ID, ctx.Query("unread") == "true") {
+		arr = append(arr, c.getNotificationPayload(n))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"notifications": arr,
+		"unread":        len(c.Repo.GetNotifications(user.ID, true)),
+	})
+}
+
+// Handles PUT requests to read a notification
+// @Summary Mark a notification as read
+// @Description Mark a notification of the logged in user as read
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Notification ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/notifications/{id}/read [put]
+// @Security BearerAuth
+func (c *Controller) ReadMyNotification(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	id, err := c.parseIdFromRequest(ctx, "notificationId")
+	if err != nil {
+		return
+	}
+
+	notification, err := c.Repo.GetNotification(id)
+	if err != nil || notification.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	notification.Read = true
+	err = c.Repo.Update(&notification)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles PUT requests to read every notification
+// @Summary Mark all my notifications as read
+// @Description Mark every notification of the logged in user as read
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Success 200
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/notifications [put]
+// @Security BearerAuth
+func (c *Controller) ReadAllMyNotifications(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		for _, n := range tx.GetNotifications(user.ID, true) {
+			n.Read = true
+			if err := tx.Update(&n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Notify the owners of the saved searches a new transfer matches and the users watching its player
+func (c *Controller) notifyTransferListed(repo repos.Repository, transferId uint) error {
+	transfer, err := repo.GetTransfer(transferId)
+	if err != nil {
+		return err
+	}
+	player := transfer.Player
+	notified := map[uint]bool{player.Team.UserID: true}
+
+	watched := models.Notification{
+		Kind:       models.NotificationWatchedListed,
+		Message:    fmt.Sprintf("%v %v was listed for %v", player.FirstName, player.LastName, transfer.Ask),
+		PlayerID:   player.ID,
+		TransferID: transfer.ID,
+	}
+	if err := c.notifyWatchers(repo, player.ID, notified, watched); err != nil {
+		return err
+	}
+
+	for _, search := range repo.GetAllSavedSearches() {
+		filters := c.getSavedSearchFilters(search)
+		if notified[search.UserID] || !filters.Matches(transfer) {
+			continue
+		}
+		notified[search.UserID] = true
+		err := repo.Create(&models.Notification{
+			UserID:     search.UserID,
+			Kind:       models.NotificationSearchMatch,
+			Message:    fmt.Sprintf("%v %v was listed for %v and matches your search '%v'", player.FirstName, player.LastName, transfer.Ask, search.Name),
+			PlayerID:   player.ID,
+			TransferID: transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notify the users watching the player of a transfer that its ask changed
+func (c *Controller) notifyTransferRepriced(repo repos.Repository, transfer models.Transfer, previousAsk int) error {
+	player := transfer.Player
+	return c.notifyWatchers(repo, player.ID, map[uint]bool{player.Team.UserID: true}, models.Notification{
+		Kind:       models.NotificationWatchedRepriced,
+		Message:    fmt.Sprintf("%v %v was re-priced from %v to %v", player.FirstName, player.LastName, previousAsk, transfer.Ask),
+		PlayerID:   player.ID,
+		TransferID: transfer.ID,
+	})
+}
+
+// Notify the users watching a player that it was sold, except the teams involved in the sale
+func (c *Controller) notifyPlayerSold(repo repos.Repository, player models.Player, seller, buyer models.Team, price int) error {
+	return c.notifyWatchers(repo, player.ID, map[uint]bool{seller.UserID: true, buyer.UserID: true}, models.Notification{
+		Kind:     models.NotificationWatchedSold,
+		Message:  fmt.Sprintf("%v %v was sold to %v for %v", player.FirstName, player.LastName, buyer.Name, price),
+		PlayerID: player.ID,
+	})
+}
+
+// Tell the owner of a player that its listing expired
+func (c *Controller) notifyListingExpired(repo repos.Repository, player models.Player, team models.Team, transferId uint) error {
+	return repo.Create(&models.Notification{
+		UserID:     team.UserID,
+		Kind:       models.NotificationListingExpired,
+		Message:    fmt.Sprintf("The listing of %v %v expired", player.FirstName, player.LastName),
+		PlayerID:   player.ID,
+		TransferID: transferId,
+	})
+}
+
+// Tell the owner of a team that a player left it when its contract expired
+func (c *Controller) notifyContractExpired(repo repos.Repository, player models.Player, team models.Team) error {
+	return repo.Create(&models.Notification{
+		UserID:   team.UserID,
+		Kind:     models.NotificationContractExpired,
+		Message:  fmt.Sprintf("The contract of %v %v expired and the player left the team", player.FirstName, player.LastName),
+		PlayerID: player.ID,
+	})
+}
+
+// Send a copy of a notification to every user watching a player that was not notified yet
+func (c *Controller) notifyWatchers(repo repos.Repository, playerId uint, notified map[uint]bool, notification models.Notification) error {
+	for _, w := range repo.GetPlayerWatchers(playerId) {
+		if notified[w.UserID] {
+			continue
+		}
+		notified[w.UserID] = true
+		n := notification
+		n.UserID = w.UserID
+		if err := repo.Create(&n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get a show notification payload from a notification
+func (c *Controller) getNotificationPayload(notification models.Notification) models.ShowNotification {
+	return models.ShowNotification{
+		ID:         notification.ID,
+		Kind:       notification.Kind,
+		Message:    notification.Message,
+		PlayerID:   notification.PlayerID,
+		TransferID: notification.TransferID,
+		Read:       notification.Read,
+		CreatedAt:  notification.CreatedAt,
+	}
+}
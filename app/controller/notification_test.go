@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"gorm.io/gorm/utils/tests"
+	"math"
+	"testing"
+)
+
+func TestNotifyTransferListed(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	player := models.Player{FirstName: "tito", Age: 20, Team: models.Team{UserID: 1}}
+	_ = repo.Create(&player)
+	transfer := models.Transfer{PlayerID: player.ID, Player: player, Ask: 1000}
+	_ = repo.Create(&transfer)
+
+	searches := []models.SavedSearch{
+		// Matches
+		{UserID: 2, MinAge: -1, MaxAge: 21, MinValue: -1, MaxValue: math.MaxInt32},
+		// Too young
+		{UserID: 3, MinAge: 25, MaxAge: math.MaxInt32, MinValue: -1, MaxValue: math.MaxInt32},
+		// The seller doesn't get alerted about its own player
+		{UserID: 1, MinAge: -1, MaxAge: math.MaxInt32, MinValue: -1, MaxValue: math.MaxInt32},
+		// Also watches the player, only gets a single notification
+		{UserID: 4, MinAge: -1, MaxAge: math.MaxInt32, MinValue: -1, MaxValue: math.MaxInt32},
+	}
+	for i := range searches {
+		_ = repo.Create(&searches[i])
+	}
+	_ = repo.Create(&models.WatchedPlayer{UserID: 4, PlayerID: player.ID})
+
+	if err := c.notifyTransferListed(repo, transfer.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests.AssertEqual(t, len(repo.GetNotifications(1, false)), 0)
+	tests.AssertEqual(t, len(repo.GetNotifications(3, false)), 0)
+	matched := repo.GetNotifications(2, false)
+	tests.AssertEqual(t, len(matched), 1)
+	tests.AssertEqual(t, matched[0].Kind, models.NotificationSearchMatch)
+	watched := repo.GetNotifications(4, false)
+	tests.AssertEqual(t, len(watched), 1)
+	tests.AssertEqual(t, watched[0].Kind, models.NotificationWatchedListed)
+}
+
+func TestSavedSearchPayloadHidesUnboundedFilters(t *testing.T) {
+	c := Controller{}
+	payload := c.getSavedSearchPayload(models.SavedSearch{MinAge: -1, MaxAge: 23, MinValue: -1, MaxValue: math.MaxInt32})
+	tests.AssertEqual(t, payload.MinAge == nil, true)
+	tests.AssertEqual(t, *payload.MaxAge, 23)
+	tests.AssertEqual(t, payload.MaxValue == nil, true)
+}
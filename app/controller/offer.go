@@ -0,0 +1,374 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Time an offer stays open since its last change
+const offerLifetime = 48 * time.Hour
+
+// Handles GET requests to the offers resource of a transfer
+// @Summary List the offers of a transfer
+// @Description The seller sees every offer made on the transfer while buyers only see their own
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Success 200 {array} models.ShowOffer
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers [get]
+// @Security BearerAuth
+func (c *Controller) ListOffers(ctx *gin.Context) {
+	transfer, err1 := c.getTransferFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	isSeller := user.IsAdmin() || transfer.Player.Team.UserID == user.ID
+	arr := make([]models.ShowOffer, 0)
+	for _, offer := range c.Repo.GetOffers(transfer.ID) {
+		if !isSeller && offer.Team.UserID != user.ID {
+			continue
+		}
+		c.expireOfferIfStale(&offer)
+		arr = append(arr, c.getOfferPayload(offer))
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"offers": arr,
+	})
+}
+
+// Handles POST requests to the offers resource of a transfer
+// @Summary Make an offer on a transfer
+// @Description Offers to buy the player of a fixed ask transfer for less than the ask
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param offer body models.CreateOffer true "Create offer"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers [post]
+// @Security BearerAuth
+func (c *Controller) CreateOffer(ctx *gin.Context) {
+	transfer, err1 := c.getTransferFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	var t models.CreateOffer
+	err := ctx.ShouldBindJSON(&t)
+	if err != nil || t.Amount <= 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	if transfer.IsAuction() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Auctioned players can only be bought by bidding")
+		return
+	}
+	if transfer.Player.Team.UserID == user.ID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Cannot make an offer on your own player")
+		return
+	}
+	if t.Amount >= transfer.Ask {
+		httputil.NewError(ctx, http.StatusBadRequest, "Offer must be below the ask, buy the transfer instead")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+
+	buyer, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	for _, o := range c.Repo.GetOffers(transfer.ID) {
+		if o.TeamID == buyer.ID && !c.expireOfferIfStale(&o) && o.IsOpen() {
+			httputil.NewError(ctx, http.StatusBadRequest, "Team already has an open offer on this transfer")
+			return
+		}
+	}
+
+	offer := models.Offer{
+		TransferID: transfer.ID,
+		TeamID:     buyer.ID,
+		Amount:     t.Amount,
+		Status:     models.OfferPending,
+		ExpiresAt:  time.Now().Add(offerLifetime),
+	}
+	err = c.Repo.Create(&offer)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": offer.ID,
+	})
+}
+
+// Handles PUT requests to counter an offer
+// @Summary Counter an offer
+// @Description The seller counters a pending offer with a new price, the buyer can answer a counter with a new offer
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param offerId path int true "Offer ID"
+// @Param offer body models.CreateOffer true "Counter offer"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers/{offerId}/counter [put]
+// @Security BearerAuth
+func (c *Controller) CounterOffer(ctx *gin.Context) {
+	transfer, offer, user, err := c.getOpenOfferFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateOffer
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil || t.Amount <= 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	switch {
+	case offer.Status == models.OfferPending && transfer.Player.Team.UserID == user.ID:
+		offer.CounterAmount = t.Amount
+		offer.Status = models.OfferCountered
+	case offer.Status == models.OfferCountered && offer.Team.UserID == user.ID:
+		offer.Amount = t.Amount
+		offer.Status = models.OfferPending
+	default:
+		httputil.NewError(ctx, http.StatusUnauthorized, "It is not your turn to answer this offer")
+		return
+	}
+	offer.ExpiresAt = time.Now().Add(offerLifetime)
+
+	c.saveOffer(ctx, &offer)
+}
+
+// Handles PUT requests to accept an offer
+// @Summary Accept an offer
+// @Description The seller accepts a pending offer or the buyer accepts a counter. The player is sold at the agreed price.
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param offerId path int true "Offer ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers/{offerId}/accept [put]
+// @Security BearerAuth
+func (c *Controller) AcceptOffer(ctx *gin.Context) {
+	transfer, offer, user, err := c.getOpenOfferFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if !c.isTurnToAnswerOffer(transfer, offer, user) {
+		httputil.NewError(ctx, http.StatusUnauthorized, "It is not your turn to answer this offer")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+
+	buyer, err := c.Repo.GetTeam(offer.TeamID)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if !c.validateSaleSquadRules(ctx, transfer.Player, buyer.ID) {
+		return
+	}
+
+	price := offer.Price()
+	if available := c.availableFunds(buyer, 0); available < price {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to execute the purchase (%v < %v)", available, price))
+		return
+	}
+
+	listing := c.getFeedListing(transfer)
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		// Lock the transfer before the offer, the same order a direct purchase uses
+		if err := tx.Lock(&models.Transfer{}, transfer.ID); err != nil {
+			return errSaleConflict
+		}
+		var locked models.Offer
+		if err := tx.Lock(&locked, offer.ID); err != nil || !locked.IsOpen() {
+			return errSaleConflict
+		}
+		locked.Status = models.OfferAccepted
+		if err := tx.Update(&locked); err != nil {
+			return err
+		}
+		return c.doExecuteTransferIn(tx, &transfer, buyer.ID, price)
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+	c.publishTransferSold(listing, buyer.ID, price)
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles PUT requests to reject an offer
+// @Summary Reject an offer
+// @Description The seller rejects a pending offer or the buyer rejects a counter
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param offerId path int true "Offer ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers/{offerId}/reject [put]
+// @Security BearerAuth
+func (c *Controller) RejectOffer(ctx *gin.Context) {
+	transfer, offer, user, err := c.getOpenOfferFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if !c.isTurnToAnswerOffer(transfer, offer, user) {
+		httputil.NewError(ctx, http.StatusUnauthorized, "It is not your turn to answer this offer")
+		return
+	}
+
+	offer.Status = models.OfferRejected
+	c.saveOffer(ctx, &offer)
+}
+
+// Handles PUT requests to withdraw an offer
+// @Summary Withdraw an offer
+// @Description The buyer withdraws an offer that is still being negotiated
+// @Tags Offers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer ID"
+// @Param offerId path int true "Offer ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/{id}/offers/{offerId}/withdraw [put]
+// @Security BearerAuth
+func (c *Controller) WithdrawOffer(ctx *gin.Context) {
+	_, offer, user, err := c.getOpenOfferFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if offer.Team.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Only the buyer can withdraw an offer")
+		return
+	}
+
+	offer.Status = models.OfferWithdrawn
+	c.saveOffer(ctx, &offer)
+}
+
+// Get the transfer, the offer and the authenticated user of a request on an offer that is still open
+func (c *Controller) getOpenOfferFromRequest(ctx *gin.Context) (models.Transfer, models.Offer, models.User, error) {
+	transfer, err := c.getTransferFromRequest(ctx)
+	if err != nil {
+		return models.Transfer{}, models.Offer{}, models.User{}, err
+	}
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return models.Transfer{}, models.Offer{}, models.User{}, err
+	}
+	id, err := c.parseIdFromRequest(ctx, "offerId")
+	if err != nil {
+		return models.Transfer{}, models.Offer{}, models.User{}, err
+	}
+
+	offer, err := c.Repo.GetOffer(id)
+	if err != nil || offer.TransferID != transfer.ID {
+		httputil.NewError(ctx, http.StatusNotFound, "Offer not found")
+		return models.Transfer{}, models.Offer{}, models.User{}, fmt.Errorf("offer not found")
+	}
+	if c.expireOfferIfStale(&offer) || !offer.IsOpen() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Offer is "+offer.Status)
+		return models.Transfer{}, models.Offer{}, models.User{}, fmt.Errorf("offer is closed")
+	}
+	return transfer, offer, user, nil
+}
+
+// Returns a bool that tells if the user is the side that has to answer the offer
+func (c *Controller) isTurnToAnswerOffer(transfer models.Transfer, offer models.Offer, user models.User) bool {
+	if offer.Status == models.OfferCountered {
+		return offer.Team.UserID == user.ID
+	}
+	return transfer.Player.Team.UserID == user.ID
+}
+
+// Mark an open offer as expired if its time ran out, returns true if it did
+func (c *Controller) expireOfferIfStale(offer *models.Offer) bool {
+	if !offer.IsOpen() || time.Now().Before(offer.ExpiresAt) {
+		return false
+	}
+	offer.Status = models.OfferExpired
+	if err := c.Repo.Update(offer); err != nil {
+		log.Println(err)
+	}
+	return true
+}
+
+// Save an offer and write the response
+func (c *Controller) saveOffer(ctx *gin.Context, offer *models.Offer) {
+	err := c.Repo.Update(offer)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Get a show offer payload from an offer
+func (c *Controller) getOfferPayload(offer models.Offer) models.ShowOffer {
+	return models.ShowOffer{
+		ID:            offer.ID,
+		TransferID:    offer.TransferID,
+		TeamID:        offer.TeamID,
+		Amount:        offer.Amount,
+		CounterAmount: offer.CounterAmount,
+		Status:        offer.Status,
+		ExpiresAt:     offer.ExpiresAt,
+	}
+}
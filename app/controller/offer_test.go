@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"../models"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestIsTurnToAnswerOffer(t *testing.T) {
+	c := Controller{}
+	seller := models.User{}
+	seller.ID = 1
+	buyer := models.User{}
+	buyer.ID = 2
+
+	transfer := models.Transfer{}
+	transfer.Player.Team.UserID = seller.ID
+	offer := models.Offer{Status: models.OfferPending}
+	offer.Team.UserID = buyer.ID
+
+	tests.AssertEqual(t, c.isTurnToAnswerOffer(transfer, offer, seller), true)
+	tests.AssertEqual(t, c.isTurnToAnswerOffer(transfer, offer, buyer), false)
+
+	offer.Status = models.OfferCountered
+	tests.AssertEqual(t, c.isTurnToAnswerOffer(transfer, offer, seller), false)
+	tests.AssertEqual(t, c.isTurnToAnswerOffer(transfer, offer, buyer), true)
+}
+
+func TestOfferPrice(t *testing.T) {
+	offer := models.Offer{Amount: 100, CounterAmount: 150, Status: models.OfferPending}
+	tests.AssertEqual(t, offer.Price(), 100)
+	tests.AssertEqual(t, offer.IsOpen(), true)
+
+	offer.Status = models.OfferCountered
+	tests.AssertEqual(t, offer.Price(), 150)
+	tests.AssertEqual(t, offer.IsOpen(), true)
+
+	offer.Status = models.OfferWithdrawn
+	tests.AssertEqual(t, offer.IsOpen(), false)
+}
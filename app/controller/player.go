@@ -3,10 +3,13 @@ package controller
 import (
 	"../httputil"
 	"../models"
+	"../repos"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
 	"log"
 	"net/http"
+	"time"
 )
 
 // Handles GET requests to the players resource
@@ -58,9 +61,12 @@ func (c *Controller) CreateNewPlayerOnTeam(ctx *gin.Context) {
 		return
 	}
 
+	if payload.PlayerAttributes == (models.PlayerAttributes{}) {
+		payload.PlayerAttributes = models.RandomAttributes(payload.Position, payload.Age)
+	}
 	validate := validator.New()
 	if err := validate.Struct(&payload); err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, "Position was out of range")
+		httputil.NewError(ctx, http.StatusBadRequest, "Position or attributes were out of range")
 		return
 	}
 
@@ -73,6 +79,8 @@ func (c *Controller) CreateNewPlayerOnTeam(ctx *gin.Context) {
 		Position:    payload.Position,
 		TeamID:      team.ID,
 	}
+	player.SetAttributes(payload.PlayerAttributes)
+	player.SignRandomContract(time.Now())
 
 	err = c.Repo.Update(&player)
 	if err != nil {
@@ -120,20 +128,56 @@ func (c *Controller) UpdatePlayer(ctx *gin.Context) {
 		return
 	}
 
+	if isAdmin && payload.PlayerAttributes != player.PlayerAttributes {
+		if err := validator.New().Struct(&payload.PlayerAttributes); err != nil {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Attributes have to be between %v and %v", models.MinAttribute, models.MaxAttribute))
+			return
+		}
+	}
+
+	previous := player
 	player.FirstName = payload.FirstName
 	player.LastName = payload.LastName
 	player.Country = payload.Country
+	player.ReleaseClause = payload.ReleaseClause
 	if isAdmin {
-		team, err := c.Repo.GetTeam(uint(payload.Team))
-		if err != nil {
-			httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		// Free agents that stay in the pool have no team to look up or squad to check
+		var team models.Team
+		stayFree := previous.IsFreeAgent() && payload.Team == 0
+		if !stayFree {
+			var err error
+			if team, err = c.Repo.GetTeam(uint(payload.Team)); err != nil {
+				httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+				return
+			}
+		}
+		moved := previous.TeamID != team.ID
+		if moved && !c.validateSquadChange(ctx, previous.TeamID, &previous, nil) {
 			return
 		}
+		if !stayFree && (payload.Position != previous.Position || moved) {
+			var removed *models.Player
+			if !moved {
+				removed = &previous
+			}
+			changed := previous
+			changed.Position = payload.Position
+			if !c.validateSquadChange(ctx, team.ID, removed, &changed) {
+				return
+			}
+		}
+		if moved {
+			player.InLineup = false
+		}
 		player.TeamID = uint(payload.Team)
 		player.Team = team
 		player.MarketValue = int32(payload.MarketValue)
 		player.Age = payload.Age
 		player.Position = payload.Position
+		player.SetAttributes(payload.PlayerAttributes)
+	}
+	if player.ReleaseClause != previous.ReleaseClause && !c.validateReleaseClause(ctx, player) {
+		return
 	}
 
 	err := c.Repo.Update(&player)
@@ -146,6 +190,85 @@ func (c *Controller) UpdatePlayer(ctx *gin.Context) {
 	httputil.NoErrorEmpty(ctx)
 }
 
+// Handles POST requests to the release clause of a player
+// @Summary Trigger a release clause
+// @Description Buys a player at once by paying its release clause, whether it is listed or not. Its listing is removed if it has one.
+// @Tags Players
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /players/{id}/release-clause [post]
+// @Security BearerAuth
+func (c *Controller) TriggerReleaseClause(ctx *gin.Context) {
+	player, err1 := c.getPlayerFromRequest(ctx)
+	user, err2 := c.getAuthenticatedUserFromRequest(ctx)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	buyer, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if player.ReleaseClause <= 0 || player.InAcademy || player.IsFreeAgent() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player has no release clause")
+		return
+	}
+	if player.TeamID == buyer.ID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Cannot buy your own player")
+		return
+	}
+	if _, err := c.Repo.GetActiveLoanOfPlayer(player.ID); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Players on loan can't be transferred")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) || !c.validateSaleSquadRules(ctx, player, buyer.ID) {
+		return
+	}
+	if available := c.availableFunds(buyer, 0); available < player.ReleaseClause {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to pay the release clause (%v < %v)", available, player.ReleaseClause))
+		return
+	}
+
+	var listing *models.Transfer
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		// Lock in the same order as a sale, starting with the listing of the player if it has one
+		listing = nil
+		if open, err := tx.GetTransferWithPlayer(&player); err == nil {
+			var locked models.Transfer
+			if err := tx.Lock(&locked, open.ID); err != nil {
+				return errSaleConflict
+			}
+			listing = &locked
+		}
+		var locked models.Player
+		if err := tx.Lock(&locked, player.ID); err != nil || locked.IsFreeAgent() || locked.TeamID != player.TeamID || locked.ReleaseClause != player.ReleaseClause {
+			return errSaleConflict
+		}
+		return c.doExecuteSaleIn(tx, player.ID, listing, buyer.ID, player.ReleaseClause)
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+	if listing != nil {
+		// Send the player as it was before the sale so subscribers filter it by the seller
+		sold := *listing
+		sold.Player = player
+		c.publishTransferSold(c.getFeedListing(sold), buyer.ID, player.ReleaseClause)
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
 // Handles a DELETE request to the player resource
 // @Summary Delete a player
 // @Description Deletes a player
@@ -155,7 +278,7 @@ func (c *Controller) UpdatePlayer(ctx *gin.Context) {
 // @Param id path int true "Player ID"
 // @Success 200
 // @Failure 401 {object} httputil.HTTPError
-// @Failure 400 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.HTTPRuleError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /players/{id} [delete]
 // @Security BearerAuth
@@ -164,6 +287,9 @@ func (c *Controller) DeletePlayer(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
+	if !c.validateSquadChange(ctx, player.TeamID, &player, nil) {
+		return
+	}
 
 	err = c.Repo.DeletePlayer(&player)
 	if err != nil {
@@ -175,6 +301,22 @@ func (c *Controller) DeletePlayer(ctx *gin.Context) {
 	httputil.NoErrorEmpty(ctx)
 }
 
+// Validate a release clause is within the league bounds and the player has a team to pay it, write the error if not. 0 removes the clause
+func (c *Controller) validateReleaseClause(ctx *gin.Context, player models.Player) bool {
+	if player.ReleaseClause == 0 {
+		return true
+	}
+	if player.IsFreeAgent() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Free agents can't have a release clause")
+		return false
+	}
+	if player.ReleaseClause < 0 || !c.Repo.GetEconomicRules().AllowsReleaseClause(player.ReleaseClause, player.MarketValue) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Release clause is out of the bounds set by the league")
+		return false
+	}
+	return true
+}
+
 // Gets a Player model from the id in the request
 func (c *Controller) getPlayerFromRequest(ctx *gin.Context) (models.Player, error) {
 	id, err := c.parseIdFromRequest(ctx, "playerId")
@@ -201,12 +343,14 @@ func (c *Controller) fillDefaultPlayerPayload(player models.Player) models.Updat
 	payload.Age = player.Age
 	payload.MarketValue = player.MarketValue
 	payload.Position = player.Position
+	payload.ReleaseClause = player.ReleaseClause
+	payload.PlayerAttributes = player.PlayerAttributes
 	return payload
 }
 
 // Create and fill the show player payload
 func (c *Controller) getPlayerPayload(p models.Player) models.ShowPlayer {
-	return models.ShowPlayer{
+	payload := models.ShowPlayer{
 		ID: p.ID,
 		BasePlayer: models.BasePlayer{
 			FirstName:   p.FirstName,
@@ -216,5 +360,16 @@ func (c *Controller) getPlayerPayload(p models.Player) models.ShowPlayer {
 			MarketValue: p.MarketValue,
 			Position:    p.Position,
 		},
+		ReleaseClause:    p.ReleaseClause,
+		PlayerAttributes: p.PlayerAttributes,
+		Overall:          p.Overall,
+		Availability:     p.Availability(time.Now()),
+		SuspendedMatches: p.SuspendedMatches,
+		InLineup:         p.InLineup,
+		FreeAgent:        p.IsFreeAgent(),
+	}
+	if payload.Availability == models.PlayerInjured {
+		payload.InjuredUntil = p.InjuredUntil
 	}
+	return payload
 }
@@ -2,6 +2,8 @@ package controller
 
 import (
 	"../models"
+	"../repos"
+	"errors"
 	"gorm.io/gorm/utils/tests"
 	"testing"
 )
@@ -30,3 +32,30 @@ func TestGetPlayerPayload(t *testing.T) {
 	tests.AssertEqual(t, show.Position, p.Position)
 	tests.AssertEqual(t, show.MarketValue, p.MarketValue)
 }
+
+func TestReleaseClauseSaleWithoutListing(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	seller, buyer := models.Team{Budget: 0}, models.Team{Budget: 3000}
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	player := models.Player{TeamID: seller.ID, MarketValue: 1000, ReleaseClause: 2000}
+	_ = repo.Create(&player)
+
+	if err := c.doExecuteSaleIn(repo, player.ID, nil, buyer.ID, player.ReleaseClause); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := repo.GetTeam(seller.ID)
+	b, _ := repo.GetTeam(buyer.ID)
+	tests.AssertEqual(t, s.Budget, 2000)
+	tests.AssertEqual(t, b.Budget, 1000)
+	p, _ := repo.GetPlayer(player.ID)
+	tests.AssertEqual(t, p.TeamID, buyer.ID)
+	tests.AssertEqual(t, p.ReleaseClause, 0)
+
+	// Free agents have no seller to pay
+	agent := models.Player{MarketValue: 1000, ReleaseClause: 2000}
+	_ = repo.Create(&agent)
+	err := c.doExecuteSaleIn(repo, agent.ID, nil, buyer.ID, agent.ReleaseClause)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
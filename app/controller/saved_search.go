@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+)
+
+// Handles GET requests to the saved searches of the logged in user
+// @Summary Show my saved searches
+// @Description Show the transfer market searches the logged in user gets alerted about
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.ShowSavedSearch
+// @Failure 401 {object} httputil.HTTPError
+// @Router /me/searches [get]
+// @Security BearerAuth
+func (c *Controller) ListMySavedSearches(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	arr := make([]models.ShowSavedSearch, 0)
+	for _, s := range c.Repo.GetSavedSearches(user.ID) {
+		arr = append(arr, c.getSavedSearchPayload(s))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"searches": arr,
+	})
+}
+
+// Handles POST requests to the saved searches of the logged in user
+// @Summary Save a search
+// @Description Save a transfer market search, the user gets a notification every time a new transfer matches it
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param search body models.CreateSavedSearch true "Create saved search"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/searches [post]
+// @Security BearerAuth
+func (c *Controller) CreateSavedSearch(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateSavedSearch
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil || (t.ValueType != "" && t.ValueType != "ask" && t.ValueType != "market") {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	search := models.SavedSearch{
+		UserID:     user.ID,
+		Name:       t.Name,
+		Country:    t.Country,
+		TeamName:   t.TeamName,
+		PlayerName: t.PlayerName,
+		MinAge:     intOrDefault(t.MinAge, -1),
+		MaxAge:     intOrDefault(t.MaxAge, math.MaxInt32),
+		MinValue:   intOrDefault(t.MinValue, -1),
+		MaxValue:   intOrDefault(t.MaxValue, math.MaxInt32),
+		ValueType:  t.ValueType,
+	}
+	err = c.Repo.Create(&search)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": search.ID,
+	})
+}
+
+// Handles DELETE requests to the saved searches of the logged in user
+// @Summary Delete a saved search
+// @Description Delete a saved search of the logged in user
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Saved search ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/searches/{id} [delete]
+// @Security BearerAuth
+func (c *Controller) DeleteSavedSearch(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	id, err := c.parseIdFromRequest(ctx, "searchId")
+	if err != nil {
+		return
+	}
+
+	search, err := c.Repo.GetSavedSearch(id)
+	if err != nil || search.UserID != user.ID {
+		httputil.NewError(ctx, http.StatusNotFound, "Saved search not found")
+		return
+	}
+
+	err = c.Repo.Delete(&search)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Get the transfer filters of a saved search
+func (c *Controller) getSavedSearchFilters(search models.SavedSearch) transferFilters {
+	return transferFilters{
+		Country:        search.Country,
+		TeamName:       search.TeamName,
+		PlayerName:     search.PlayerName,
+		MinAgeFilter:   search.MinAge,
+		MaxAgeFilter:   search.MaxAge,
+		MinValueFilter: search.MinValue,
+		MaxValueFilter: search.MaxValue,
+		ValueType:      search.ValueType,
+	}
+}
+
+// Get a show saved search payload from a saved search, bounds that don't filter anything are left out
+func (c *Controller) getSavedSearchPayload(search models.SavedSearch) models.ShowSavedSearch {
+	bound := func(value, unbounded int) *int {
+		if value == unbounded {
+			return nil
+		}
+		return &value
+	}
+	return models.ShowSavedSearch{
+		ID:         search.ID,
+		Name:       search.Name,
+		Country:    search.Country,
+		TeamName:   search.TeamName,
+		PlayerName: search.PlayerName,
+		MinAge:     bound(search.MinAge, -1),
+		MaxAge:     bound(search.MaxAge, math.MaxInt32),
+		MinValue:   bound(search.MinValue, -1),
+		MaxValue:   bound(search.MaxValue, math.MaxInt32),
+		ValueType:  search.ValueType,
+	}
+}
+
+// Get the value of an optional int or a default one when missing
+func intOrDefault(value *int, def int) int {
+	if value == nil {
+		return def
+	}
+	return *value
+}
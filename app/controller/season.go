@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"../season"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+var errSeasonPassed = errors.New("a later season was already rolled over")
+
+// Handles GET requests to the rolled over seasons
+// @Summary List the seasons
+// @Description List the reports of the season rollovers, newest first
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.ShowSeason
+// @Failure 401 {object} httputil.HTTPError
+// @Router /admin/seasons [get]
+// @Security BearerAuth[admin]
+func (c *Controller) ListSeasons(ctx *gin.Context) {
+	arr := make([]models.ShowSeason, 0)
+	for _, s := range c.Repo.GetSeasons() {
+		arr = append(arr, c.getSeasonPayload(s))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"seasons": arr,
+	})
+}
+
+// Handles POST requests to roll over a season
+// @Summary Start a new season
+// @Description Every player gets a year older and is revalued on an age curve. Players past the retirement age retire,
+// @Description they leave their teams and the market and young players are generated to replace them.
+// @Description Everything happens in a single transaction. Rolling over a season again changes nothing and returns its report.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param season body models.CreateSeasonRollover true "Season to start"
+// @Success 200 {object} models.ShowSeason
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /admin/seasons/rollover [post]
+// @Security BearerAuth[admin]
+func (c *Controller) RolloverSeason(ctx *gin.Context) {
+	var t models.CreateSeasonRollover
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	report, rolledOver, err := c.rolloverSeason(t.Season)
+	if errors.Is(err, errSeasonPassed) {
+		httputil.NewError(ctx, http.StatusBadRequest, "A later season was already rolled over")
+		return
+	}
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+
+	payload := c.getSeasonPayload(report)
+	payload.AlreadyRolledOver = !rolledOver
+	httputil.NoError(ctx, payload)
+}
+
+// Roll over the season that started when the league has a start month, run as a background job
+func (c *Controller) RolloverCurrentSeason() error {
+	if c.Season.StartMonth == 0 {
+		return nil
+	}
+	_, _, err := c.rolloverSeason(season.Of(time.Now(), c.Season.StartMonth))
+	if errors.Is(err, errSeasonPassed) {
+		return nil
+	}
+	return err
+}
+
+// Roll over a season in a single transaction and save its report. Returns the report and true when the
+// season was rolled over now, or the report of the previous rollover and false when it was already done.
+func (c *Controller) rolloverSeason(number int) (models.Season, bool, error) {
+	if previous, err := c.Repo.GetSeason(number); err == nil {
+		return previous, false, nil
+	}
+	if seasons := c.Repo.GetSeasons(); len(seasons) > 0 && seasons[0].Number > number {
+		return models.Season{}, false, errSeasonPassed
+	}
+
+	var report models.Season
+	var withdrawn []models.Transfer
+	err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		report, withdrawn = models.Season{Number: number}, make([]models.Transfer, 0)
+		// The number is unique, so a concurrent rollover of the same season fails here
+		if err := tx.Create(&report); err != nil {
+			return err
+		}
+
+		players := tx.GetAllPlayers()
+		// Lock in the same order as a sale, starting with the listings of the players that retire
+		for _, p := range players {
+			if !c.Season.Retires(p.Age + 1) {
+				continue
+			}
+			listing, err := tx.GetTransferWithPlayer(&p)
+			if err != nil {
+				continue
+			}
+			var locked models.Transfer
+			if err := tx.Lock(&locked, listing.ID); err != nil {
+				return errSaleConflict
+			}
+			locked.Player = p
+			withdrawn = append(withdrawn, c.getFeedListing(locked))
+		}
+
+		for _, p := range players {
+			var player models.Player
+			if err := tx.Lock(&player, p.ID); err != nil {
+				return errSaleConflict
+			}
+			report.ValueBefore += int64(player.MarketValue)
+			player.Age++
+			if !c.Season.Retires(player.Age) {
+				player.MarketValue = c.valuationModel().Revalue(player)
+				if err := tx.Update(&player); err != nil {
+					return err
+				}
+				report.PlayersAged++
+				report.ValueAfter += int64(player.MarketValue)
+				continue
+			}
+
+			owner, err := c.retirePlayerIn(tx, player)
+			if err != nil {
+				return err
+			}
+			report.PlayersRetired++
+			if _, err := tx.GetTeam(owner); err != nil {
+				continue
+			}
+			youth := season.Youth(player.Position, c.Season)
+			youth.TeamID = owner
+			youth.SignRandomContract(time.Now())
+			if err := tx.Create(&youth); err != nil {
+				return err
+			}
+			report.PlayersGenerated++
+			report.ValueAfter += int64(youth.MarketValue)
+		}
+		report.ListingsWithdrawn = len(withdrawn)
+		return tx.Update(&report)
+	})
+	if err != nil {
+		// Another request rolled the season over first
+		if previous, err := c.Repo.GetSeason(number); err == nil {
+			return previous, false, nil
+		}
+		return models.Season{}, false, err
+	}
+	for _, listing := range withdrawn {
+		c.publishTransferEvent(models.TransferEventWithdrawn, listing)
+	}
+	log.Printf("Season %v rolled over: %v players aged, %v retired, %v generated",
+		report.Number, report.PlayersAged, report.PlayersRetired, report.PlayersGenerated)
+	return report, true, nil
+}
+
+// Take a player out of the league inside a transaction. It is detached from its teams and deleted
+// along with its listing. Returns the team that owned the player.
+func (c *Controller) retirePlayerIn(tx repos.Repository, player models.Player) (uint, error) {
+	owner, err := c.detachPlayerIn(tx, player)
+	if err != nil {
+		return 0, err
+	}
+	return owner, tx.DeletePlayer(&player)
+}
+
+// End the active loan of a player and withdraw the pending trades that include it inside a transaction,
+// before it leaves its team. Returns the team that owns the player, the parent team when it was on loan.
+func (c *Controller) detachPlayerIn(tx repos.Repository, player models.Player) (uint, error) {
+	owner := player.TeamID
+	if loan, err := tx.GetActiveLoanOfPlayer(player.ID); err == nil {
+		owner = loan.ParentTeamID
+		loan.Status = models.LoanReturned
+		if err := tx.Update(&loan); err != nil {
+			return 0, err
+		}
+	}
+	for _, trade := range tx.GetTradesOfTeam(player.TeamID) {
+		if trade.Status != models.TradePending {
+			continue
+		}
+		for _, p := range trade.Players {
+			if p.PlayerID != player.ID {
+				continue
+			}
+			trade.Status = models.TradeWithdrawn
+			if err := tx.Update(&trade); err != nil {
+				return 0, err
+			}
+			break
+		}
+	}
+	return owner, nil
+}
+
+// Get the payload of a season report
+func (c *Controller) getSeasonPayload(s models.Season) models.ShowSeason {
+	return models.ShowSeason{
+		ID:                s.ID,
+		Number:            s.Number,
+		PlayersAged:       s.PlayersAged,
+		PlayersRetired:    s.PlayersRetired,
+		ListingsWithdrawn: s.ListingsWithdrawn,
+		PlayersGenerated:  s.PlayersGenerated,
+		ValueBefore:       s.ValueBefore,
+		ValueAfter:        s.ValueAfter,
+		RolledOverAt:      s.CreatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"../season"
+	"../valuation"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestRolloverSeason(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo, Season: season.DefaultConfig()}
+	user, _ := repo.CreateUser("owner@test.com", []byte{}, 0)
+	team, _ := repo.GetUserTeam(user)
+	players := repo.GetPlayers(team.ID)
+	for i := range players {
+		players[i].Age = 25
+		players[i].MarketValue = 1000
+		_ = repo.Update(&players[i])
+	}
+	veteran := players[0]
+	veteran.Age = c.Season.RetirementAge
+	_ = repo.Update(&veteran)
+	listing := models.Transfer{PlayerID: veteran.ID, Ask: 1000}
+	_ = repo.Create(&listing)
+
+	report, rolledOver, err := c.rolloverSeason(2026)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, rolledOver, true)
+	tests.AssertEqual(t, report.PlayersAged, len(players)-1)
+	tests.AssertEqual(t, report.PlayersRetired, 1)
+	tests.AssertEqual(t, report.ListingsWithdrawn, 1)
+	tests.AssertEqual(t, report.PlayersGenerated, 1)
+
+	if _, err := repo.GetPlayer(veteran.ID); err == nil {
+		t.Error("the retired player is still in the league")
+	}
+	if _, err := repo.GetTransfer(listing.ID); err == nil {
+		t.Error("the listing of the retired player is still on the market")
+	}
+	after := repo.GetPlayers(team.ID)
+	tests.AssertEqual(t, len(after), len(players))
+	var youth models.Player
+	for _, p := range after {
+		if p.ID == players[1].ID {
+			tests.AssertEqual(t, p.Age, 26)
+			tests.AssertEqual(t, p.MarketValue, valuation.NewDefaultModel().Revalue(models.Player{MarketValue: 1000, Age: 26}))
+		}
+		if p.ID > youth.ID {
+			youth = p
+		}
+	}
+	if youth.Position != veteran.Position || youth.Age > c.Season.MaxYouthAge {
+		t.Errorf("unexpected replacement %+v", youth)
+	}
+
+	// Rolling over the same season again changes nothing
+	again, rolledOver, err := c.rolloverSeason(2026)
+	tests.AssertEqual(t, err, nil)
+	tests.AssertEqual(t, rolledOver, false)
+	tests.AssertEqual(t, again.ID, report.ID)
+	aged, _ := repo.GetPlayer(players[1].ID)
+	tests.AssertEqual(t, aged.Age, 26)
+
+	_, _, err = c.rolloverSeason(2025)
+	tests.AssertEqual(t, errors.Is(err, errSeasonPassed), true)
+}
+
+func TestRetiringPlayerEndsLoanAndTrades(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	parent, borrower := models.Team{}, models.Team{}
+	_ = repo.Create(&parent)
+	_ = repo.Create(&borrower)
+	player := models.Player{TeamID: borrower.ID}
+	_ = repo.Create(&player)
+	loan := models.Loan{PlayerID: player.ID, ParentTeamID: parent.ID, BorrowerTeamID: borrower.ID, Status: models.LoanActive}
+	_ = repo.Create(&loan)
+	trade := models.Trade{ProposerTeamID: borrower.ID, ReceiverTeamID: parent.ID, Status: models.TradePending}
+	_ = repo.Create(&trade)
+	_ = repo.Create(&models.TradePlayer{TradeID: trade.ID, PlayerID: player.ID, FromTeamID: borrower.ID})
+
+	owner, err := c.retirePlayerIn(repo, player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, owner, parent.ID)
+	ended, _ := repo.GetLoan(loan.ID)
+	tests.AssertEqual(t, ended.Status, models.LoanReturned)
+	withdrawn, _ := repo.GetTrade(trade.ID)
+	tests.AssertEqual(t, withdrawn.Status, models.TradeWithdrawn)
+}
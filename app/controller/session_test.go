@@ -14,7 +14,7 @@ func TestGetUser(t *testing.T) {
 	c := Controller{Repo: db}
 	email := "test@gmail.com"
 	pass := "hello123"
-	_, err := c.registerUser(email, pass)
+	_, err := c.registerUser(email, pass, "")
 	if err != nil {
 		t.Error(err)
 		return
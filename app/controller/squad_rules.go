@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// Handles GET requests to the squad rules resource
+// @Summary Show the squad rules
+// @Description Show the limits on the size and composition of every squad. A limit of 0 means there is no limit.
+// @Tags Squad rules
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.ShowSquadRules
+// @Router /squad-rules [get]
+func (c *Controller) ShowSquadRules(ctx *gin.Context) {
+	httputil.NoError(ctx, c.getSquadRulesPayload(c.Repo.GetSquadRules()))
+}
+
+// Handles PATCH requests to the squad rules resource
+// @Summary Update the squad rules
+// @Description Update the limits on the size and composition of every squad. A limit of 0 means there is no limit.
+// @Tags Squad rules
+// @Accept  json
+// @Produce  json
+// @Param rules body models.ShowSquadRules true "Squad rules"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /squad-rules [patch]
+// @Security BearerAuth[admin]
+func (c *Controller) UpdateSquadRules(ctx *gin.Context) {
+	rules := c.Repo.GetSquadRules()
+	t := c.getSquadRulesPayload(rules)
+	err := ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+
+	rules.MinSquadSize, rules.MaxSquadSize = t.MinSquadSize, t.MaxSquadSize
+	rules.MinGoalkeepers, rules.MaxGoalkeepers = t.MinGoalkeepers, t.MaxGoalkeepers
+	rules.MinDefenders, rules.MaxDefenders = t.MinDefenders, t.MaxDefenders
+	rules.MinMidfielders, rules.MaxMidfielders = t.MinMidfielders, t.MaxMidfielders
+	rules.MinAttackers, rules.MaxAttackers = t.MinAttackers, t.MaxAttackers
+	if !c.validSquadRules(rules) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Limits can't be negative and minimums can't exceed maximums")
+		return
+	}
+
+	err = c.Repo.Update(&rules)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Check what squad rule a team breaks by losing and getting a player, any of them can be nil
+func (c *Controller) checkSquadChange(teamId uint, removed *models.Player, added *models.Player) *models.SquadRuleViolation {
+	var removedPlayers, addedPlayers []models.Player
+	if removed != nil {
+		removedPlayers = append(removedPlayers, *removed)
+	}
+	if added != nil {
+		addedPlayers = append(addedPlayers, *added)
+	}
+	return c.checkSquadChangesIn(c.Repo, teamId, removedPlayers, addedPlayers)
+}
+
+// Check what squad rule a team breaks by losing and getting several players, reading from a given repository
+func (c *Controller) checkSquadChangesIn(repo repos.Repository, teamId uint, removed []models.Player, added []models.Player) *models.SquadRuleViolation {
+	// Free agents belong to no squad
+	if teamId == 0 {
+		return nil
+	}
+	removedIds := make(map[uint]bool)
+	for _, p := range removed {
+		removedIds[p.ID] = true
+	}
+	before := repo.GetPlayers(teamId)
+	after := make([]models.Player, 0)
+	for _, p := range before {
+		if !removedIds[p.ID] {
+			after = append(after, p)
+		}
+	}
+	after = append(after, added...)
+	return repo.GetSquadRules().Check(before, after)
+}
+
+// Validate a team can lose and get a player and write the broken rule if it can't, any of them can be nil
+func (c *Controller) validateSquadChange(ctx *gin.Context, teamId uint, removed *models.Player, added *models.Player) bool {
+	violation := c.checkSquadChange(teamId, removed, added)
+	if violation != nil {
+		httputil.NewRuleError(ctx, http.StatusBadRequest, violation.Message, violation.Rule)
+		return false
+	}
+	return true
+}
+
+// Validate the seller and the buyer squads keep within the rules after a player is sold
+func (c *Controller) validateSaleSquadRules(ctx *gin.Context, player models.Player, buyerId uint) bool {
+	return c.validateSquadChange(ctx, player.TeamID, &player, nil) && c.validateSquadChange(ctx, buyerId, nil, &player)
+}
+
+// Returns a bool that tells if the limits of the rules are consistent
+func (c *Controller) validSquadRules(r models.SquadRules) bool {
+	pairs := [][2]int{
+		{r.MinSquadSize, r.MaxSquadSize},
+		{r.MinGoalkeepers, r.MaxGoalkeepers},
+		{r.MinDefenders, r.MaxDefenders},
+		{r.MinMidfielders, r.MaxMidfielders},
+		{r.MinAttackers, r.MaxAttackers},
+	}
+	for _, p := range pairs {
+		min, max := p[0], p[1]
+		if min < 0 || max < 0 || (max > 0 && min > max) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the squad rules payload
+func (c *Controller) getSquadRulesPayload(r models.SquadRules) models.ShowSquadRules {
+	return models.ShowSquadRules{
+		MinSquadSize:   r.MinSquadSize,
+		MaxSquadSize:   r.MaxSquadSize,
+		MinGoalkeepers: r.MinGoalkeepers,
+		MaxGoalkeepers: r.MaxGoalkeepers,
+		MinDefenders:   r.MinDefenders,
+		MaxDefenders:   r.MaxDefenders,
+		MinMidfielders: r.MinMidfielders,
+		MaxMidfielders: r.MaxMidfielders,
+		MinAttackers:   r.MinAttackers,
+		MaxAttackers:   r.MaxAttackers,
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestSquadRulesCheck(t *testing.T) {
+	_, squad := models.RandomTeam()
+	rules := models.SquadRules{MinGoalkeepers: 3, MaxSquadSize: 20}
+
+	// Selling a goalkeeper breaks the minimum
+	violation := rules.Check(squad, squad[1:])
+	tests.AssertEqual(t, violation.Rule, "min_goalkeepers")
+
+	// Selling an attacker is fine
+	tests.AssertEqual(t, rules.Check(squad, squad[:len(squad)-1]) == nil, true)
+
+	// Buying anyone breaks the maximum size
+	violation = rules.Check(squad, append(squad, models.RandomPlayer(2)))
+	tests.AssertEqual(t, violation.Rule, "max_squad_size")
+
+	// Already broken rules are only reported when the change makes them worse
+	rules = models.SquadRules{MinGoalkeepers: 5}
+	tests.AssertEqual(t, rules.Check(squad, append(squad, models.RandomPlayer(0))) == nil, true)
+	tests.AssertEqual(t, rules.Check(squad, squad[1:]).Rule, "min_goalkeepers")
+
+	// No limits
+	tests.AssertEqual(t, models.SquadRules{}.Check(squad, squad[:0]) == nil, true)
+}
+
+func TestValidSquadRules(t *testing.T) {
+	c := Controller{}
+	tests.AssertEqual(t, c.validSquadRules(models.SquadRules{}), true)
+	tests.AssertEqual(t, c.validSquadRules(models.SquadRules{MinDefenders: 4}), true)
+	tests.AssertEqual(t, c.validSquadRules(models.SquadRules{MinDefenders: 4, MaxDefenders: 6}), true)
+	tests.AssertEqual(t, c.validSquadRules(models.SquadRules{MinDefenders: 7, MaxDefenders: 6}), false)
+	tests.AssertEqual(t, c.validSquadRules(models.SquadRules{MinSquadSize: -1}), false)
+}
+
+func TestExecuteSaleChecksSquadRulesUnderTheLocks(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	seller, buyer := models.Team{}, models.Team{Budget: 1000}
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	first := models.Player{TeamID: seller.ID}
+	second := models.Player{TeamID: seller.ID}
+	_ = repo.Create(&first)
+	_ = repo.Create(&second)
+	_ = repo.Create(&models.SquadRules{MaxSquadSize: 1})
+
+	if err := c.doExecuteSaleIn(repo, first.ID, nil, buyer.ID, 100); err != nil {
+		t.Fatal(err)
+	}
+	// The buyer filled its squad while the second purchase was validated
+	err := c.doExecuteSaleIn(repo, second.ID, nil, buyer.ID, 100)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+	p, _ := repo.GetPlayer(second.ID)
+	tests.AssertEqual(t, p.TeamID, seller.ID)
+}
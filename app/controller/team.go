@@ -60,12 +60,7 @@ func (c *Controller) ListTeamPlayers(ctx *gin.Context) {
 		return
 	}
 
-	players := c.Repo.GetPlayers(team.ID)
-
-	playerModels := make([]models.ShowPlayer, 0)
-	for _, p := range players {
-		playerModels = append(playerModels, c.getPlayerPayload(p))
-	}
+	playerModels := c.getTeamPlayersPayload(team, c.Repo.GetPlayers(team.ID))
 
 	httputil.NoError(ctx, gin.H{"players": playerModels})
 }
@@ -88,7 +83,7 @@ func (c *Controller) ShowTeam(ctx *gin.Context) {
 		return
 	}
 
-	httputil.NoError(ctx, c.getTeamPayload(team, c.Repo.GetPlayers(team.ID)))
+	httputil.NoError(ctx, c.getFullTeamPayload(team))
 }
 
 // Handles a POST request to a team resource
@@ -225,12 +220,13 @@ func (c *Controller) RedirectToTeamPlayers(ctx *gin.Context) {
 
 // Generate a json from a team model
 func (c *Controller) getTeamPayload(team models.Team, players []models.Player) models.ShowTeam {
-	marketValue := 0
+	marketValue, wageBill := 0, 0
 
 	playerModels := make([]models.ShowPlayer, 0)
 	for _, p := range players {
 		playerModels = append(playerModels, c.getPlayerPayload(p))
 		marketValue += int(p.MarketValue)
+		wageBill += p.WeeklyWage
 	}
 	return models.ShowTeam{
 		ID:          team.ID,
@@ -239,7 +235,45 @@ func (c *Controller) getTeamPayload(team models.Team, players []models.Player) m
 		Budget:      team.Budget,
 		Players:     playerModels,
 		MarketValue: marketValue,
+		WageBill:    wageBill,
+		IsBot:       team.IsBot,
+	}
+}
+
+// Generate a json from a team model including its players and their loans
+func (c *Controller) getFullTeamPayload(team models.Team) models.ShowTeam {
+	players := c.Repo.GetPlayers(team.ID)
+	payload := c.getTeamPayload(team, players)
+	payload.Players = c.getTeamPlayersPayload(team, players)
+	return payload
+}
+
+// Get the payloads of the players of a team marking the loaned ones, players loaned to other teams are included
+func (c *Controller) getTeamPlayersPayload(team models.Team, players []models.Player) []models.ShowPlayer {
+	loans := c.Repo.GetActiveLoans(team.ID)
+	loanedIn := make(map[uint]bool)
+	for _, l := range loans {
+		if l.BorrowerTeamID == team.ID {
+			loanedIn[l.PlayerID] = true
+		}
+	}
+
+	playerModels := make([]models.ShowPlayer, 0)
+	for _, p := range players {
+		payload := c.getPlayerPayload(p)
+		if loanedIn[p.ID] {
+			payload.Loan = models.LoanedIn
+		}
+		playerModels = append(playerModels, payload)
+	}
+	for _, l := range loans {
+		if l.ParentTeamID == team.ID {
+			payload := c.getPlayerPayload(l.Player)
+			payload.Loan = models.LoanedOut
+			playerModels = append(playerModels, payload)
+		}
 	}
+	return playerModels
 }
 
 // Get team from request
@@ -0,0 +1,547 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Handles GET requests to the trades resource
+// @Summary Show my trades
+// @Description Show the trades the team of the logged in user proposed or received, most recent first
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.ShowTrade
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /trades [get]
+// @Security BearerAuth
+func (c *Controller) ListMyTrades(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	team, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return
+	}
+
+	arr := make([]models.ShowTrade, 0)
+	for _, trade := range c.Repo.GetTradesOfTeam(team.ID) {
+		arr = append(arr, c.getTradePayload(trade))
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"trades": arr,
+	})
+}
+
+// Handles GET requests to the trades resource
+// @Summary Show a trade
+// @Description Get a trade by ID, only the teams involved can see it
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} models.ShowTrade
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /trades/{id} [get]
+// @Security BearerAuth
+func (c *Controller) ShowTrade(ctx *gin.Context) {
+	trade, user, err := c.getTradeFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if !user.IsAdmin() && c.getTradeSide(trade, user) == 0 {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to see a trade of another team")
+		return
+	}
+
+	httputil.NoError(ctx, c.getTradePayload(trade))
+}
+
+// Handles POST requests to the trades resource
+// @Summary Propose a trade
+// @Description Propose exchanging players with another team, optionally with cash going either way. The trade executes once both teams accepted it, proposing counts as accepting.
+// @Description Players going one way for cash going the other are a sale and have to go through the transfer market.
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Param trade body models.CreateTrade true "Create trade"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /trades [post]
+// @Security BearerAuth
+func (c *Controller) CreateTrade(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateTrade
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if len(t.OfferedPlayerIDs)+len(t.RequestedPlayerIDs) == 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "A trade needs at least one player")
+		return
+	}
+
+	proposer, err := c.Repo.GetUserTeam(user)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return
+	}
+	receiver, err := c.Repo.GetTeam(t.TeamID)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return
+	}
+	if receiver.ID == proposer.ID {
+		httputil.NewError(ctx, http.StatusBadRequest, "Cannot trade with your own team")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+
+	included := make(map[uint]bool)
+	offered, ok := c.getTradedPlayersFromRequest(ctx, t.OfferedPlayerIDs, proposer.ID, included)
+	if !ok {
+		return
+	}
+	requested, ok := c.getTradedPlayersFromRequest(ctx, t.RequestedPlayerIDs, receiver.ID, included)
+	if !ok {
+		return
+	}
+
+	trade := models.Trade{
+		ProposerTeamID:   proposer.ID,
+		ReceiverTeamID:   receiver.ID,
+		Cash:             t.Cash,
+		ProposerAccepted: true,
+		Status:           models.TradePending,
+	}
+	if !c.validateTradeIsViable(ctx, trade, offered, requested) {
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		if err := tx.Create(&trade); err != nil {
+			return err
+		}
+		for _, p := range offered {
+			if err := tx.Create(&models.TradePlayer{TradeID: trade.ID, PlayerID: p.ID, FromTeamID: proposer.ID}); err != nil {
+				return err
+			}
+		}
+		for _, p := range requested {
+			if err := tx.Create(&models.TradePlayer{TradeID: trade.ID, PlayerID: p.ID, FromTeamID: receiver.ID}); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.Notification{
+			UserID:  receiver.UserID,
+			Kind:    models.NotificationTradeProposed,
+			Message: fmt.Sprintf("%v proposed a trade of %v players for %v players", proposer.Name, len(offered), len(requested)),
+		})
+	})
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": trade.ID,
+	})
+}
+
+// Handles PUT requests to accept a trade
+// @Summary Accept a trade
+// @Description Accept a trade on behalf of one of its teams. Once both teams accepted it the players and the cash are exchanged atomically and any open transfer on the players is cancelled.
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Trade ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPRuleError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /trades/{id}/accept [put]
+// @Security BearerAuth
+func (c *Controller) AcceptTrade(ctx *gin.Context) {
+	trade, side, err := c.getPendingTradeFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	if side == trade.ProposerTeamID {
+		trade.ProposerAccepted = true
+	} else {
+		trade.ReceiverAccepted = true
+	}
+	if !trade.ProposerAccepted || !trade.ReceiverAccepted {
+		c.saveTrade(ctx, &trade)
+		return
+	}
+
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+	offered, err1 := c.getPlayersByIds(c.Repo, trade.PlayersFrom(trade.ProposerTeamID))
+	requested, err2 := c.getPlayersByIds(c.Repo, trade.PlayersFrom(trade.ReceiverTeamID))
+	if err1 != nil || err2 != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "A player of the trade no longer exists")
+		return
+	}
+	if !c.validateTradeIsViable(ctx, trade, offered, requested) {
+		return
+	}
+
+	// The trade cancels the open listings of its players
+	listings := make([]models.Transfer, 0)
+	for _, players := range [][]models.Player{offered, requested} {
+		for _, player := range players {
+			if transfer, err := c.Repo.GetTransferWithPlayer(&player); err == nil {
+				listings = append(listings, c.getFeedListing(transfer))
+			}
+		}
+	}
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.doExecuteTradeIn(tx, trade)
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+	for _, listing := range listings {
+		c.publishTransferEvent(models.TransferEventWithdrawn, listing)
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles PUT requests to reject a trade
+// @Summary Reject a trade
+// @Description The team receiving a trade rejects it
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Trade ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /trades/{id}/reject [put]
+// @Security BearerAuth
+func (c *Controller) RejectTrade(ctx *gin.Context) {
+	trade, side, err := c.getPendingTradeFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if side != trade.ReceiverTeamID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Only the receiving team can reject a trade")
+		return
+	}
+
+	trade.Status = models.TradeRejected
+	c.saveTrade(ctx, &trade)
+}
+
+// Handles PUT requests to withdraw a trade
+// @Summary Withdraw a trade
+// @Description The team that proposed a trade withdraws it
+// @Tags Trades
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Trade ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /trades/{id}/withdraw [put]
+// @Security BearerAuth
+func (c *Controller) WithdrawTrade(ctx *gin.Context) {
+	trade, side, err := c.getPendingTradeFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	if side != trade.ProposerTeamID {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Only the proposing team can withdraw a trade")
+		return
+	}
+
+	trade.Status = models.TradeWithdrawn
+	c.saveTrade(ctx, &trade)
+}
+
+// Exchange the players and the cash of a trade inside a transaction. Like a sale every row involved
+// is locked and checked again, if anything changed since the trade was accepted it fails with errSaleConflict
+func (c *Controller) doExecuteTradeIn(tx repos.Repository, trade models.Trade) error {
+	var locked models.Trade
+	if err := tx.Lock(&locked, trade.ID); err != nil || locked.Status != models.TradePending {
+		return errSaleConflict
+	}
+
+	ids := make([]uint, 0)
+	for _, p := range trade.Players {
+		ids = append(ids, p.PlayerID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// Cancel the open transfers first, a sale locks the transfer before the player
+	for _, id := range ids {
+		var player models.Player
+		player.ID = id
+		transfer, err := tx.GetTransferWithPlayer(&player)
+		if err != nil {
+			continue
+		}
+		if err := tx.Lock(&models.Transfer{}, transfer.ID); err != nil {
+			return errSaleConflict
+		}
+		if err := tx.DeleteTransfer(&transfer); err != nil {
+			return err
+		}
+	}
+
+	players := make([]models.Player, len(ids))
+	for i, id := range ids {
+		if err := tx.Lock(&players[i], id); err != nil {
+			return errSaleConflict
+		}
+	}
+	teams, err := c.lockTeams(tx, trade.ProposerTeamID, trade.ReceiverTeamID)
+	if err != nil {
+		return errSaleConflict
+	}
+	proposer, receiver := teams[trade.ProposerTeamID], teams[trade.ReceiverTeamID]
+
+	// Every player must still belong to the team giving it away
+	from := make(map[uint]uint)
+	for _, p := range trade.Players {
+		from[p.PlayerID] = p.FromTeamID
+	}
+	var offered, requested []models.Player
+	for _, p := range players {
+		if p.TeamID != from[p.ID] {
+			return errSaleConflict
+		}
+		if p.TeamID == proposer.ID {
+			offered = append(offered, p)
+		} else {
+			requested = append(requested, p)
+		}
+	}
+
+	payer, payee, amount := &proposer, &receiver, trade.Cash
+	if amount < 0 {
+		payer, payee, amount = &receiver, &proposer, -amount
+	}
+	if c.availableFundsIn(tx, *payer, 0) < amount ||
+		c.checkSquadChangesIn(tx, proposer.ID, offered, requested) != nil ||
+		c.checkSquadChangesIn(tx, receiver.ID, requested, offered) != nil {
+		return errSaleConflict
+	}
+
+	// Like a sale, the team a player leaves pays its wages up to the trade
+	now := time.Now()
+	for i := range players {
+		if players[i].TeamID == proposer.ID {
+			proposer.Budget -= players[i].SettleWages(now)
+			movePlayer(&players[i], receiver)
+		} else {
+			receiver.Budget -= players[i].SettleWages(now)
+			movePlayer(&players[i], proposer)
+		}
+		if err := tx.Update(&players[i]); err != nil {
+			return err
+		}
+	}
+	payTeam(payer, payee, amount)
+
+	locked.ProposerAccepted = true
+	locked.ReceiverAccepted = true
+	locked.Status = models.TradeExecuted
+	err1 := tx.Update(&proposer)
+	err2 := tx.Update(&receiver)
+	err3 := tx.Update(&locked)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return fmt.Errorf("failed to save models")
+	}
+	return nil
+}
+
+// Get the players of a trade side from the request ids, checking they belong to the team and are not included twice
+func (c *Controller) getTradedPlayersFromRequest(ctx *gin.Context, ids []uint, teamId uint, included map[uint]bool) ([]models.Player, bool) {
+	players := make([]models.Player, 0)
+	for _, id := range ids {
+		if included[id] {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Player %v is included twice", id))
+			return nil, false
+		}
+		included[id] = true
+
+		player, err := c.Repo.GetPlayer(id)
+		if err != nil {
+			httputil.NewError(ctx, http.StatusNotFound, "Player not found")
+			return nil, false
+		}
+		if player.TeamID != teamId {
+			httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Player %v does not belong to team %v", id, teamId))
+			return nil, false
+		}
+		if player.InAcademy {
+			httputil.NewError(ctx, http.StatusBadRequest, "Academy players have to be promoted before they are traded")
+			return nil, false
+		}
+		if _, err := c.Repo.GetActiveLoanOfPlayer(id); err == nil {
+			httputil.NewError(ctx, http.StatusBadRequest, "Players on loan can't be traded")
+			return nil, false
+		}
+		players = append(players, player)
+	}
+	return players, true
+}
+
+// Get players by id from a given repository
+func (c *Controller) getPlayersByIds(repo repos.Repository, ids []uint) ([]models.Player, error) {
+	players := make([]models.Player, 0)
+	for _, id := range ids {
+		player, err := repo.GetPlayer(id)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+	return players, nil
+}
+
+// Validate a trade is not a sale, both squads keep within the rules and the paying team can afford the cash of a trade
+func (c *Controller) validateTradeIsViable(ctx *gin.Context, trade models.Trade, offered []models.Player, requested []models.Player) bool {
+	// A sale disguised as a trade would skip the market tax, the sell-on fees and the sales history
+	if (len(offered) == 0 && trade.Cash > 0) || (len(requested) == 0 && trade.Cash < 0) {
+		httputil.NewError(ctx, http.StatusBadRequest, "Players can only be exchanged for cash on the transfer market")
+		return false
+	}
+	for _, side := range []struct {
+		teamId      uint
+		lost, added []models.Player
+	}{
+		{trade.ProposerTeamID, offered, requested},
+		{trade.ReceiverTeamID, requested, offered},
+	} {
+		if violation := c.checkSquadChangesIn(c.Repo, side.teamId, side.lost, side.added); violation != nil {
+			httputil.NewRuleError(ctx, http.StatusBadRequest, violation.Message, violation.Rule)
+			return false
+		}
+	}
+
+	payerId, amount := trade.ProposerTeamID, trade.Cash
+	if amount < 0 {
+		payerId, amount = trade.ReceiverTeamID, -amount
+	}
+	payer, err := c.Repo.GetTeam(payerId)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Team not found")
+		return false
+	}
+	if available := c.availableFunds(payer, 0); available < amount {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to pay the trade (%v < %v)", available, amount))
+		return false
+	}
+	return true
+}
+
+// Get the id of the team of the user in a trade, 0 if the user is not part of it
+func (c *Controller) getTradeSide(trade models.Trade, user models.User) uint {
+	team, err := c.Repo.GetUserTeam(user)
+	if err != nil || (team.ID != trade.ProposerTeamID && team.ID != trade.ReceiverTeamID) {
+		return 0
+	}
+	return team.ID
+}
+
+// Gets a trade model from the id in the request
+func (c *Controller) getTradeFromRequest(ctx *gin.Context) (models.Trade, models.User, error) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return models.Trade{}, models.User{}, err
+	}
+	id, err := c.parseIdFromRequest(ctx, "tradeId")
+	if err != nil {
+		return models.Trade{}, models.User{}, err
+	}
+
+	trade, err := c.Repo.GetTrade(id)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Trade not found")
+		return models.Trade{}, models.User{}, err
+	}
+	return trade, user, nil
+}
+
+// Gets a pending trade from the request and the team of the user in it
+func (c *Controller) getPendingTradeFromRequest(ctx *gin.Context) (models.Trade, uint, error) {
+	trade, user, err := c.getTradeFromRequest(ctx)
+	if err != nil {
+		return models.Trade{}, 0, err
+	}
+	side := c.getTradeSide(trade, user)
+	if side == 0 {
+		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to answer a trade of another team")
+		return models.Trade{}, 0, fmt.Errorf("not part of the trade")
+	}
+	if trade.Status != models.TradePending {
+		httputil.NewError(ctx, http.StatusBadRequest, "Trade is "+trade.Status)
+		return models.Trade{}, 0, fmt.Errorf("trade is closed")
+	}
+	return trade, side, nil
+}
+
+// Save a trade and write the response
+func (c *Controller) saveTrade(ctx *gin.Context, trade *models.Trade) {
+	// The players of a trade never change after it was proposed
+	trade.Players = nil
+	err := c.Repo.Update(trade)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Get a show trade payload from a trade
+func (c *Controller) getTradePayload(trade models.Trade) models.ShowTrade {
+	return models.ShowTrade{
+		ID:                 trade.ID,
+		ProposerTeamID:     trade.ProposerTeamID,
+		ReceiverTeamID:     trade.ReceiverTeamID,
+		OfferedPlayerIDs:   trade.PlayersFrom(trade.ProposerTeamID),
+		RequestedPlayerIDs: trade.PlayersFrom(trade.ReceiverTeamID),
+		Cash:               trade.Cash,
+		ProposerAccepted:   trade.ProposerAccepted,
+		ReceiverAccepted:   trade.ReceiverAccepted,
+		Status:             trade.Status,
+	}
+}
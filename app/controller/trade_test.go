@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"errors"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+)
+
+func TestExecuteTradeSwapsPlayersAndCash(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	proposer, receiver := models.Team{Budget: 1000}, models.Team{Budget: 1000}
+	_ = repo.Create(&proposer)
+	_ = repo.Create(&receiver)
+	offered := models.Player{TeamID: proposer.ID}
+	requested := models.Player{TeamID: receiver.ID}
+	_ = repo.Create(&offered)
+	_ = repo.Create(&requested)
+	_ = repo.Create(&models.Transfer{PlayerID: requested.ID, Ask: 500})
+
+	trade := models.Trade{ProposerTeamID: proposer.ID, ReceiverTeamID: receiver.ID, Cash: -300, Status: models.TradePending}
+	_ = repo.Create(&trade)
+	_ = repo.Create(&models.TradePlayer{TradeID: trade.ID, PlayerID: offered.ID, FromTeamID: proposer.ID})
+	_ = repo.Create(&models.TradePlayer{TradeID: trade.ID, PlayerID: requested.ID, FromTeamID: receiver.ID})
+	trade, _ = repo.GetTrade(trade.ID)
+
+	if err := c.doExecuteTradeIn(repo, trade); err != nil {
+		t.Fatal(err)
+	}
+
+	o, _ := repo.GetPlayer(offered.ID)
+	r, _ := repo.GetPlayer(requested.ID)
+	tests.AssertEqual(t, o.TeamID, receiver.ID)
+	tests.AssertEqual(t, r.TeamID, proposer.ID)
+	p, _ := repo.GetTeam(proposer.ID)
+	rc, _ := repo.GetTeam(receiver.ID)
+	tests.AssertEqual(t, p.Budget, 1300)
+	tests.AssertEqual(t, rc.Budget, 700)
+	if _, err := repo.GetTransferWithPlayer(&requested); err == nil {
+		t.Error("open transfer was not cancelled")
+	}
+	executed, _ := repo.GetTrade(trade.ID)
+	tests.AssertEqual(t, executed.Status, models.TradeExecuted)
+
+	// Executing it again conflicts
+	err := c.doExecuteTradeIn(repo, trade)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
+
+func TestExecuteTradeConflictsWhenPlayerMoved(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	proposer, receiver := models.Team{}, models.Team{}
+	_ = repo.Create(&proposer)
+	_ = repo.Create(&receiver)
+	offered := models.Player{TeamID: receiver.ID}
+	_ = repo.Create(&offered)
+
+	trade := models.Trade{
+		ProposerTeamID: proposer.ID,
+		ReceiverTeamID: receiver.ID,
+		Status:         models.TradePending,
+		Players:        []models.TradePlayer{{PlayerID: offered.ID, FromTeamID: proposer.ID}},
+	}
+	_ = repo.Create(&trade)
+
+	err := c.doExecuteTradeIn(repo, trade)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
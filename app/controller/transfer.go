@@ -3,20 +3,29 @@ package controller
 import (
 	"../httputil"
 	"../models"
+	"../repos"
+	"../valuation"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
 	"math"
-	"math/rand"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
-	"strings"
+	"time"
 )
 
+// Amount of recent comparable sales the valuation model takes into account
+const comparableSalesCount = 10
+
+// Returned when a sale or a trade can't be executed because another request changed it or the teams first
+var errSaleConflict = errors.New("sale conflicts with a concurrent change")
+
 // Handles GET requests to the transfers resource
 // @Summary Show all transfers
-// @Description Show all transfers and filter by country, team name, player name, age and value
+// @Description Show all transfers and filter by country, team name, player name, age, overall rating and value. Results can be sorted and paginated with a cursor. Also reports if the market is open and when the current window closes.
 // @Tags Transfers
 // @Accept  json
 // @Produce  json
@@ -27,24 +36,45 @@ import (
 // @Param max_age query string false "Filter by the player's age"
 // @Param min_value query string false "Filter by the transfer ask value"
 // @Param max_value query string false "Filter by the transfer ask value"
+// @Param min_overall query int false "Filter by the player's overall rating"
+// @Param max_overall query int false "Filter by the player's overall rating"
 // @Param value_type query string false "Type of value to filter by. Can be 'market' or 'ask'. Defaults to 'ask'"
+// @Param available_only query bool false "Leave out injured and suspended players"
+// @Param sort query string false "Sort by 'ask', 'market_value', 'age' or 'created_at'. Defaults to 'created_at'"
+// @Param order query string false "Sort order. Can be 'asc' or 'desc'. Defaults to 'asc'"
+// @Param limit query int false "Maximum amount of transfers to return. Returns every transfer when missing"
+// @Param cursor query string false "Return the transfers after this cursor, taken from a previous 'next_cursor'"
 // @Success 200 {array} models.ShowTransfer
+// @Failure 400 {object} httputil.HTTPError
 // @Router /transfers [get]
 func (c *Controller) ListTransfers(ctx *gin.Context) {
-	filter := c.parseTransferFilters(ctx.Request.URL.Query())
-	transfers := c.Repo.GetTransfers()
+	query, err := c.parseTransferQuery(ctx)
+	if err != nil {
+		return
+	}
+	transfers, total := c.Repo.QueryTransfers(query)
 
 	arr := make([]models.ShowTransfer, 0)
 	for _, transfer := range transfers {
-		if !filter.Matches(transfer) {
-			continue
-		}
 		arr = append(arr, c.getTransferPayload(transfer))
 	}
 
-	httputil.NoError(ctx, map[string]interface{}{
+	payload := map[string]interface{}{
 		"transfers": arr,
-	})
+		"total":     total,
+	}
+	if query.Limit > 0 && len(transfers) == query.Limit {
+		payload["next_cursor"] = query.CursorOf(transfers[len(transfers)-1]).Encode()
+	}
+	open, window, next := c.getMarketStatus(time.Now())
+	payload["market_open"] = open
+	if window != nil {
+		payload["window_closes_at"] = window.ClosesAt
+	}
+	if next != nil {
+		payload["next_window_opens_at"] = next.OpensAt
+	}
+	httputil.NoError(ctx, payload)
 }
 
 // Handles GET requests to the transfers resource
@@ -70,7 +100,7 @@ func (c *Controller) ShowTransfer(ctx *gin.Context) {
 
 // Handles a POST request to a transfer resource
 // @Summary Create a new transfer
-// @Description Create a new transfer
+// @Description Create a new transfer. Transfers are sold at a fixed ask by default or to the highest bidder when the mode is 'auction'
 // @Tags Transfers
 // @Accept  json
 // @Produce  json
@@ -94,6 +124,9 @@ func (c *Controller) CreateTransfer(ctx *gin.Context) {
 		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
 		return
 	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
 
 	player, err := c.Repo.GetPlayer(t.PlayerID)
 	if err != nil {
@@ -105,21 +138,45 @@ func (c *Controller) CreateTransfer(ctx *gin.Context) {
 		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to create a transfer on a player not owned")
 		return
 	}
+	if player.InAcademy {
+		httputil.NewError(ctx, http.StatusBadRequest, "Academy players have to be promoted before they are transferred")
+		return
+	}
+	if _, err := c.Repo.GetActiveLoanOfPlayer(player.ID); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Players on loan can't be transferred")
+		return
+	}
+	if !c.validateSquadChange(ctx, player.TeamID, &player, nil) {
+		return
+	}
 	transfer, err := c.Repo.GetTransferWithPlayer(&player)
 	if err == nil {
 		httputil.NewError(ctx, http.StatusBadRequest, "Player already has an open transfer")
 		return
 	}
 
-	transfer = models.Transfer{
-		PlayerID: t.PlayerID,
-		Ask:      t.Ask,
+	if !c.validateTransferPayload(ctx, t) {
+		return
 	}
-	err = c.Repo.Create(&transfer)
+
+	fee := c.Repo.GetEconomicRules().ListingFee
+	if available := c.availableFunds(player.Team, 0); available < fee {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to pay the listing fee (%v < %v)", available, fee))
+		return
+	}
+
+	transfer = c.newTransferFromPayload(t)
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.doCreateTransferIn(tx, &transfer, player.TeamID)
+	})
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		c.writeSaleError(ctx, err)
 		return
 	}
+	c.publishTransferEvent(models.TransferEventListed, transfer)
+	if err := c.notifyTransferListed(c.Repo, transfer.ID); err != nil {
+		log.Println(err)
+	}
 
 	httputil.NoError(ctx, map[string]interface{}{
 		"id": transfer.ID,
@@ -128,7 +185,7 @@ func (c *Controller) CreateTransfer(ctx *gin.Context) {
 
 // Handles PATCH requests to the transfers resource
 // @Summary Updates a existing transfer.
-// @Description Updates a existing transfer by ID
+// @Description Updates a existing transfer by ID. Auctions cannot be updated, their price is set by the bids.
 // @Tags Transfers
 // @Accept  json
 // @Produce  json
@@ -159,7 +216,15 @@ func (c *Controller) UpdateTransfer(ctx *gin.Context) {
 		httputil.NewError(ctx, http.StatusUnauthorized, "Trying to update a not owned transfer")
 		return
 	}
+	if transfer.IsAuction() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Auctions cannot be updated")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
 
+	previousAsk := transfer.Ask
 	transfer.Ask = t.Ask
 
 	err = c.Repo.Update(&transfer)
@@ -167,20 +232,29 @@ func (c *Controller) UpdateTransfer(ctx *gin.Context) {
 		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	if transfer.Ask != previousAsk {
+		c.publishTransferEvent(models.TransferEventRepriced, transfer)
+		if err := c.notifyTransferRepriced(c.Repo, transfer, previousAsk); err != nil {
+			log.Println(err)
+		}
+	}
 
 	httputil.NoErrorEmpty(ctx)
 }
 
 // @Summary Buy a transfer
 // @Description Buys a transfer with a specific id and buys it. Updates budgets, values and finally deletes the transfer.
+// @Description The player signs a new contract with the buyer, it turns down wages below its asking wage.
 // @Tags Transfers
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Transfer ID"
+// @Param contract body models.ContractOffer false "Contract terms"
 // @Success 200
 // @Failure 401 {object} httputil.HTTPError
 // @Failure 400 {object} httputil.HTTPError
 // @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /transfers/{id}/buy [put]
 // @Security BearerAuth
@@ -204,15 +278,31 @@ func (c *Controller) BuyTransfer(ctx *gin.Context) {
 		return
 	}
 
-	if buyer.Budget < transfer.Ask {
-		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to execute the purchase (%v < %v)", buyer.Budget, transfer.Ask))
+	if transfer.IsAuction() {
+		httputil.NewError(ctx, http.StatusBadRequest, "Auctioned players can only be bought by bidding")
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
 		return
 	}
 
-	err = c.doExecuteTransfer(&transfer, buyer)
+	if !c.validateSaleSquadRules(ctx, transfer.Player, buyer.ID) {
+		return
+	}
+
+	if available := c.availableFunds(buyer, 0); available < transfer.Ask {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("Team does not have enough money to execute the purchase (%v < %v)", available, transfer.Ask))
+		return
+	}
+	now := time.Now()
+	terms, ok := c.getNewContractTerms(ctx, transfer.Player, now)
+	if !ok {
+		return
+	}
+
+	err = c.doExecuteTransferWithContract(&transfer, buyer, transfer.Ask, &terms, now)
 	if err != nil {
-		log.Println(err)
-		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		c.writeSaleError(ctx, err)
 		return
 	}
 	p, _ := c.Repo.GetPlayer(transfer.PlayerID)
@@ -248,46 +338,321 @@ func (c *Controller) DeleteTransfer(ctx *gin.Context) {
 		return
 	}
 
-	err := c.Repo.Delete(&transfer)
+	err := c.Repo.DeleteTransfer(&transfer)
 	if err != nil {
 		log.Println(err)
 		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
 		return
 	}
+	c.publishTransferEvent(models.TransferEventWithdrawn, transfer)
 
 	httputil.NoErrorEmpty(ctx)
 }
 
-// Execute a transfer and update the records if successful
-func (c *Controller) doExecuteTransfer(transfer *models.Transfer, buyer models.Team) error {
-	seller := transfer.Player.Team
-	// Randomly update the player value
-	player := transfer.Player
-	player.MarketValue = int32(float64(player.MarketValue) * (1.1 + rand.Float64()*0.9))
-
-	// Actually do the transfer
-	player.TeamID = buyer.ID
-	player.Team = buyer
-
-	seller.Budget += transfer.Ask
-	buyer.Budget -= transfer.Ask
-
-	return c.Repo.RunInTransaction(func() error {
-		err1 := c.Repo.Update(&player)
-		err2 := c.Repo.Update(&buyer)
-		err3 := c.Repo.Update(&seller)
-		err4 := c.Repo.Delete(&transfer)
-		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
-			return fmt.Errorf("failed to save models")
+// Execute a transfer for a given price in its own transaction
+func (c *Controller) doExecuteTransfer(transfer *models.Transfer, buyer models.Team, price int) error {
+	return c.doExecuteTransferWithContract(transfer, buyer, price, nil, time.Now())
+}
+
+// Execute a transfer and have the player sign a new contract with the buyer starting at a time in the same
+// transaction, the player keeps its contract when the terms are nil
+func (c *Controller) doExecuteTransferWithContract(transfer *models.Transfer, buyer models.Team, price int, terms *contractTerms, now time.Time) error {
+	listing := c.getFeedListing(*transfer)
+	err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		if err := c.doExecuteTransferIn(tx, transfer, buyer.ID, price); err != nil || terms == nil {
+			return err
+		}
+		return c.signContractIn(tx, transfer.PlayerID, *terms, now)
+	})
+	if err == nil {
+		c.publishTransferSold(listing, buyer.ID, price)
+	}
+	return err
+}
+
+// Execute a transfer for a given price inside a transaction and update the records if successful.
+// The rows involved are locked and checked again, so when concurrent requests race for the same
+// transfer or budget only the first one succeeds and the rest get errSaleConflict
+func (c *Controller) doExecuteTransferIn(tx repos.Repository, transfer *models.Transfer, buyerId uint, price int) error {
+	var locked models.Transfer
+	if err := tx.Lock(&locked, transfer.ID); err != nil || !locked.UpdatedAt.Equal(transfer.UpdatedAt) {
+		return errSaleConflict
+	}
+	return c.doExecuteSaleIn(tx, locked.PlayerID, &locked, buyerId, price)
+}
+
+// Sell a player to a team inside a transaction. The listing of the player has to be locked already and
+// gets deleted, it is nil when the player is sold without one.
+func (c *Controller) doExecuteSaleIn(tx repos.Repository, playerId uint, listing *models.Transfer, buyerId uint, price int) error {
+	var player models.Player
+	if err := tx.Lock(&player, playerId); err != nil || player.IsFreeAgent() {
+		// Free agents have no seller to pay, they are signed instead
+		return errSaleConflict
+	}
+	// The club that sold the player to the seller gets the sell-on fee, unless it is buying it back
+	previousId := c.getPreviousClubIn(tx, player)
+	if previousId == buyerId {
+		previousId = 0
+	}
+	teams, err := c.lockTeams(tx, player.TeamID, buyerId, previousId)
+	if err != nil {
+		return errSaleConflict
+	}
+	seller, buyer, previous := teams[player.TeamID], teams[buyerId], teams[previousId]
+	var listingId uint
+	var listedAt *time.Time
+	if listing != nil {
+		listingId, listedAt = listing.ID, &listing.CreatedAt
+	}
+	if c.availableFundsIn(tx, buyer, listingId) < price {
+		return errSaleConflict
+	}
+	// The squads may have changed since the sale was validated
+	if c.checkSquadChangesIn(tx, seller.ID, []models.Player{player}, nil) != nil ||
+		c.checkSquadChangesIn(tx, buyer.ID, nil, []models.Player{player}) != nil {
+		return errSaleConflict
+	}
+
+	// Update the player value from the sale
+	valueBefore := player.MarketValue
+	player.MarketValue = c.valuePlayerIn(tx, player, price)
+
+	// Actually do the transfer, the seller pays the tax and the sell-on fee from the price
+	// and the wages up to the sale, the buyer pays them from then on
+	tax, sellOn := tx.GetEconomicRules().SplitPrice(price, previousId != 0)
+	seller.Budget -= player.SettleWages(time.Now())
+	movePlayer(&player, buyer)
+	payTeam(&buyer, &seller, price)
+	seller.Budget -= tax
+	payTeam(&seller, &previous, sellOn)
+
+	record := models.CompletedTransfer{
+		PlayerID:     player.ID,
+		SellerTeamID: seller.ID,
+		BuyerTeamID:  buyer.ID,
+		Price:        price,
+		ValueBefore:  valueBefore,
+		ValueAfter:   player.MarketValue,
+		ListedAt:     listedAt,
+	}
+
+	err1 := tx.Update(&player)
+	err2 := tx.Update(&buyer)
+	err3 := tx.Update(&seller)
+	err4 := tx.Create(&record)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return fmt.Errorf("failed to save models")
+	}
+	if listing != nil {
+		if err := tx.DeleteTransfer(listing); err != nil {
+			return err
+		}
+	}
+	if previousId != 0 {
+		if err := tx.Update(&previous); err != nil {
+			return err
+		}
+	}
+	if err := c.recordSaleSplitIn(tx, record, listing, tax, sellOn, previousId); err != nil {
+		return err
+	}
+	return c.notifyPlayerSold(tx, player, seller, buyer, price)
+}
+
+// Save how the price of a sale was split between the treasury, the previous club and the seller, along with the fee
+// the seller paid for the listing it was sold through, if any
+func (c *Controller) recordSaleSplitIn(tx repos.Repository, record models.CompletedTransfer, listing *models.Transfer, tax, sellOn int, previousId uint) error {
+	items := []models.SaleLineItem{
+		{CompletedTransferID: record.ID, Kind: models.LineItemMarketTax, Amount: tax},
+		{CompletedTransferID: record.ID, Kind: models.LineItemSellOn, Amount: sellOn, TeamID: previousId},
+		{CompletedTransferID: record.ID, Kind: models.LineItemSellerNet, Amount: record.Price - tax - sellOn, TeamID: record.SellerTeamID},
+	}
+	if listing != nil {
+		items = append(items, models.SaleLineItem{CompletedTransferID: record.ID, Kind: models.LineItemListingFee, Amount: listing.ListingFee})
+	}
+	for i := range items {
+		if items[i].Amount == 0 && items[i].Kind != models.LineItemSellerNet {
+			continue
+		}
+		if err := tx.Create(&items[i]); err != nil {
+			return err
+		}
+	}
+	if tax == 0 {
+		return nil
+	}
+	return tx.Create(&models.TreasuryEntry{
+		Kind:                models.TreasuryMarketTax,
+		Amount:              tax,
+		TeamID:              record.SellerTeamID,
+		CompletedTransferID: record.ID,
+	})
+}
+
+// Move a player to a team, the ownership update every sale and trade goes through
+func movePlayer(player *models.Player, to models.Team) {
+	player.TeamID = to.ID
+	player.Team = to
+	// The clause and the lineup were set by the previous owner
+	player.ReleaseClause = 0
+	player.InLineup = false
+}
+
+// Move funds from a team to another, the budget update every sale and trade goes through
+func payTeam(from *models.Team, to *models.Team, amount int) {
+	from.Budget -= amount
+	to.Budget += amount
+}
+
+// Take every expired listing off the market and tell the sellers
+func (c *Controller) ExpireListings() error {
+	for _, transfer := range c.Repo.GetExpiredTransfers(time.Now()) {
+		if err := c.expireListing(transfer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete an expired listing and notify the owner of the player
+func (c *Controller) expireListing(transfer models.Transfer) error {
+	expired := false
+	err := c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		var locked models.Transfer
+		if err := tx.Lock(&locked, transfer.ID); err != nil || !locked.IsExpired(time.Now()) {
+			// It was sold or deleted in the meantime
+			return nil
+		}
+		if err := tx.DeleteTransfer(&locked); err != nil {
+			return err
 		}
+		expired = true
+		player, err := tx.GetPlayer(locked.PlayerID)
+		if err != nil {
+			return nil
+		}
+		team, err := tx.GetTeam(player.TeamID)
+		if err != nil {
+			return nil
+		}
+		return c.notifyListingExpired(tx, player, team, locked.ID)
+	})
+	if err == nil && expired {
+		c.publishTransferEvent(models.TransferEventExpired, transfer)
+	}
+	return err
+}
+
+// Create a transfer charging the listing fee to the team of the player
+func (c *Controller) doCreateTransferIn(tx repos.Repository, transfer *models.Transfer, teamId uint) error {
+	return c.doCreateTransfersIn(tx, []*models.Transfer{transfer}, teamId)
+}
+
+// Create transfers of players of the same team charging the listing fee of each one to the team.
+// The players are locked in ascending id order before the team, the same order a sale uses.
+func (c *Controller) doCreateTransfersIn(tx repos.Repository, transfers []*models.Transfer, teamId uint) error {
+	ordered := make([]*models.Transfer, len(transfers))
+	copy(ordered, transfers)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].PlayerID < ordered[j].PlayerID })
+	for _, transfer := range ordered {
+		var player models.Player
+		if err := tx.Lock(&player, transfer.PlayerID); err != nil || player.TeamID != teamId {
+			return errSaleConflict
+		}
+	}
+	teams, err := c.lockTeams(tx, teamId)
+	if err != nil {
+		return errSaleConflict
+	}
+	team := teams[teamId]
+	rules := tx.GetEconomicRules()
+	fee := rules.ListingFee
+	if c.availableFundsIn(tx, team, 0) < fee*len(transfers) {
+		return errSaleConflict
+	}
+
+	for _, transfer := range transfers {
+		if !transfer.IsAuction() && transfer.ExpiresAt == nil && rules.ListingLifetimeHours > 0 {
+			expiresAt := time.Now().Add(time.Duration(rules.ListingLifetimeHours) * time.Hour)
+			transfer.ExpiresAt = &expiresAt
+		}
+		transfer.ListingFee = fee
+		if err := tx.Create(transfer); err != nil {
+			return err
+		}
+		if fee == 0 {
+			continue
+		}
+		err := tx.Create(&models.TreasuryEntry{
+			Kind:       models.TreasuryListingFee,
+			Amount:     fee,
+			TeamID:     teamId,
+			TransferID: transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if fee == 0 {
 		return nil
+	}
+	team.Budget -= fee * len(transfers)
+	return tx.Update(&team)
+}
+
+// Lock teams by id in ascending order so concurrent transactions can't deadlock each other
+func (c *Controller) lockTeams(tx repos.Repository, ids ...uint) (map[uint]models.Team, error) {
+	sorted := append([]uint{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	teams := make(map[uint]models.Team)
+	for _, id := range sorted {
+		if _, ok := teams[id]; ok || id == 0 {
+			continue
+		}
+		var team models.Team
+		if err := tx.Lock(&team, id); err != nil {
+			return nil, err
+		}
+		teams[id] = team
+	}
+	return teams, nil
+}
+
+// Write the error of a sale that could not be executed
+func (c *Controller) writeSaleError(ctx *gin.Context, err error) {
+	if errors.Is(err, errSaleConflict) {
+		httputil.NewError(ctx, http.StatusConflict, "The deal was changed by another request, please try again")
+		return
+	}
+	log.Println(err)
+	httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+}
+
+// Get the market value of a player after being sold for a price, reading the comparable sales inside a transaction
+func (c *Controller) valuePlayerIn(tx repos.Repository, player models.Player, price int) int32 {
+	return c.valuationModel().Value(valuation.Sale{
+		Player:      player,
+		Price:       price,
+		Comparables: tx.GetComparableSales(player, comparableSalesCount),
 	})
 }
 
+// Get the configured valuation model, the default one when none was set
+func (c *Controller) valuationModel() valuation.Model {
+	if c.Valuation == nil {
+		return valuation.NewDefaultModel()
+	}
+	return c.Valuation
+}
+
 // Gets transfers from the request
 func (c *Controller) getTransferFromRequest(ctx *gin.Context) (models.Transfer, error) {
 	id, err := c.parseIdFromRequest(ctx, "transferId")
 	transfer, err := c.Repo.GetTransfer(id)
+	if err == nil && transfer.IsExpired(time.Now()) {
+		err = fmt.Errorf("transfer expired")
+	}
 	if err != nil {
 		httputil.NewError(ctx, http.StatusNotFound, "Not found")
 		return models.Transfer{}, err
@@ -295,6 +660,53 @@ func (c *Controller) getTransferFromRequest(ctx *gin.Context) (models.Transfer,
 	return transfer, nil
 }
 
+// Parse the URL parameters of a request into a transfer query
+func (c *Controller) parseTransferQuery(ctx *gin.Context) (repos.TransferQuery, error) {
+	q := ctx.Request.URL.Query()
+	query := repos.TransferQuery{
+		Filters:  c.parseTransferFilters(q),
+		Sort:     repos.SortByCreatedAt,
+		ActiveAt: time.Now(),
+	}
+
+	if v := q.Get("sort"); v != "" {
+		if !repos.ValidTransferSort(v) {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid sort")
+			return query, fmt.Errorf("invalid sort")
+		}
+		query.Sort = v
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid order")
+		return query, fmt.Errorf("invalid order")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid limit")
+			return query, fmt.Errorf("invalid limit")
+		}
+		query.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := repos.DecodeTransferCursor(v)
+		if err != nil {
+			httputil.NewError(ctx, http.StatusBadRequest, "Invalid cursor")
+			return query, fmt.Errorf("invalid cursor")
+		}
+		query.After = &cursor
+	}
+
+	return query, nil
+}
+
 // Parse URL parameters into a transferFilters object
 func (c *Controller) parseTransferFilters(q url.Values) transferFilters {
 	filter := transferFilters{
@@ -332,33 +744,21 @@ func (c *Controller) parseTransferFilters(q url.Values) transferFilters {
 		filter.MaxValueFilter = int(value)
 	}
 
-	return filter
-}
+	if overall, err := strconv.ParseInt(q.Get("min_overall"), 10, 32); err == nil {
+		filter.MinOverallFilter = int(overall)
+	}
+	if overall, err := strconv.ParseInt(q.Get("max_overall"), 10, 32); err == nil {
+		filter.MaxOverallFilter = int(overall)
+	}
+	if available, err := strconv.ParseBool(q.Get("available_only")); err == nil && available {
+		filter.AvailableAt = time.Now()
+	}
 
-// A group of filters to apply to matches
-type transferFilters struct {
-	Country        string
-	TeamName       string
-	PlayerName     string
-	MinAgeFilter   int
-	MinValueFilter int
-	MaxAgeFilter   int
-	MaxValueFilter int
-	ValueType      string
+	return filter
 }
 
-// Returns a bool that tells if the transfer matches with the filter
-func (f *transferFilters) Matches(transfer models.Transfer) bool {
-	value := transfer.Ask
-	if f.ValueType == "market" {
-		value = int(transfer.Player.MarketValue)
-	}
-	return strings.Contains(strings.ToLower(transfer.Player.FirstName+" "+transfer.Player.LastName), strings.ToLower(f.PlayerName)) &&
-		strings.Contains(strings.ToLower(transfer.Player.Team.Name), strings.ToLower(f.TeamName)) &&
-		strings.Contains(strings.ToLower(transfer.Player.Country), strings.ToLower(f.Country)) &&
-		value >= f.MinValueFilter && transfer.Player.Age >= f.MinAgeFilter &&
-		value <= f.MaxValueFilter && transfer.Player.Age <= f.MaxAgeFilter
-}
+// A group of filters to apply to transfers
+type transferFilters = repos.TransferFilters
 
 /// Fill the transfer payload with default values
 func (c *Controller) fillDefaultTransferPayload(transfer models.Transfer) models.UpdateTransfer {
@@ -367,11 +767,78 @@ func (c *Controller) fillDefaultTransferPayload(transfer models.Transfer) models
 	return payload
 }
 
+// Validate the mode specific fields of a create transfer payload
+func (c *Controller) validateTransferPayload(ctx *gin.Context, t models.CreateTransfer) bool {
+	if msg := c.checkTransferPayload(t); msg != "" {
+		httputil.NewError(ctx, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
+
+// Check the mode specific fields of a create transfer payload, returns why it is invalid or an empty string
+func (c *Controller) checkTransferPayload(t models.CreateTransfer) string {
+	switch t.Mode {
+	case "", models.TransferModeFixed:
+		if t.Ask <= 0 {
+			return "Invalid body parameters"
+		}
+		if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+			return "Listings need an expiry in the future"
+		}
+	case models.TransferModeAuction:
+		if t.ExpiresAt != nil {
+			return "Auctions close at their end time and can't expire"
+		}
+		if t.EndsAt == nil || !t.EndsAt.After(time.Now()) {
+			return "Auctions need an end time in the future"
+		}
+		if t.ReservePrice < 0 {
+			return "Reserve price can't be negative"
+		}
+	default:
+		return "Mode must be either 'fixed' or 'auction'"
+	}
+	return ""
+}
+
+// Build a transfer model from an already validated create transfer payload
+func (c *Controller) newTransferFromPayload(t models.CreateTransfer) models.Transfer {
+	if t.Mode != models.TransferModeAuction {
+		return models.Transfer{
+			PlayerID:  t.PlayerID,
+			Ask:       t.Ask,
+			Mode:      models.TransferModeFixed,
+			ExpiresAt: t.ExpiresAt,
+		}
+	}
+	// The ask mirrors the reserve price so auctions can be filtered by value
+	return models.Transfer{
+		PlayerID:     t.PlayerID,
+		Ask:          t.ReservePrice,
+		Mode:         models.TransferModeAuction,
+		ReservePrice: t.ReservePrice,
+		EndsAt:       t.EndsAt,
+	}
+}
+
 // Get a show transfer payload from a transfer
 func (c *Controller) getTransferPayload(transfer models.Transfer) models.ShowTransfer {
-	return models.ShowTransfer{
-		ID:     transfer.ID,
-		Player: c.getPlayerPayload(transfer.Player),
-		Ask:    transfer.Ask,
+	payload := models.ShowTransfer{
+		ID:        transfer.ID,
+		Player:    c.getPlayerPayload(transfer.Player),
+		Ask:       transfer.Ask,
+		Mode:      models.TransferModeFixed,
+		ExpiresAt: transfer.ExpiresAt,
 	}
+	payload.Injured = payload.Player.Availability == models.PlayerInjured
+	if transfer.IsAuction() {
+		payload.Mode = transfer.Mode
+		payload.ReservePrice = transfer.ReservePrice
+		payload.EndsAt = transfer.EndsAt
+		if bid := transfer.HighestBid(); bid != nil {
+			payload.HighestBid = bid.Amount
+		}
+	}
+	return payload
 }
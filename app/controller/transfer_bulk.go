@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"../repos"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Most items a bulk request can carry
+const maxBulkTransfers = 50
+
+// Handles POST requests to list several players at once
+// @Summary Create several transfers
+// @Description List several players of the same team, each with its own ask. Every item is validated before anything is created and either all of them are listed or none is. The result of every item is returned, also when the batch is rejected.
+// @Tags Transfers
+// @Accept  json
+// @Produce  json
+// @Param transfers body models.CreateBulkTransfers true "Transfers to create"
+// @Success 200 {array} models.BulkTransferResult
+// @Failure 400 {object} httputil.HTTPBatchError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/bulk [post]
+// @Security BearerAuth
+func (c *Controller) CreateBulkTransfers(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateBulkTransfers
+	if err := ctx.ShouldBindJSON(&t); err != nil || len(t.Transfers) == 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if len(t.Transfers) > maxBulkTransfers {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("A batch can't have more than %v items", maxBulkTransfers))
+		return
+	}
+	if !c.validateMarketIsOpen(ctx) {
+		return
+	}
+
+	results, teamId, ok := c.checkBulkListing(user, t.Transfers)
+	if !ok {
+		httputil.NewBatchError(ctx, http.StatusBadRequest, "No transfer was created, some items are invalid", results)
+		return
+	}
+
+	transfers := make([]*models.Transfer, 0)
+	for _, item := range t.Transfers {
+		transfer := c.newTransferFromPayload(item)
+		transfers = append(transfers, &transfer)
+	}
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.doCreateTransfersIn(tx, transfers, teamId)
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+	for i, transfer := range transfers {
+		results[i].TransferID = transfer.ID
+		c.publishTransferEvent(models.TransferEventListed, *transfer)
+		if err := c.notifyTransferListed(c.Repo, transfer.ID); err != nil {
+			log.Println(err)
+		}
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"results": results,
+	})
+}
+
+// Handles DELETE requests to withdraw several transfers at once
+// @Summary Delete several transfers
+// @Description Withdraw several transfers. Every item is validated before anything is deleted and either all of them are withdrawn or none is. The result of every item is returned, also when the batch is rejected.
+// @Tags Transfers
+// @Accept  json
+// @Produce  json
+// @Param transfers body models.DeleteBulkTransfers true "Transfers to delete"
+// @Success 200 {array} models.BulkTransferResult
+// @Failure 400 {object} httputil.HTTPBatchError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfers/bulk [delete]
+// @Security BearerAuth
+func (c *Controller) DeleteBulkTransfers(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.DeleteBulkTransfers
+	if err := ctx.ShouldBindJSON(&t); err != nil || len(t.TransferIDs) == 0 {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if len(t.TransferIDs) > maxBulkTransfers {
+		httputil.NewError(ctx, http.StatusBadRequest, fmt.Sprintf("A batch can't have more than %v items", maxBulkTransfers))
+		return
+	}
+
+	results, transfers, ok := c.checkBulkDelisting(user, t.TransferIDs)
+	if !ok {
+		httputil.NewBatchError(ctx, http.StatusBadRequest, "No transfer was deleted, some items are invalid", results)
+		return
+	}
+
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		return c.doDeleteTransfersIn(tx, transfers)
+	})
+	if err != nil {
+		c.writeSaleError(ctx, err)
+		return
+	}
+	for _, transfer := range transfers {
+		c.publishTransferEvent(models.TransferEventWithdrawn, transfer)
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"results": results,
+	})
+}
+
+// Check every item of a bulk listing with the same rules as a single listing, the players also
+// have to belong to the same team. Returns the result of every item, the team and if all of them are valid.
+func (c *Controller) checkBulkListing(user models.User, items []models.CreateTransfer) ([]models.BulkTransferResult, uint, bool) {
+	results := make([]models.BulkTransferResult, len(items))
+	seen := make(map[uint]bool)
+	var team *models.Team
+	listed := make([]models.Player, 0)
+	for i, item := range items {
+		results[i].PlayerID = item.PlayerID
+		if seen[item.PlayerID] {
+			results[i].Error = "Player is more than once in the batch"
+			continue
+		}
+		seen[item.PlayerID] = true
+
+		player, err := c.Repo.GetPlayer(item.PlayerID)
+		if err != nil {
+			results[i].Error = "Player not found"
+			continue
+		}
+		if team == nil {
+			t, err := c.Repo.GetTeam(player.TeamID)
+			if err != nil {
+				results[i].Error = "Player not found"
+				continue
+			}
+			team = &t
+		}
+		if player.TeamID != team.ID {
+			results[i].Error = "Every player of the batch has to belong to the same team"
+			continue
+		}
+		if msg := c.checkPlayerCanBeListed(user, *team, player); msg != "" {
+			results[i].Error = msg
+			continue
+		}
+		if msg := c.checkTransferPayload(item); msg != "" {
+			results[i].Error = msg
+			continue
+		}
+		listed = append(listed, player)
+	}
+	if team == nil || len(listed) < len(items) {
+		return results, 0, false
+	}
+
+	// The squad has to keep within the rules without all the listed players at once
+	if violation := c.checkSquadChangesIn(c.Repo, team.ID, listed, nil); violation != nil {
+		for i := range results {
+			results[i].Error, results[i].Rule = violation.Message, violation.Rule
+		}
+		return results, 0, false
+	}
+	fees := c.Repo.GetEconomicRules().ListingFee * len(items)
+	if available := c.availableFunds(*team, 0); available < fees {
+		for i := range results {
+			results[i].Error = fmt.Sprintf("Team does not have enough money to pay the listing fees (%v < %v)", available, fees)
+		}
+		return results, 0, false
+	}
+	return results, team.ID, true
+}
+
+// Check a user can list a player of a team, returns why it can't or an empty string
+func (c *Controller) checkPlayerCanBeListed(user models.User, team models.Team, player models.Player) string {
+	if !user.IsAdmin() && team.UserID != user.ID {
+		return "Trying to create a transfer on a player not owned"
+	}
+	if player.InAcademy {
+		return "Academy players have to be promoted before they are transferred"
+	}
+	if _, err := c.Repo.GetActiveLoanOfPlayer(player.ID); err == nil {
+		return "Players on loan can't be transferred"
+	}
+	if _, err := c.Repo.GetTransferWithPlayer(&player); err == nil {
+		return "Player already has an open transfer"
+	}
+	return ""
+}
+
+// Check every item of a bulk withdrawal with the same rules as a single one.
+// Returns the result of every item, the transfers and if all of them are valid.
+func (c *Controller) checkBulkDelisting(user models.User, ids []uint) ([]models.BulkTransferResult, []models.Transfer, bool) {
+	results := make([]models.BulkTransferResult, len(ids))
+	transfers := make([]models.Transfer, 0)
+	seen := make(map[uint]bool)
+	for i, id := range ids {
+		results[i].TransferID = id
+		if seen[id] {
+			results[i].Error = "Transfer is more than once in the batch"
+			continue
+		}
+		seen[id] = true
+
+		transfer, err := c.Repo.GetTransfer(id)
+		if err != nil || transfer.IsExpired(time.Now()) {
+			results[i].Error = "Transfer not found"
+			continue
+		}
+		results[i].PlayerID = transfer.PlayerID
+		team, err := c.Repo.GetTeam(c.getFeedListing(transfer).Player.TeamID)
+		if err != nil || (!user.IsAdmin() && team.UserID != user.ID) {
+			results[i].Error = "Trying to delete a not owned transfer"
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+	return results, transfers, len(transfers) == len(ids)
+}
+
+// Delete transfers locking them in ascending id order, the same order sales use,
+// fails with errSaleConflict if any of them was sold or deleted in the meantime
+func (c *Controller) doDeleteTransfersIn(tx repos.Repository, transfers []models.Transfer) error {
+	ordered := make([]models.Transfer, len(transfers))
+	copy(ordered, transfers)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].ID < ordered[j].ID })
+	for _, transfer := range ordered {
+		var locked models.Transfer
+		if err := tx.Lock(&locked, transfer.ID); err != nil {
+			return errSaleConflict
+		}
+		if err := tx.DeleteTransfer(&locked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
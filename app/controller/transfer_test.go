@@ -2,11 +2,14 @@ package controller
 
 import (
 	"../models"
+	"../repos"
+	"errors"
 	"fmt"
 	"gorm.io/gorm/utils/tests"
 	"math"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestControllerParseTransferFilters(t *testing.T) {
@@ -154,3 +157,177 @@ func TestControllerTransferFilterMatches(t *testing.T) {
 		tests.AssertEqual(t, filter.Matches(transfer), false)
 	}
 }
+
+func TestNewTransferFromPayload(t *testing.T) {
+	c := Controller{}
+	fixed := c.newTransferFromPayload(models.CreateTransfer{PlayerID: 1, Ask: 500})
+	tests.AssertEqual(t, fixed.Mode, models.TransferModeFixed)
+	tests.AssertEqual(t, fixed.Ask, 500)
+	tests.AssertEqual(t, fixed.IsAuction(), false)
+
+	endsAt := time.Now().Add(time.Hour)
+	auction := c.newTransferFromPayload(models.CreateTransfer{
+		PlayerID:     1,
+		Mode:         models.TransferModeAuction,
+		ReservePrice: 1000,
+		EndsAt:       &endsAt,
+	})
+	tests.AssertEqual(t, auction.IsAuction(), true)
+	tests.AssertEqual(t, auction.Ask, 1000)
+	tests.AssertEqual(t, auction.ReservePrice, 1000)
+	tests.AssertEqual(t, auction.HasEnded(time.Now()), false)
+	tests.AssertEqual(t, auction.HasEnded(endsAt), true)
+}
+
+func TestExecuteTransferConflicts(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	seller, buyer := models.Team{Budget: 0}, models.Team{Budget: 1000}
+	_ = repo.Create(&seller)
+	_ = repo.Create(&buyer)
+	player := models.Player{TeamID: seller.ID, MarketValue: 1000}
+	_ = repo.Create(&player)
+	transfer := models.Transfer{PlayerID: player.ID, Player: player, Ask: 500}
+	_ = repo.Create(&transfer)
+
+	// The buyer can't afford the price once its team is locked
+	err := c.doExecuteTransferIn(repo, &transfer, buyer.ID, 2000)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+
+	if err := c.doExecuteTransfer(&transfer, buyer, 500); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := repo.GetTeam(seller.ID)
+	b, _ := repo.GetTeam(buyer.ID)
+	tests.AssertEqual(t, s.Budget, 500)
+	tests.AssertEqual(t, b.Budget, 500)
+
+	// A second purchase of the same transfer loses the race
+	err = c.doExecuteTransfer(&transfer, buyer, 500)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
+
+func TestExpireListingsNotifiesTheSeller(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	user, _ := repo.CreateUser("seller@test.com", []byte{}, 0)
+	team, _ := repo.GetUserTeam(user)
+	players := repo.GetPlayers(team.ID)
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	expired := models.Transfer{PlayerID: players[0].ID, Ask: 1000, ExpiresAt: &past}
+	active := models.Transfer{PlayerID: players[1].ID, Ask: 1000, ExpiresAt: &future}
+	_ = repo.Create(&expired)
+	_ = repo.Create(&active)
+
+	if err := c.ExpireListings(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetTransfer(expired.ID); err == nil {
+		t.Error("expired listing was not deleted")
+	}
+	if _, err := repo.GetTransfer(active.ID); err != nil {
+		t.Error("active listing was deleted")
+	}
+	notifications := repo.GetNotifications(user.ID, true)
+	tests.AssertEqual(t, len(notifications), 1)
+	tests.AssertEqual(t, notifications[0].Kind, models.NotificationListingExpired)
+}
+
+func TestCreateTransferUsesListingLifetime(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	_ = repo.Create(&models.EconomicRules{ListingLifetimeHours: 24})
+	team := models.Team{}
+	_ = repo.Create(&team)
+	player := models.Player{TeamID: team.ID}
+	_ = repo.Create(&player)
+
+	transfer := models.Transfer{PlayerID: player.ID, Ask: 1000, Mode: models.TransferModeFixed}
+	if err := c.doCreateTransferIn(repo, &transfer, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	if transfer.ExpiresAt == nil || transfer.ExpiresAt.Sub(time.Now()) < 23*time.Hour {
+		t.Errorf("unexpected expiry %v", transfer.ExpiresAt)
+	}
+}
+
+func TestBulkListingIsAllOrNothing(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	_ = repo.Create(&models.EconomicRules{ListingFee: 100})
+	user, _ := repo.CreateUser("seller@test.com", []byte{}, 0)
+	other, _ := repo.CreateUser("other@test.com", []byte{}, 0)
+	team, _ := repo.GetUserTeam(user)
+	otherTeam, _ := repo.GetUserTeam(other)
+	players := repo.GetPlayers(team.ID)
+	otherPlayers := repo.GetPlayers(otherTeam.ID)
+
+	items := []models.CreateTransfer{
+		{PlayerID: players[0].ID, Ask: 1000},
+		{PlayerID: otherPlayers[0].ID, Ask: 1000},
+		{PlayerID: players[0].ID, Ask: 1000},
+	}
+	results, _, ok := c.checkBulkListing(user, items)
+	tests.AssertEqual(t, ok, false)
+	tests.AssertEqual(t, results[0].Error, "")
+	tests.AssertEqual(t, results[1].Error, "Every player of the batch has to belong to the same team")
+	tests.AssertEqual(t, results[2].Error, "Player is more than once in the batch")
+
+	items = []models.CreateTransfer{{PlayerID: players[0].ID, Ask: 1000}, {PlayerID: players[1].ID, Ask: 1000}}
+	results, teamId, ok := c.checkBulkListing(user, items)
+	tests.AssertEqual(t, ok, true)
+	tests.AssertEqual(t, teamId, team.ID)
+
+	transfers := []*models.Transfer{}
+	for _, item := range items {
+		transfer := c.newTransferFromPayload(item)
+		transfers = append(transfers, &transfer)
+	}
+	if err := c.doCreateTransfersIn(repo, transfers, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := repo.GetTeam(team.ID)
+	tests.AssertEqual(t, updated.Budget, team.Budget-200)
+
+	listed := []models.Transfer{*transfers[0], *transfers[1]}
+	if err := c.doDeleteTransfersIn(repo, listed); err != nil {
+		t.Fatal(err)
+	}
+	// A second withdrawal of the same listings loses the race
+	err := c.doDeleteTransfersIn(repo, listed)
+	tests.AssertEqual(t, errors.Is(err, errSaleConflict), true)
+}
+
+func TestTransferFiltersByOverall(t *testing.T) {
+	c := Controller{}
+	filters := c.parseTransferFilters(url.Values{"min_overall": {"70"}, "max_overall": {"80"}})
+	tests.AssertEqual(t, filters.MinOverallFilter, 70)
+	tests.AssertEqual(t, filters.MaxOverallFilter, 80)
+
+	for overall, matches := range map[int]bool{69: false, 70: true, 80: true, 81: false} {
+		transfer := models.Transfer{Player: models.Player{Age: 25, Overall: overall}}
+		tests.AssertEqual(t, filters.Matches(transfer), matches)
+	}
+	// Without bounds the rating is not taken into account
+	filters = c.parseTransferFilters(url.Values{})
+	tests.AssertEqual(t, filters.Matches(models.Transfer{}), true)
+}
+
+func TestOverallRatingDependsOnPosition(t *testing.T) {
+	attributes := models.PlayerAttributes{Pace: 70, Shooting: 50, Passing: 60, Defending: 80, Goalkeeping: 10, Physical: 75}
+	tests.AssertEqual(t, attributes.OverallRating(models.Defender), 75)
+	tests.AssertEqual(t, attributes.OverallRating(models.Goalkeeper), 22)
+
+	player := models.Player{Position: models.Defender}
+	player.SetAttributes(attributes)
+	tests.AssertEqual(t, player.Overall, 75)
+	// The potential is never below the current rating
+	tests.AssertEqual(t, player.Potential, 75)
+
+	for position := range models.PositionNames {
+		generated := models.RandomPlayer(position)
+		if generated.Overall < models.MinAttribute || generated.Potential < generated.Overall {
+			t.Errorf("unexpected attributes %+v", generated.PlayerAttributes)
+		}
+	}
+}
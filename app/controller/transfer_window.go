@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Handles GET requests to the transfer windows resource
+// @Summary List transfer windows
+// @Description List every transfer window sorted by opening time. The market is always open when no windows are defined.
+// @Tags Transfer windows
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.ShowTransferWindow
+// @Router /transfer-windows [get]
+func (c *Controller) ListTransferWindows(ctx *gin.Context) {
+	arr := make([]models.ShowTransferWindow, 0)
+	for _, w := range c.Repo.GetTransferWindows() {
+		arr = append(arr, c.getTransferWindowPayload(w))
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"windows": arr,
+	})
+}
+
+// Handles GET requests to the transfer windows resource
+// @Summary Show a transfer window
+// @Description Get a transfer window by ID
+// @Tags Transfer windows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer window ID"
+// @Success 200 {object} models.ShowTransferWindow
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Router /transfer-windows/{id} [get]
+func (c *Controller) ShowTransferWindow(ctx *gin.Context) {
+	window, err := c.getTransferWindowFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	httputil.NoError(ctx, c.getTransferWindowPayload(window))
+}
+
+// Handles POST requests to the transfer windows resource
+// @Summary Create a transfer window
+// @Description Create a new transfer window
+// @Tags Transfer windows
+// @Accept  json
+// @Produce  json
+// @Param window body models.CreateTransferWindow true "Create transfer window"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfer-windows [post]
+// @Security BearerAuth[admin]
+func (c *Controller) CreateTransferWindow(ctx *gin.Context) {
+	var t models.CreateTransferWindow
+	err := ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if !t.ClosesAt.After(t.OpensAt) {
+		httputil.NewError(ctx, http.StatusBadRequest, "A transfer window needs to close after it opens")
+		return
+	}
+
+	window := models.TransferWindow{
+		Name:     t.Name,
+		OpensAt:  t.OpensAt,
+		ClosesAt: t.ClosesAt,
+	}
+	err = c.Repo.Create(&window)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoError(ctx, map[string]interface{}{
+		"id": window.ID,
+	})
+}
+
+// Handles PATCH requests to the transfer windows resource
+// @Summary Update a transfer window
+// @Description Update a transfer window by ID
+// @Tags Transfer windows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer window ID"
+// @Param window body models.UpdateTransferWindow true "Update transfer window"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfer-windows/{id} [patch]
+// @Security BearerAuth[admin]
+func (c *Controller) UpdateTransferWindow(ctx *gin.Context) {
+	window, err := c.getTransferWindowFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	t := c.fillDefaultTransferWindowPayload(window)
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if !t.ClosesAt.After(t.OpensAt) {
+		httputil.NewError(ctx, http.StatusBadRequest, "A transfer window needs to close after it opens")
+		return
+	}
+
+	window.Name = t.Name
+	window.OpensAt = t.OpensAt
+	window.ClosesAt = t.ClosesAt
+
+	err = c.Repo.Update(&window)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles DELETE requests to the transfer windows resource
+// @Summary Delete a transfer window
+// @Description Delete a transfer window by ID
+// @Tags Transfer windows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transfer window ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /transfer-windows/{id} [delete]
+// @Security BearerAuth[admin]
+func (c *Controller) DeleteTransferWindow(ctx *gin.Context) {
+	window, err := c.getTransferWindowFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	err = c.Repo.Delete(&window)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Get the state of the market at a given time. Returns if it is open, the window it is open on and the next window to open.
+// The market is always open when no windows are defined.
+func (c *Controller) getMarketStatus(now time.Time) (bool, *models.TransferWindow, *models.TransferWindow) {
+	windows := c.Repo.GetTransferWindows()
+	if len(windows) == 0 {
+		return true, nil, nil
+	}
+
+	var next *models.TransferWindow
+	for i := range windows {
+		if windows[i].IsOpen(now) {
+			return true, &windows[i], nil
+		}
+		if next == nil && windows[i].OpensAt.After(now) {
+			next = &windows[i]
+		}
+	}
+	return false, nil, next
+}
+
+// Validate the market is open and write an error explaining why if it is not
+func (c *Controller) validateMarketIsOpen(ctx *gin.Context) bool {
+	open, _, next := c.getMarketStatus(time.Now())
+	if open {
+		return true
+	}
+
+	msg := "The transfer market is closed"
+	if next != nil {
+		msg += fmt.Sprintf(", the next window '%v' opens at %v", next.Name, next.OpensAt.Format(time.RFC3339))
+	}
+	httputil.NewError(ctx, http.StatusBadRequest, msg)
+	return false
+}
+
+// Gets a transfer window model from the id in the request
+func (c *Controller) getTransferWindowFromRequest(ctx *gin.Context) (models.TransferWindow, error) {
+	id, err := c.parseIdFromRequest(ctx, "windowId")
+	if err != nil {
+		return models.TransferWindow{}, err
+	}
+
+	window, err := c.Repo.GetTransferWindow(id)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Transfer window not found")
+		return models.TransferWindow{}, err
+	}
+	return window, nil
+}
+
+// Fill the transfer window payload with default values
+func (c *Controller) fillDefaultTransferWindowPayload(window models.TransferWindow) models.UpdateTransferWindow {
+	var payload models.UpdateTransferWindow
+	payload.Name = window.Name
+	payload.OpensAt = window.OpensAt
+	payload.ClosesAt = window.ClosesAt
+	return payload
+}
+
+// Get a show transfer window payload from a transfer window
+func (c *Controller) getTransferWindowPayload(window models.TransferWindow) models.ShowTransferWindow {
+	return models.ShowTransferWindow{
+		ID:       window.ID,
+		Name:     window.Name,
+		OpensAt:  window.OpensAt,
+		ClosesAt: window.ClosesAt,
+	}
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"../models"
+	"../repos"
+	"gorm.io/gorm/utils/tests"
+	"testing"
+	"time"
+)
+
+func TestMarketIsOpenWithoutWindows(t *testing.T) {
+	c := Controller{Repo: repos.CreateRepositoryMemory()}
+	open, window, next := c.getMarketStatus(time.Now())
+	tests.AssertEqual(t, open, true)
+	tests.AssertEqual(t, window == nil, true)
+	tests.AssertEqual(t, next == nil, true)
+}
+
+func TestMarketStatusFollowsWindows(t *testing.T) {
+	repo := repos.CreateRepositoryMemory()
+	c := Controller{Repo: repo}
+	now := time.Now()
+	_ = repo.Create(&models.TransferWindow{Name: "summer", OpensAt: now.Add(48 * time.Hour), ClosesAt: now.Add(72 * time.Hour)})
+	_ = repo.Create(&models.TransferWindow{Name: "winter", OpensAt: now.Add(-time.Hour), ClosesAt: now.Add(time.Hour)})
+
+	open, window, _ := c.getMarketStatus(now)
+	tests.AssertEqual(t, open, true)
+	tests.AssertEqual(t, window.Name, "winter")
+
+	open, window, next := c.getMarketStatus(now.Add(2 * time.Hour))
+	tests.AssertEqual(t, open, false)
+	tests.AssertEqual(t, window == nil, true)
+	tests.AssertEqual(t, next.Name, "summer")
+
+	open, _, next = c.getMarketStatus(now.Add(100 * time.Hour))
+	tests.AssertEqual(t, open, false)
+	tests.AssertEqual(t, next == nil, true)
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"../httputil"
 	"../models"
+	"../repos"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"log"
@@ -192,18 +193,18 @@ func (c *Controller) DeleteUser(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
-	err = c.Repo.RunInTransaction(func() error {
-		team, err := c.Repo.GetUserTeam(user)
+	err = c.Repo.RunInTransaction(func(tx repos.Repository) error {
+		team, err := tx.GetUserTeam(user)
 		if err != nil {
 			return err
 		}
 
-		err = c.Repo.DeleteTeam(&team)
+		err = tx.DeleteTeam(&team)
 		if err != nil {
 			return err
 		}
 
-		return c.Repo.Delete(&user)
+		return tx.Delete(&user)
 	})
 	if err != nil {
 		log.Println(err)
@@ -215,7 +216,7 @@ func (c *Controller) DeleteUser(ctx *gin.Context) {
 
 // Handles PATCH requests to the user's resource
 // @Summary Update a user
-// @Description Update user by ID
+// @Description Update user by ID, an admin can also freeze or unfreeze the account
 // @Tags Users
 // @Accept  json
 // @Produce  json
@@ -243,6 +244,7 @@ func (c *Controller) UpdateUser(ctx *gin.Context) {
 	}
 
 	user.Email = t.Email
+	user.Frozen = t.Frozen
 	err = c.Repo.Update(user)
 	if err != nil {
 		httputil.NewError(ctx, http.StatusBadRequest, "Incorrect body parameters")
@@ -290,7 +292,7 @@ func (c *Controller) CreateUser(ctx *gin.Context) {
 
 	log.Println("Registering a new user...")
 
-	user, err := c.registerUser(t.Email, t.Password)
+	user, err := c.registerUser(t.Email, t.Password, ctx.ClientIP())
 	if err != nil {
 		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
 		return
@@ -340,13 +342,19 @@ func (c *Controller) emailExists(email string) bool {
 }
 
 // Registers a new user with the given credentials
-func (c *Controller) registerUser(email, password string) (models.User, error) {
+func (c *Controller) registerUser(email, password, ip string) (models.User, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return models.User{}, err
 	}
 
-	return c.Repo.CreateUser(email, hashedPassword, 0)
+	user, err := c.Repo.CreateUser(email, hashedPassword, 0)
+	if err != nil {
+		return user, err
+	}
+	// Kept to spot accounts that were registered by the same person
+	user.SignupIP = ip
+	return user, c.Repo.Update(&user)
 }
 
 // Parse a user from the request parameters or return an error if not found
@@ -374,9 +382,10 @@ func (c *Controller) getShowUserPayload(ctx *gin.Context, user models.User) (mod
 	}
 
 	return models.ShowUser{
-		ID:    user.ID,
-		Email: user.Email,
-		Team:  c.getTeamPayload(team, c.Repo.GetPlayers(team.ID)),
+		ID:     user.ID,
+		Email:  user.Email,
+		Frozen: user.Frozen,
+		Team:   c.getFullTeamPayload(team),
 	}, nil
 }
 
@@ -384,5 +393,6 @@ func (c *Controller) getShowUserPayload(ctx *gin.Context, user models.User) (mod
 func (c *Controller) fillDefaultUserPayload(user models.User) models.UpdateUser {
 	var payload models.UpdateUser
 	payload.Email = user.Email
+	payload.Frozen = user.Frozen
 	return payload
 }
@@ -47,7 +47,7 @@ func TestRegisterUser(t *testing.T) {
 	c := Controller{Repo: repos.CreateRepositoryMemory()}
 	email := "test@gmail.com"
 	pass := "hello123"
-	user, err := c.registerUser(email, pass)
+	user, err := c.registerUser(email, pass, "")
 	if err != nil {
 		t.Error(err)
 	}
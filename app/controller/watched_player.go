@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"../httputil"
+	"../models"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// Handles GET requests to the watchlist of the logged in user
+// @Summary Show my watchlist
+// @Description Show the players the logged in user watches
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.ShowWatchedPlayer
+// @Failure 401 {object} httputil.HTTPError
+// @Router /me/watchlist [get]
+// @Security BearerAuth
+func (c *Controller) ListMyWatchlist(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	arr := make([]models.ShowWatchedPlayer, 0)
+	for _, w := range c.Repo.GetWatchedPlayers(user.ID) {
+		arr = append(arr, models.ShowWatchedPlayer{
+			PlayerID:  w.PlayerID,
+			WatchedAt: w.CreatedAt,
+		})
+	}
+	httputil.NoError(ctx, map[string]interface{}{
+		"players": arr,
+	})
+}
+
+// Handles POST requests to the watchlist of the logged in user
+// @Summary Watch a player
+// @Description Watch a player, the user gets a notification when the player is listed, re-priced or sold
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param player body models.CreateWatchedPlayer true "Player to watch"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/watchlist [post]
+// @Security BearerAuth
+func (c *Controller) WatchPlayer(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+
+	var t models.CreateWatchedPlayer
+	err = ctx.ShouldBindJSON(&t)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Invalid body parameters")
+		return
+	}
+	if _, err := c.Repo.GetPlayer(t.PlayerID); err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Player not found")
+		return
+	}
+	if _, err := c.Repo.GetWatchedPlayer(user.ID, t.PlayerID); err == nil {
+		httputil.NewError(ctx, http.StatusBadRequest, "Player is already on the watchlist")
+		return
+	}
+
+	err = c.Repo.Create(&models.WatchedPlayer{UserID: user.ID, PlayerID: t.PlayerID})
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
+
+// Handles DELETE requests to the watchlist of the logged in user
+// @Summary Stop watching a player
+// @Description Remove a player from the watchlist of the logged in user
+// @Tags Me
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Player ID"
+// @Success 200
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /me/watchlist/{id} [delete]
+// @Security BearerAuth
+func (c *Controller) UnwatchPlayer(ctx *gin.Context) {
+	user, err := c.getAuthenticatedUserFromRequest(ctx)
+	if err != nil {
+		return
+	}
+	playerId, err := c.parseIdFromRequest(ctx, "playerId")
+	if err != nil {
+		return
+	}
+
+	watched, err := c.Repo.GetWatchedPlayer(user.ID, playerId)
+	if err != nil {
+		httputil.NewError(ctx, http.StatusNotFound, "Player is not on the watchlist")
+		return
+	}
+
+	err = c.Repo.Delete(&watched)
+	if err != nil {
+		log.Println(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	httputil.NoErrorEmpty(ctx)
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestEconomicRulesApplyToSales(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	_, err := doPatchRequest("economic-rules", admin, map[string]interface{}{
+		"market_tax_percent": 10,
+		"listing_fee":        500,
+		"sell_on_percent":    5,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seller, players := getTokenAndPlayerIds(t, false)
+	transferId := createTransferUsing(t, 10000, seller, players[0])
+	buyer := getUserToken(t, "hola@test.com")
+	_, err = doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp1, err := doGetRequest("me/team", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2, err := doGetRequest("me/team", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp1["budget"], models.DefaultTeamBudget-500+9000)
+	tests.AssertEqual(t, resp2["budget"], models.DefaultTeamBudget-10000)
+
+	resp, err := doGetRequest("players/"+strconv.Itoa(players[0])+"/history", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := resp["transfers"].([]interface{})[0].(map[string]interface{})
+	items := record["line_items"].([]interface{})
+	tests.AssertEqual(t, len(items), 3)
+	fees := 0
+	for _, item := range items {
+		if item.(map[string]interface{})["kind"] == models.LineItemListingFee {
+			fees += int(item.(map[string]interface{})["amount"].(float64))
+		}
+	}
+	tests.AssertEqual(t, fees, 500)
+
+	resp, err = doGetRequest("treasury", admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["balance"], 1500)
+	totals := resp["totals"].(map[string]interface{})
+	tests.AssertEqual(t, totals[models.TreasuryListingFee], 500)
+	tests.AssertEqual(t, totals[models.TreasuryMarketTax], 1000)
+
+	_, err = doGetRequest("treasury", seller, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidEconomicRules(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	_, err := doPatchRequest("economic-rules", admin, map[string]interface{}{
+		"market_tax_percent": 60,
+		"sell_on_percent":    50,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("economic-rules", "", http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["market_tax_percent"], 0)
+}
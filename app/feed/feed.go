@@ -0,0 +1,140 @@
+package feed
+
+import (
+	"../models"
+	"sync"
+	"time"
+)
+
+const (
+	// Events kept in memory for clients that reconnect
+	DefaultHistory = 1000
+	// Events a subscriber can fall behind before it gets dropped
+	subscriberBuffer = 64
+)
+
+// An event of the live market feed
+type Event struct {
+	ID   uint64
+	Kind string
+	// The listing the event is about as it was before the change, with its player and team
+	Transfer models.Transfer
+	// The price and the buyer of a sale
+	Price       int
+	BuyerTeamID uint
+	At          time.Time
+}
+
+// Fans market events out to the subscribers and keeps the most recent ones so clients can resume
+type Broker struct {
+	mu          sync.Mutex
+	lastId      uint64
+	history     []Event
+	size        int
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+// A client listening to the events that match its filter
+type Subscription struct {
+	broker *Broker
+	events chan Event
+	match  func(e Event) bool
+}
+
+// Create a broker that remembers the last size events
+func NewBroker(size int) *Broker {
+	return &Broker{
+		// Ids start at the current time so they keep growing across restarts
+		// and a client resuming on a new process never skips events
+		lastId:      uint64(time.Now().UnixNano()),
+		history:     make([]Event, 0, size),
+		size:        size,
+		subscribers: make(map[*Subscription]bool),
+	}
+}
+
+// Assign the next id to an event and send it to every subscriber it matches.
+// Subscribers that fell too far behind are dropped, they can resume from the last event they got.
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastId++
+	e.ID = b.lastId
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, e)
+	}
+
+	for s := range b.subscribers {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			b.drop(s)
+		}
+	}
+	return e
+}
+
+// Subscribe to the events that match a filter. The events after lastId that are still
+// remembered are returned so the client can catch up, none when lastId is zero.
+func (b *Broker) Subscribe(lastId uint64, match func(e Event) bool) ([]Event, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &Subscription{broker: b, events: make(chan Event, subscriberBuffer), match: match}
+	missed := make([]Event, 0)
+	if lastId > 0 {
+		for _, e := range b.history {
+			if e.ID > lastId && match(e) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	if b.closed {
+		close(s.events)
+		return missed, s
+	}
+	b.subscribers[s] = true
+	return missed, s
+}
+
+// Close every subscription, used when the app shuts down
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		b.drop(s)
+	}
+	b.closed = true
+}
+
+// Remove a subscriber and close its channel, the lock must be held
+func (b *Broker) drop(s *Subscription) {
+	if b.subscribers[s] {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// The events of the subscription, the channel is closed when the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Stop receiving events
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
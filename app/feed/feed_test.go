@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"../models"
+	"testing"
+)
+
+// Match the events about a player
+func aboutPlayer(id uint) func(e Event) bool {
+	return func(e Event) bool {
+		return e.Transfer.PlayerID == id
+	}
+}
+
+func TestBrokerSendsMatchingEvents(t *testing.T) {
+	b := NewBroker(10)
+	_, sub := b.Subscribe(0, aboutPlayer(1))
+	defer sub.Close()
+
+	b.Publish(Event{Kind: models.TransferEventListed, Transfer: models.Transfer{PlayerID: 2}})
+	listed := b.Publish(Event{Kind: models.TransferEventListed, Transfer: models.Transfer{PlayerID: 1}})
+
+	select {
+	case e := <-sub.Events():
+		if e.ID != listed.ID || e.At.IsZero() {
+			t.Errorf("unexpected event %v", e)
+		}
+	default:
+		t.Fatal("the matching event was not sent")
+	}
+	select {
+	case e := <-sub.Events():
+		t.Errorf("unexpected extra event %v", e)
+	default:
+	}
+}
+
+func TestBrokerReplaysMissedEvents(t *testing.T) {
+	b := NewBroker(2)
+	first := b.Publish(Event{Transfer: models.Transfer{PlayerID: 1}})
+	second := b.Publish(Event{Transfer: models.Transfer{PlayerID: 1}})
+	third := b.Publish(Event{Transfer: models.Transfer{PlayerID: 1}})
+	if second.ID != first.ID+1 || third.ID != second.ID+1 {
+		t.Fatalf("ids are not consecutive %v, %v, %v", first.ID, second.ID, third.ID)
+	}
+
+	missed, sub := b.Subscribe(second.ID, aboutPlayer(1))
+	sub.Close()
+	if len(missed) != 1 || missed[0].ID != third.ID {
+		t.Errorf("unexpected missed events %v", missed)
+	}
+	// Only the most recent events are remembered
+	missed, sub = b.Subscribe(first.ID-1, aboutPlayer(1))
+	sub.Close()
+	if len(missed) != 2 || missed[0].ID != second.ID {
+		t.Errorf("unexpected missed events %v", missed)
+	}
+	missed, sub = b.Subscribe(0, aboutPlayer(1))
+	sub.Close()
+	if len(missed) != 0 {
+		t.Errorf("a new client should not get old events %v", missed)
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(0)
+	_, sub := b.Subscribe(0, aboutPlayer(1))
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.Publish(Event{Transfer: models.Transfer{PlayerID: 1}})
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("expected %v events before being dropped, got %v", subscriberBuffer, received)
+	}
+	// Closing a dropped subscription does nothing
+	sub.Close()
+}
+
+func TestBrokerCloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker(10)
+	_, sub := b.Subscribe(0, aboutPlayer(1))
+	b.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("the subscription is still open")
+	}
+	_, sub = b.Subscribe(0, aboutPlayer(1))
+	if _, ok := <-sub.Events(); ok {
+		t.Error("subscribing to a closed broker should end right away")
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"./models"
+	"bufio"
+	"encoding/json"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferStreamPushesAndResumes(t *testing.T) {
+	setupTest()
+	token, players := getTokenAndPlayerIds(t, false)
+	player := getPlayer(t, token, players[0])
+	query := "player_name=" + url.QueryEscape(player["first_name"].(string))
+
+	events, closeStream := openTransferStream(t, query, 0)
+	transferId := createTransferUsing(t, 10000, token, players[0])
+	listed := nextTransferEvent(t, events)
+	closeStream()
+	tests.AssertEqual(t, listed.Kind, models.TransferEventListed)
+	tests.AssertEqual(t, int(listed.Transfer.ID), transferId)
+
+	// The client was away when the listing got re-priced and withdrawn
+	_, err := doPatchRequest("transfers/"+strconv.Itoa(transferId), token, map[string]interface{}{"ask": 9000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doDeleteRequest("transfers/"+strconv.Itoa(transferId), token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, closeStream = openTransferStream(t, query, listed.ID)
+	defer closeStream()
+	repriced := nextTransferEvent(t, events)
+	tests.AssertEqual(t, repriced.Kind, models.TransferEventRepriced)
+	tests.AssertEqual(t, repriced.Transfer.Ask, 9000)
+	tests.AssertEqual(t, nextTransferEvent(t, events).Kind, models.TransferEventWithdrawn)
+}
+
+// Open the live feed and return the events it sends and a func that closes it
+func openTransferStream(t *testing.T, query string, lastEventId uint64) (chan models.ShowTransferEvent, func()) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+testAddr+"/api/transfers/stream?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventId > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(lastEventId, 10))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal(err)
+	}
+
+	events := make(chan models.ShowTransferEvent, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+				var e models.ShowTransferEvent
+				if json.Unmarshal([]byte(data), &e) == nil {
+					events <- e
+				}
+			}
+		}
+		close(events)
+	}()
+	return events, func() { _ = resp.Body.Close() }
+}
+
+// Wait for the next event of the live feed
+func nextTransferEvent(t *testing.T, events chan models.ShowTransferEvent) models.ShowTransferEvent {
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("the stream ended")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event was sent")
+	}
+	return models.ShowTransferEvent{}
+}
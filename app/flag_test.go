@@ -0,0 +1,90 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestCollusionFlagsCanBeDismissedOrFrozen(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	seller, players := getTokenAndPlayerIds(t, false)
+	sellerTeam := getTeamIdFromUser(t, seller)
+	buyer := getUserToken(t, "hola@test.com")
+
+	// Players are worth a million, selling them for two and a half is far above their value
+	for _, player := range players[:2] {
+		transferId := createTransferUsing(t, 2500000, seller, player)
+		_, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusOK)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := doGetRequest("admin/flags", seller, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest("admin/flags/scan", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := doGetRequest("admin/flags", admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflated := make([]int, 0)
+	for _, f := range resp["flags"].([]interface{}) {
+		flag := f.(map[string]interface{})
+		if flag["kind"] == models.FlagInflatedPrice {
+			tests.AssertEqual(t, flag["team_id"], sellerTeam)
+			inflated = append(inflated, int(flag["id"].(float64)))
+		}
+	}
+	tests.AssertEqual(t, len(inflated), 2)
+
+	// A second scan does not raise the same flags again
+	resp, err = doPostRequest("admin/flags/scan", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["created"], 0)
+
+	_, err = doPutRequest("admin/flags/"+strconv.Itoa(inflated[0])+"/dismiss", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("admin/flags/"+strconv.Itoa(inflated[0])+"/dismiss", admin, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("admin/flags/"+strconv.Itoa(inflated[1])+"/freeze", admin, map[string]interface{}{
+		"team_id": sellerTeam,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the seller got frozen
+	_, err = doGetRequest("me/team", seller, http.StatusForbidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doGetRequest("me/team", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doGetRequest("admin/flags?status=frozen", admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["flags"].([]interface{})), 1)
+	_, err = doGetRequest("admin/flags?status=unknown", admin, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestReleaseAndSignFreeAgent(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	token, players := getTokenAndPlayerIds(t, false)
+	buyer := getUserToken(t, "buyer@test.com")
+	player := strconv.Itoa(players[0])
+
+	_, err := doPostRequest("players/"+player+"/release", token, map[string]interface{}{}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest("players/"+player+"/release", admin, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["free_agent"], true)
+	// Admins can still edit a free agent
+	patchPlayer(t, admin, players[0], map[string]interface{}{"first_name": "Free"})
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["first_name"], "Free")
+	_, err = doPatchRequest("players/"+player, admin, map[string]interface{}{"release_clause": 5000000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("free-agents", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agents := resp["free_agents"].([]interface{})
+	tests.AssertEqual(t, len(agents), 1)
+	agent := agents[0].(map[string]interface{})
+	fee := int(agent["signing_fee"].(float64))
+	tests.AssertEqual(t, fee, int(agent["market_value"].(float64))/models.SigningFeeDivisor)
+
+	_, err = doPostRequest("free-agents/"+player+"/sign", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	team, err := doGetRequest("me/team", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, team["budget"], float64(models.DefaultTeamBudget-fee))
+	tests.AssertEqual(t, hasPlayer(team, players[0]), true)
+	treasury, err := doGetRequest("treasury", admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, treasury["totals"].(map[string]interface{})[models.TreasurySigningFee], fee)
+
+	_, err = doPostRequest("free-agents/"+player+"/sign", buyer, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
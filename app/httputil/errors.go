@@ -16,6 +16,28 @@ func NewError(ctx *gin.Context, status int, error string) {
 	ctx.JSON(status, er)
 }
 
+// Write an error caused by breaking a named rule to the response
+func NewRuleError(ctx *gin.Context, status int, error string, rule string) {
+	er := HTTPRuleError{
+		Code:    status,
+		Message: error,
+		Rule:    rule,
+	}
+	ctx.Header("Content-Type", "application/json")
+	ctx.JSON(status, er)
+}
+
+// Write an error for a batch of operations along with the result of every operation
+func NewBatchError(ctx *gin.Context, status int, error string, results interface{}) {
+	er := HTTPBatchError{
+		Code:    status,
+		Message: error,
+		Results: results,
+	}
+	ctx.Header("Content-Type", "application/json")
+	ctx.JSON(status, er)
+}
+
 // Write an OK response with no message
 func NoErrorEmpty(ctx *gin.Context) {
 	NoError(ctx, map[string]interface{}{})
@@ -39,3 +61,17 @@ type HTTPError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 } // @name HTTPError
+
+// HTTPRuleError example
+type HTTPRuleError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Rule    string `json:"rule" example:"min_goalkeepers"`
+} // @name HTTPRuleError
+
+// HTTPBatchError example
+type HTTPBatchError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Results interface{} `json:"results"`
+} // @name HTTPBatchError
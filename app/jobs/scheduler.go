@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// A task that runs periodically on the background
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// Runs a group of jobs periodically until it gets stopped
+type Scheduler struct {
+	jobs    []job
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	running bool
+}
+
+// Create a new scheduler without jobs
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs: make([]job, 0),
+		stop: make(chan struct{}),
+	}
+}
+
+// Add a job that runs every interval once the scheduler is started
+func (s *Scheduler) Add(name string, interval time.Duration, run func() error) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start running every job on its own goroutine
+func (s *Scheduler) Start() {
+	if s.running {
+		return
+	}
+	s.running = true
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+}
+
+// Stop every job and wait for the ones that are running to finish
+func (s *Scheduler) Stop() {
+	if !s.running {
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Run a job every time its interval ticks until the scheduler is stopped
+func (s *Scheduler) loop(j job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := j.run(); err != nil {
+				log.Printf("job %v failed: %v", j.name, err)
+			}
+		}
+	}
+}
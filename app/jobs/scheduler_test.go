@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobsUntilStopped(t *testing.T) {
+	var runs int32
+	s := NewScheduler()
+	s.Add("count", time.Millisecond, func() error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	s.Start()
+	time.Sleep(20 * time.Millisecond)
+	s.Stop()
+
+	stopped := atomic.LoadInt32(&runs)
+	if stopped == 0 {
+		t.Fatal("job never ran")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != stopped {
+		t.Error("job kept running after the scheduler was stopped")
+	}
+}
+
+func TestStoppingAnIdleScheduler(t *testing.T) {
+	s := NewScheduler()
+	s.Stop()
+	s.Start()
+	s.Stop()
+	s.Stop()
+}
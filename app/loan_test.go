@@ -0,0 +1,86 @@
+package app
+
+import (
+	"./controller"
+	"./repos"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoanPlayerAndReturnIt(t *testing.T) {
+	setupTest()
+	parent, players := getTokenAndPlayerIds(t, false)
+	borrower := getUserToken(t, "hola@test.com")
+	parentTeam := getTeamIdFromUser(t, parent)
+	borrowerTeam := getTeamIdFromUser(t, borrower)
+
+	resp, err := doPostRequest("loans", parent, map[string]interface{}{
+		"player_id": players[0],
+		"team_id":   borrowerTeam,
+		"fee":       1000,
+		"ends_at":   time.Now().Add(time.Hour).Format(time.RFC3339),
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loanRes := "loans/" + strconv.Itoa(int(resp["id"].(float64)))
+
+	_, err = doPutRequest(loanRes+"/accept", parent, map[string]interface{}{}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(loanRes+"/accept", borrower, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests.AssertEqual(t, getLoanMark(t, parent, parentTeam, players[0]), "loaned_out")
+	tests.AssertEqual(t, getLoanMark(t, borrower, borrowerTeam, players[0]), "loaned_in")
+
+	// The borrowing team can't sell the player
+	_, err = doPostRequest("transfers", borrower, map[string]interface{}{
+		"player_id": players[0],
+		"ask":       10000,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := app.db.Table("loans").Where("player_id = ?", players[0]).Update("ends_at", time.Now().Add(-time.Minute))
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	err = controller.NewController(repos.RepositorySQL{Db: app.db}).ReturnExpiredLoans()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doGetRequest(loanRes, parent, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["status"], "returned")
+	tests.AssertEqual(t, getLoanMark(t, parent, parentTeam, players[0]), "")
+}
+
+// Get the loan mark of a player on the listing of a team
+func getLoanMark(t *testing.T, token string, team int, player int) interface{} {
+	resp, err := doGetRequest("teams/"+strconv.Itoa(team)+"/players", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range resp["players"].([]interface{}) {
+		p := m.(map[string]interface{})
+		if int(p["id"].(float64)) == player {
+			if loan, ok := p["loan"]; ok {
+				return loan
+			}
+			return ""
+		}
+	}
+	t.Fatalf("player %v is not listed on team %v", player, team)
+	return nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestMarketStats(t *testing.T) {
+	setupTest()
+	seller, players := getTokenAndPlayerIds(t, false)
+	sold := createTransferUsing(t, 10000, seller, players[0])
+	createTransferUsing(t, 20000, seller, players[1])
+	createTransferUsing(t, 40000, seller, players[2])
+	buyer := getUserToken(t, "hola@test.com")
+	_, err := doPutRequest("transfers/"+strconv.Itoa(sold)+"/buy", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("market/stats", "", http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asks := resp["asks"].(map[string]interface{})
+	tests.AssertEqual(t, asks["count"], 2)
+	tests.AssertEqual(t, asks["average"], 30000)
+	tests.AssertEqual(t, asks["median"], 30000)
+	sales := resp["sales"].(map[string]interface{})
+	tests.AssertEqual(t, sales["count"], 1)
+	tests.AssertEqual(t, sales["median"], 10000)
+	tests.AssertEqual(t, resp["sell_through_rate"].(float64) > 0.33 && resp["sell_through_rate"].(float64) < 0.34, true)
+	tests.AssertEqual(t, resp["hours_to_sale"].(map[string]interface{})["count"], 1)
+	tests.AssertEqual(t, len(resp["price_index"].([]interface{})), 1)
+
+	// Teams are created with 3 goalkeepers listed first
+	positions := resp["by_position"].([]interface{})
+	tests.AssertEqual(t, len(positions), 1)
+	tests.AssertEqual(t, positions[0].(map[string]interface{})["key"], "goalkeeper")
+
+	resp, err = doGetRequest("market/stats?max_value=15000", "", http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["asks"].(map[string]interface{})["count"], 0)
+	tests.AssertEqual(t, resp["sales"].(map[string]interface{})["count"], 1)
+}
+
+func TestMarketStatsWithInvalidDaysFails(t *testing.T) {
+	setupTest()
+	_, err := doGetRequest("market/stats?days=0", "", http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
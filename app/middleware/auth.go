@@ -61,6 +61,11 @@ func Auth(repo repos.Repository) gin.HandlerFunc {
 				httputil.NewError(c, http.StatusUnauthorized, "Invalid token")
 				c.Abort()
 			}
+			if user.Frozen {
+				httputil.NewError(c, http.StatusForbidden, "Account is frozen")
+				c.Abort()
+				return
+			}
 			// Save the user so the handlers can use it
 			c.Set("user", user)
 			log.Println(fmt.Sprintf("user %v succesfully authenticated for request %v", claims.Email, c.Request.RequestURI))
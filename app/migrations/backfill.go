@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The helpers below are frozen copies of how the models generated new data when a migration was written,
+// so later changes to the models don't change what running an old migration does
+
+// Attributes of a player as they were generated when they were added
+type playerAttributes struct {
+	Pace        int
+	Shooting    int
+	Passing     int
+	Defending   int
+	Goalkeeping int
+	Physical    int
+	Potential   int
+	Overall     int
+}
+
+// Weight in percent of every attribute in the overall rating of goalkeepers, defenders, midfielders and attackers
+var overallWeights = [4]playerAttributes{
+	{Goalkeeping: 80, Passing: 10, Physical: 10},
+	{Defending: 50, Physical: 20, Pace: 15, Passing: 15},
+	{Passing: 40, Pace: 15, Shooting: 15, Defending: 15, Physical: 15},
+	{Shooting: 45, Pace: 25, Passing: 15, Physical: 15},
+}
+
+// Generate random attributes fitting a position and their overall rating
+func randomAttributes(position int, age int) playerAttributes {
+	quality := 65 + rand.NormFloat64()*8
+	around := func(mean float64) int {
+		return clampAttribute(int(mean + rand.NormFloat64()*6))
+	}
+	a := playerAttributes{
+		Pace:        around(quality - 15),
+		Shooting:    around(quality - 20),
+		Passing:     around(quality - 10),
+		Defending:   around(quality - 20),
+		Goalkeeping: around(15),
+		Physical:    around(quality - 5),
+	}
+	switch position {
+	case 0:
+		a.Goalkeeping = around(quality + 5)
+		a.Pace, a.Shooting, a.Defending = around(35), around(20), around(30)
+	case 1:
+		a.Defending = around(quality + 5)
+	case 2:
+		a.Passing = around(quality + 5)
+	case 3:
+		a.Shooting, a.Pace = around(quality+5), around(quality)
+	}
+	a.Overall = overallRating(a, position)
+	growth := 0
+	if age < 30 {
+		growth = rand.Intn((30-age)*2 + 1)
+	}
+	a.Potential = clampAttribute(a.Overall + growth)
+	return a
+}
+
+// Get the overall rating of some attributes for a position
+func overallRating(a playerAttributes, position int) int {
+	if position < 0 || position >= len(overallWeights) {
+		return clampAttribute(0)
+	}
+	w := overallWeights[position]
+	total := a.Pace*w.Pace + a.Shooting*w.Shooting + a.Passing*w.Passing +
+		a.Defending*w.Defending + a.Goalkeeping*w.Goalkeeping + a.Physical*w.Physical
+	return clampAttribute((total + 50) / 100)
+}
+
+// Keep an attribute between 1 and 99
+func clampAttribute(v int) int {
+	if v < 1 {
+		return 1
+	}
+	if v > 99 {
+		return 99
+	}
+	return v
+}
+
+// Get the weekly wage and the end of a contract signed at a time at the asking wage, lasting between one and four years
+func randomContract(marketValue int32, now time.Time) (int, time.Time) {
+	wage := int(math.Round(float64(marketValue) * 0.002))
+	return wage, now.AddDate(1+rand.Intn(4), 0, 0)
+}
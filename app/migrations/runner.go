@@ -4,6 +4,7 @@ import (
 	"github.com/go-gormigrate/gormigrate"
 	"gorm.io/gorm"
 	"log"
+	"time"
 )
 
 func Run(db *gorm.DB) error {
@@ -99,5 +100,543 @@ func getMigrations() []*gormigrate.Migration {
 				return tx.Migrator().DropTable("transfers")
 			},
 		},
+		{
+			ID: "202610181000",
+			Migrate: func(tx *gorm.DB) error {
+				type Transfer struct {
+					Mode         string `gorm:"default:fixed"`
+					ReservePrice int
+					EndsAt       *time.Time
+				}
+				type Bid struct {
+					gorm.Model
+					TransferID uint
+					TeamID     uint
+					Amount     int
+					Active     bool
+				}
+
+				err := tx.AutoMigrate(&Transfer{})
+				if err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&Bid{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				err := tx.Migrator().DropTable("bids")
+				if err != nil {
+					return err
+				}
+				type Transfer struct{}
+				for _, column := range []string{"mode", "reserve_price", "ends_at"} {
+					if err := tx.Migrator().DropColumn(&Transfer{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610181100",
+			Migrate: func(tx *gorm.DB) error {
+				type Offer struct {
+					gorm.Model
+					TransferID    uint
+					TeamID        uint
+					Amount        int
+					CounterAmount int
+					Status        string
+					ExpiresAt     time.Time
+				}
+
+				return tx.AutoMigrate(&Offer{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("offers")
+			},
+		},
+		{
+			ID: "202610181200",
+			Migrate: func(tx *gorm.DB) error {
+				type CompletedTransfer struct {
+					gorm.Model
+					PlayerID     uint `gorm:"index"`
+					SellerTeamID uint `gorm:"index"`
+					BuyerTeamID  uint `gorm:"index"`
+					Price        int
+					ValueBefore  int32
+					ValueAfter   int32
+				}
+
+				return tx.AutoMigrate(&CompletedTransfer{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("completed_transfers")
+			},
+		},
+		{
+			ID: "202610181300",
+			Migrate: func(tx *gorm.DB) error {
+				type Loan struct {
+					gorm.Model
+					PlayerID       uint `gorm:"index"`
+					ParentTeamID   uint
+					BorrowerTeamID uint
+					Fee            int
+					EndsAt         time.Time
+					Status         string
+				}
+
+				return tx.AutoMigrate(&Loan{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("loans")
+			},
+		},
+		{
+			ID: "202610181400",
+			Migrate: func(tx *gorm.DB) error {
+				type TransferWindow struct {
+					gorm.Model
+					Name     string
+					OpensAt  time.Time
+					ClosesAt time.Time
+				}
+
+				return tx.AutoMigrate(&TransferWindow{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("transfer_windows")
+			},
+		},
+		{
+			ID: "202610181500",
+			Migrate: func(tx *gorm.DB) error {
+				type SquadRules struct {
+					gorm.Model
+					MinSquadSize   int
+					MaxSquadSize   int
+					MinGoalkeepers int
+					MaxGoalkeepers int
+					MinDefenders   int
+					MaxDefenders   int
+					MinMidfielders int
+					MaxMidfielders int
+					MinAttackers   int
+					MaxAttackers   int
+				}
+
+				return tx.AutoMigrate(&SquadRules{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("squad_rules")
+			},
+		},
+		{
+			ID: "202610181600",
+			Migrate: func(tx *gorm.DB) error {
+				type SavedSearch struct {
+					gorm.Model
+					UserID     uint `gorm:"index"`
+					Name       string
+					Country    string
+					TeamName   string
+					PlayerName string
+					MinAge     int
+					MaxAge     int
+					MinValue   int
+					MaxValue   int
+					ValueType  string
+				}
+				type WatchedPlayer struct {
+					gorm.Model
+					UserID   uint `gorm:"index"`
+					PlayerID uint `gorm:"index"`
+				}
+				type Notification struct {
+					gorm.Model
+					UserID     uint `gorm:"index"`
+					Kind       string
+					Message    string
+					PlayerID   uint
+					TransferID uint
+					Read       bool
+				}
+
+				return tx.AutoMigrate(&SavedSearch{}, &WatchedPlayer{}, &Notification{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("saved_searches", "watched_players", "notifications")
+			},
+		},
+		{
+			ID: "202610181700",
+			Migrate: func(tx *gorm.DB) error {
+				type Trade struct {
+					gorm.Model
+					ProposerTeamID   uint `gorm:"index"`
+					ReceiverTeamID   uint `gorm:"index"`
+					Cash             int
+					ProposerAccepted bool
+					ReceiverAccepted bool
+					Status           string
+				}
+				type TradePlayer struct {
+					gorm.Model
+					TradeID    uint `gorm:"index"`
+					PlayerID   uint `gorm:"index"`
+					FromTeamID uint
+				}
+
+				return tx.AutoMigrate(&Trade{}, &TradePlayer{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("trade_players", "trades")
+			},
+		},
+		{
+			ID: "202610181800",
+			Migrate: func(tx *gorm.DB) error {
+				type EconomicRules struct {
+					gorm.Model
+					MarketTaxPercent float64
+					ListingFee       int
+					SellOnPercent    float64
+				}
+				type SaleLineItem struct {
+					gorm.Model
+					CompletedTransferID uint `gorm:"index"`
+					Kind                string
+					Amount              int
+					TeamID              uint
+				}
+				type TreasuryEntry struct {
+					gorm.Model
+					Kind                string
+					Amount              int
+					TeamID              uint
+					TransferID          uint
+					CompletedTransferID uint
+				}
+
+				return tx.AutoMigrate(&EconomicRules{}, &SaleLineItem{}, &TreasuryEntry{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("economic_rules", "sale_line_items", "treasury_entries")
+			},
+		},
+		{
+			ID: "202610181900",
+			Migrate: func(tx *gorm.DB) error {
+				type Team struct {
+					IsBot bool
+				}
+
+				return tx.AutoMigrate(&Team{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Team struct{}
+				return tx.Migrator().DropColumn(&Team{}, "is_bot")
+			},
+		},
+		{
+			ID: "202610182000",
+			Migrate: func(tx *gorm.DB) error {
+				type CompletedTransfer struct {
+					ListedAt *time.Time
+				}
+
+				return tx.AutoMigrate(&CompletedTransfer{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type CompletedTransfer struct{}
+				return tx.Migrator().DropColumn(&CompletedTransfer{}, "listed_at")
+			},
+		},
+		{
+			ID: "202610182100",
+			Migrate: func(tx *gorm.DB) error {
+				type Transfer struct {
+					ExpiresAt *time.Time `gorm:"index"`
+				}
+				type EconomicRules struct {
+					ListingLifetimeHours int
+				}
+
+				err := tx.AutoMigrate(&Transfer{})
+				if err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&EconomicRules{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Transfer struct{}
+				type EconomicRules struct{}
+				err := tx.Migrator().DropColumn(&Transfer{}, "expires_at")
+				if err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&EconomicRules{}, "listing_lifetime_hours")
+			},
+		},
+		{
+			ID: "202610182200",
+			Migrate: func(tx *gorm.DB) error {
+				type Player struct {
+					ReleaseClause int
+				}
+				type EconomicRules struct {
+					MinReleaseClauseMultiple float64
+					MaxReleaseClauseMultiple float64
+				}
+
+				err := tx.AutoMigrate(&Player{})
+				if err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&EconomicRules{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Player struct{}
+				type EconomicRules struct{}
+				err := tx.Migrator().DropColumn(&Player{}, "release_clause")
+				if err != nil {
+					return err
+				}
+				for _, column := range []string{"min_release_clause_multiple", "max_release_clause_multiple"} {
+					if err := tx.Migrator().DropColumn(&EconomicRules{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610182300",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					SignupIP string
+					Frozen   bool
+				}
+				type Flag struct {
+					gorm.Model
+					Key                 string `gorm:"uniqueIndex"`
+					Kind                string
+					Status              string `gorm:"index"`
+					TeamID              uint
+					OtherTeamID         uint
+					PlayerID            uint
+					CompletedTransferID uint
+					Details             string
+					ReviewedBy          uint
+				}
+
+				err := tx.AutoMigrate(&User{})
+				if err != nil {
+					return err
+				}
+				return tx.AutoMigrate(&Flag{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				err := tx.Migrator().DropTable("flags")
+				if err != nil {
+					return err
+				}
+				type User struct{}
+				for _, column := range []string{"signup_ip", "frozen"} {
+					if err := tx.Migrator().DropColumn(&User{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610182305",
+			Migrate: func(tx *gorm.DB) error {
+				type Player struct {
+					ID          uint
+					Position    int
+					Age         int
+					Pace        int
+					Shooting    int
+					Passing     int
+					Defending   int
+					Goalkeeping int
+					Physical    int
+					Potential   int
+					Overall     int `gorm:"index"`
+				}
+
+				err := tx.AutoMigrate(&Player{})
+				if err != nil {
+					return err
+				}
+				// Existing players get attributes generated the same way as new ones
+				var players []Player
+				return tx.Where("overall = 0").FindInBatches(&players, 500, func(batch *gorm.DB, _ int) error {
+					for _, p := range players {
+						generated := randomAttributes(p.Position, p.Age)
+						err := tx.Model(&Player{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+							"pace":        generated.Pace,
+							"shooting":    generated.Shooting,
+							"passing":     generated.Passing,
+							"defending":   generated.Defending,
+							"goalkeeping": generated.Goalkeeping,
+							"physical":    generated.Physical,
+							"potential":   generated.Potential,
+							"overall":     generated.Overall,
+						}).Error
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Player struct{}
+				for _, column := range []string{"pace", "shooting", "passing", "defending", "goalkeeping", "physical", "potential", "overall"} {
+					if err := tx.Migrator().DropColumn(&Player{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610182310",
+			Migrate: func(tx *gorm.DB) error {
+				type Season struct {
+					gorm.Model
+					Number            int `gorm:"uniqueIndex"`
+					PlayersAged       int
+					PlayersRetired    int
+					ListingsWithdrawn int
+					PlayersGenerated  int
+					ValueBefore       int64
+					ValueAfter        int64
+				}
+
+				return tx.AutoMigrate(&Season{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("seasons")
+			},
+		},
+		{
+			ID: "202610182315",
+			Migrate: func(tx *gorm.DB) error {
+				type Player struct {
+					InjuredUntil     *time.Time
+					SuspendedMatches int
+					InLineup         bool
+				}
+
+				return tx.AutoMigrate(&Player{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Player struct{}
+				for _, column := range []string{"injured_until", "suspended_matches", "in_lineup"} {
+					if err := tx.Migrator().DropColumn(&Player{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610182320",
+			Migrate: func(tx *gorm.DB) error {
+				type Player struct {
+					ID               uint
+					MarketValue      int32
+					WeeklyWage       int
+					ContractStartsAt *time.Time
+					ContractEndsAt   *time.Time `gorm:"index"`
+					WagesPaidUntil   *time.Time
+				}
+
+				// Free agents belong to no team
+				if tx.Migrator().HasConstraint(&Player{}, "fk_players_team") {
+					if err := tx.Migrator().DropConstraint(&Player{}, "fk_players_team"); err != nil {
+						return err
+					}
+				}
+				err := tx.Migrator().AutoMigrate(&Player{})
+				if err != nil {
+					return err
+				}
+				// Existing players sign contracts the same way as new ones
+				now := time.Now()
+				var players []Player
+				return tx.Where("contract_ends_at IS NULL AND team_id <> 0").FindInBatches(&players, 500, func(batch *gorm.DB, _ int) error {
+					for _, p := range players {
+						wage, endsAt := randomContract(p.MarketValue, now)
+						err := tx.Model(&Player{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+							"weekly_wage":        wage,
+							"contract_starts_at": now,
+							"contract_ends_at":   endsAt,
+							"wages_paid_until":   now,
+						}).Error
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Player struct{}
+				for _, column := range []string{"weekly_wage", "contract_starts_at", "contract_ends_at", "wages_paid_until"} {
+					if err := tx.Migrator().DropColumn(&Player{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202610182325",
+			Migrate: func(tx *gorm.DB) error {
+				type Player struct {
+					InAcademy bool `gorm:"index"`
+				}
+				type AcademyIntake struct {
+					gorm.Model
+					Season             int `gorm:"uniqueIndex"`
+					Teams              int
+					ProspectsGenerated int
+				}
+
+				return tx.AutoMigrate(&Player{}, &AcademyIntake{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Player struct{}
+				if err := tx.Migrator().DropColumn(&Player{}, "in_academy"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropTable("academy_intakes")
+			},
+		},
+		{
+			ID: "202610182355",
+			Migrate: func(tx *gorm.DB) error {
+				type Transfer struct {
+					ListingFee int
+				}
+
+				if err := tx.AutoMigrate(&Transfer{}); err != nil {
+					return err
+				}
+				// Take the fee of the open listings from what their team paid the treasury
+				return tx.Exec("UPDATE transfers SET listing_fee = treasury_entries.amount FROM treasury_entries "+
+					"WHERE treasury_entries.transfer_id = transfers.id AND treasury_entries.kind = ?", "listing_fee").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Transfer struct{}
+				return tx.Migrator().DropColumn(&Transfer{}, "listing_fee")
+			},
+		},
 	}
 }
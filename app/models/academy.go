@@ -0,0 +1,58 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Prospects every team gets in a yearly intake
+	AcademyIntakeSize = 3
+	MinProspectAge    = 16
+	MaxProspectAge    = 18
+	// Bounds of the market value of a new prospect
+	minProspectValue = 50000
+	maxProspectValue = 250000
+)
+
+// AcademyIntake DB model, the report of a yearly youth intake. Intakes are named after the season they
+// belong to and only generated once.
+type AcademyIntake struct {
+	gorm.Model
+	Season int `gorm:"uniqueIndex"`
+	// Teams that got a batch of prospects
+	Teams              int
+	ProspectsGenerated int
+}
+
+// Create a youth prospect of a position for an academy. Prospects are younger and cheaper than a random
+// player and start with lower attributes, but have a high potential.
+func RandomProspect(position int) Player {
+	p := RandomPlayer(position)
+	p.Age = MinProspectAge + rand.Intn(MaxProspectAge-MinProspectAge+1)
+	p.MarketValue = int32(minProspectValue + rand.Intn(maxProspectValue-minProspectValue+1))
+	p.InAcademy = true
+
+	a := RandomAttributes(position, p.Age)
+	for _, v := range []*int{&a.Pace, &a.Shooting, &a.Passing, &a.Defending, &a.Goalkeeping, &a.Physical} {
+		*v = clampAttribute(*v - 15)
+	}
+	a.Potential = clampAttribute(a.OverallRating(position) + 20 + rand.Intn(16))
+	p.SetAttributes(a)
+	return p
+}
+
+type ShowAcademyIntake struct {
+	ID                 uint      `json:"id"`
+	Season             int       `json:"season" example:"2026"`
+	Teams              int       `json:"teams" example:"24"`
+	ProspectsGenerated int       `json:"prospects_generated" example:"72"`
+	GeneratedAt        time.Time `json:"generated_at"`
+	// Set when the intake of the season was already generated and nothing changed
+	AlreadyGenerated bool `json:"already_generated,omitempty"`
+} //@name ShowAcademyIntake
+
+type CreateAcademyIntake struct {
+	Season int `json:"season" binding:"required" example:"2026"`
+} //@name CreateAcademyIntake
@@ -0,0 +1,24 @@
+package models
+
+import "gorm.io/gorm"
+
+// Bid DB model
+type Bid struct {
+	gorm.Model
+	TransferID uint
+	TeamID     uint
+	Team       Team
+	Amount     int
+	// Active bids hold funds from the team budget until they are outbid
+	Active bool
+}
+
+type ShowBid struct {
+	ID     uint `json:"id"`
+	TeamID uint `json:"team_id"`
+	Amount int  `json:"amount"`
+} //@name ShowBid
+
+type CreateBid struct {
+	Amount int `json:"amount" binding:"required" example:"15000"`
+} //@name CreateBid
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// CompletedTransfer DB model, a permanent record of a sale
+type CompletedTransfer struct {
+	gorm.Model
+	PlayerID     uint
+	SellerTeamID uint
+	BuyerTeamID  uint
+	Price        int
+	ValueBefore  int32
+	ValueAfter   int32
+	// When the player was listed, nil for sales recorded before it was tracked
+	ListedAt  *time.Time
+	LineItems []SaleLineItem
+}
+
+type ShowCompletedTransfer struct {
+	ID           uint      `json:"id"`
+	PlayerID     uint      `json:"player_id"`
+	SellerTeamID uint      `json:"seller_team_id"`
+	BuyerTeamID  uint      `json:"buyer_team_id"`
+	Price        int       `json:"price"`
+	ValueBefore  int32     `json:"value_before"`
+	ValueAfter   int32     `json:"value_after"`
+	CompletedAt  time.Time `json:"completed_at"`
+	// How the price was split between the seller, the league treasury and the previous club
+	LineItems []ShowSaleLineItem `json:"line_items"`
+} //@name ShowCompletedTransfer
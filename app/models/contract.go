@@ -0,0 +1,125 @@
+package models
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Wages are paid once per week
+	WageWeek = 7 * 24 * time.Hour
+	// Share of the market value a player asks as weekly wage
+	WageShare = 0.002
+	// Bounds of the length of a new contract
+	MinContractLength = WageWeek
+	MaxContractYears  = 5
+	// Length of the contract a bought player signs when the buyer gives no terms
+	DefaultContractYears = 3
+)
+
+// Get the weekly wage a player asks for to sign or renew a contract
+func (p Player) AskingWage() int {
+	return int(math.Round(float64(p.MarketValue) * WageShare))
+}
+
+// Sign a new contract, the wages are owed from its start
+func (p *Player) SignContract(wage int, startsAt time.Time, endsAt time.Time) {
+	p.WeeklyWage = wage
+	p.ContractStartsAt = &startsAt
+	p.ContractEndsAt = &endsAt
+	p.WagesPaidUntil = &startsAt
+}
+
+// Sign a contract at the asking wage that lasts between one and four years
+func (p *Player) SignRandomContract(now time.Time) {
+	p.SignContract(p.AskingWage(), now, now.AddDate(1+rand.Intn(4), 0, 0))
+}
+
+// Returns a bool that tells if the contract of a player is over at a time, free agents have none
+func (p Player) ContractExpired(now time.Time) bool {
+	return p.ContractEndsAt != nil && !p.ContractEndsAt.After(now)
+}
+
+// Get the whole weeks of wages owed up to a time, no wages are owed past the end of the contract
+func (p Player) WeeksOwed(now time.Time) int {
+	if p.WagesPaidUntil == nil || p.ContractEndsAt == nil {
+		return 0
+	}
+	if p.ContractEndsAt.Before(now) {
+		now = *p.ContractEndsAt
+	}
+	if !now.After(*p.WagesPaidUntil) {
+		return 0
+	}
+	return int(now.Sub(*p.WagesPaidUntil) / WageWeek)
+}
+
+// Mark the whole weeks of wages owed up to a time as paid and get the amount owed for them
+func (p *Player) PayOwedWages(now time.Time) int {
+	weeks := p.WeeksOwed(now)
+	if weeks == 0 {
+		return 0
+	}
+	paidUntil := p.WagesPaidUntil.Add(time.Duration(weeks) * WageWeek)
+	p.WagesPaidUntil = &paidUntil
+	return weeks * p.WeeklyWage
+}
+
+// Mark the wages owed up to a time as paid, the last part of a week included, and get the amount owed for them.
+// Used when the player changes teams, so each team pays for the time the player was with it.
+func (p *Player) SettleWages(now time.Time) int {
+	if p.WagesPaidUntil == nil || p.ContractEndsAt == nil {
+		return 0
+	}
+	until := now
+	if p.ContractEndsAt.Before(until) {
+		until = *p.ContractEndsAt
+	}
+	if !until.After(*p.WagesPaidUntil) {
+		return 0
+	}
+	owed := float64(p.WeeklyWage) * float64(until.Sub(*p.WagesPaidUntil)) / float64(WageWeek)
+	p.WagesPaidUntil = &until
+	return int(math.Round(owed))
+}
+
+// Returns a bool that tells if a player belongs to no team
+func (p Player) IsFreeAgent() bool {
+	return p.TeamID == 0
+}
+
+// Take a player off its team and end its contract, making it a free agent
+func (p *Player) ReleaseToFreeAgency() {
+	p.TeamID = 0
+	p.Team = Team{}
+	p.ReleaseClause = 0
+	p.InLineup = false
+	p.InAcademy = false
+	p.WeeklyWage = 0
+	p.ContractStartsAt = nil
+	p.ContractEndsAt = nil
+	p.WagesPaidUntil = nil
+}
+
+type ShowContract struct {
+	PlayerID uint `json:"player_id"`
+	// Free agents have no team and no contract
+	TeamID     uint       `json:"team_id,omitempty"`
+	WeeklyWage int        `json:"weekly_wage" example:"2000"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	// Lowest weekly wage the player accepts for a new contract
+	AskingWage int `json:"asking_wage" example:"2200"`
+} //@name ShowContract
+
+type RenewContract struct {
+	WeeklyWage int       `json:"weekly_wage" binding:"required" example:"2200"`
+	EndsAt     time.Time `json:"ends_at" binding:"required" example:"2029-06-30T00:00:00Z"`
+} //@name RenewContract
+
+type ContractOffer struct {
+	// Terms of the contract the player signs with its new team, it signs at its asking wage for three years when left out
+	WeeklyWage     int        `json:"weekly_wage" example:"2200"`
+	ContractEndsAt *time.Time `json:"contract_ends_at" example:"2029-06-30T00:00:00Z"`
+} //@name ContractOffer
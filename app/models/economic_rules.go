@@ -0,0 +1,52 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"math"
+)
+
+// EconomicRules DB model, the fees and taxes the league applies on the transfer market
+type EconomicRules struct {
+	gorm.Model
+	// Percentage of every sale price that goes to the league treasury
+	MarketTaxPercent float64
+	// Flat fee the seller pays to the league treasury when listing a player
+	ListingFee int
+	// Percentage of every sale price owed to the club that sold the player to the current seller
+	SellOnPercent float64
+	// Hours fixed price listings stay on the market when they are created without an expiry, 0 means forever
+	ListingLifetimeHours int
+	// Bounds of the release clauses as multiples of the market value of the player, 0 means no bound
+	MinReleaseClauseMultiple float64
+	MaxReleaseClauseMultiple float64
+}
+
+// Split a sale price into the market tax and the sell-on fee, the seller keeps the rest
+func (r EconomicRules) SplitPrice(price int, hasPreviousClub bool) (int, int) {
+	tax := int(math.Round(float64(price) * r.MarketTaxPercent / 100))
+	sellOn := 0
+	if hasPreviousClub {
+		sellOn = int(math.Round(float64(price) * r.SellOnPercent / 100))
+	}
+	return tax, sellOn
+}
+
+// Returns a bool that tells if a release clause is within the bounds for a player with a market value
+func (r EconomicRules) AllowsReleaseClause(clause int, marketValue int32) bool {
+	value := float64(marketValue)
+	if r.MinReleaseClauseMultiple > 0 && float64(clause) < value*r.MinReleaseClauseMultiple {
+		return false
+	}
+	return r.MaxReleaseClauseMultiple <= 0 || float64(clause) <= value*r.MaxReleaseClauseMultiple
+}
+
+type ShowEconomicRules struct {
+	MarketTaxPercent float64 `json:"market_tax_percent" example:"5"`
+	ListingFee       int     `json:"listing_fee" example:"1000"`
+	SellOnPercent    float64 `json:"sell_on_percent" example:"10"`
+	// Hours fixed price listings stay on the market by default, 0 means forever
+	ListingLifetimeHours int `json:"listing_lifetime_hours" example:"168"`
+	// Bounds of the release clauses as multiples of the market value of the player, 0 means no bound
+	MinReleaseClauseMultiple float64 `json:"min_release_clause_multiple" example:"1.5"`
+	MaxReleaseClauseMultiple float64 `json:"max_release_clause_multiple" example:"10"`
+} //@name EconomicRules
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	FlagRepeatedTrades = "repeated_trades"
+	FlagRoundTrip      = "round_trip"
+	FlagInflatedPrice  = "inflated_price"
+	FlagSharedSignup   = "shared_signup"
+
+	FlagOpen      = "open"
+	FlagDismissed = "dismissed"
+	FlagFrozen    = "frozen"
+)
+
+// Flag DB model, a suspicious pattern in the completed sales waiting for an admin to review it
+type Flag struct {
+	gorm.Model
+	// Identifies what was found so later scans do not flag it again
+	Key    string `gorm:"uniqueIndex"`
+	Kind   string
+	Status string
+	// The teams involved, OtherTeamID is zero when the flag is about a single team
+	TeamID      uint
+	OtherTeamID uint
+	// The player and the most recent sale behind the flag, when there is one
+	PlayerID            uint
+	CompletedTransferID uint
+	Details             string
+	// The admin that dismissed the flag or froze the accounts
+	ReviewedBy uint
+}
+
+// Returns the teams the flag is about
+func (f Flag) TeamIDs() []uint {
+	if f.OtherTeamID == 0 {
+		return []uint{f.TeamID}
+	}
+	return []uint{f.TeamID, f.OtherTeamID}
+}
+
+type ShowFlag struct {
+	ID                  uint      `json:"id"`
+	Kind                string    `json:"kind" example:"round_trip"`
+	Status              string    `json:"status" example:"open"`
+	TeamID              uint      `json:"team_id"`
+	OtherTeamID         uint      `json:"other_team_id,omitempty"`
+	PlayerID            uint      `json:"player_id,omitempty"`
+	CompletedTransferID uint      `json:"completed_transfer_id,omitempty"`
+	Details             string    `json:"details"`
+	ReviewedBy          uint      `json:"reviewed_by,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+} //@name ShowFlag
+
+type FreezeFlag struct {
+	// Freeze only the account owning this team, every account of the flag when empty
+	TeamID uint `json:"team_id" example:"2"`
+} //@name FreezeFlag
@@ -0,0 +1,17 @@
+package models
+
+// The signing fee of a free agent is this fraction of its market value
+const SigningFeeDivisor = 10
+
+// Get the fee a team pays to sign a free agent
+func (p Player) SigningFee() int {
+	return int(p.MarketValue) / SigningFeeDivisor
+}
+
+type ShowFreeAgent struct {
+	ShowPlayer
+	// Taken from the budget of the team that signs the player
+	SigningFee int `json:"signing_fee" example:"100000"`
+	// Lowest weekly wage the player accepts
+	AskingWage int `json:"asking_wage" example:"2000"`
+} //@name ShowFreeAgent
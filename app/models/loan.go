@@ -0,0 +1,47 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	LoanPending  = "pending"
+	LoanActive   = "active"
+	LoanReturned = "returned"
+)
+
+const (
+	LoanedIn  = "loaned_in"
+	LoanedOut = "loaned_out"
+)
+
+// Loan DB model, lends a player to another team until a given time
+type Loan struct {
+	gorm.Model
+	PlayerID       uint
+	Player         Player
+	ParentTeamID   uint
+	BorrowerTeamID uint
+	Fee            int
+	EndsAt         time.Time
+	Status         string
+}
+
+type ShowLoan struct {
+	ID             uint      `json:"id"`
+	PlayerID       uint      `json:"player_id"`
+	ParentTeamID   uint      `json:"parent_team_id"`
+	BorrowerTeamID uint      `json:"borrower_team_id"`
+	Fee            int       `json:"fee"`
+	EndsAt         time.Time `json:"ends_at"`
+	Status         string    `json:"status" example:"active"`
+} //@name ShowLoan
+
+type CreateLoan struct {
+	PlayerID uint `json:"player_id" binding:"required"`
+	// The team borrowing the player
+	TeamID uint      `json:"team_id" binding:"required"`
+	Fee    int       `json:"fee" example:"5000"`
+	EndsAt time.Time `json:"ends_at" binding:"required" example:"2021-06-01T00:00:00Z"`
+} //@name CreateLoan
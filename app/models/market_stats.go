@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+type ShowPriceStats struct {
+	Count   int     `json:"count" example:"12"`
+	Average float64 `json:"average" example:"1250000"`
+	Median  float64 `json:"median" example:"1100000"`
+} //@name PriceStats
+
+type ShowMarketGroupStats struct {
+	Key   string         `json:"key" example:"defender"`
+	Asks  ShowPriceStats `json:"asks"`
+	Sales ShowPriceStats `json:"sales"`
+} //@name MarketGroupStats
+
+type ShowPriceIndexPoint struct {
+	Day string `json:"day" example:"2026-10-18"`
+	// Average price paid over the market value before the sale, 100 means sales at market value
+	Index float64 `json:"index" example:"112.5"`
+	Sales int     `json:"sales" example:"4"`
+} //@name PriceIndexPoint
+
+type ShowMarketStats struct {
+	// Sales completed after this time are taken into account
+	Since time.Time `json:"since"`
+	// Asks of the open listings
+	Asks ShowPriceStats `json:"asks"`
+	// Prices of the completed sales
+	Sales      ShowPriceStats         `json:"sales"`
+	ByPosition []ShowMarketGroupStats `json:"by_position"`
+	ByAgeBand  []ShowMarketGroupStats `json:"by_age_band"`
+	ByCountry  []ShowMarketGroupStats `json:"by_country"`
+	// Share of the sales over the sales and the listings still open
+	SellThroughRate float64 `json:"sell_through_rate" example:"0.4"`
+	// Hours between listing a player and selling it
+	HoursToSale ShowPriceStats        `json:"hours_to_sale"`
+	PriceIndex  []ShowPriceIndexPoint `json:"price_index"`
+} //@name MarketStats
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	NotificationSearchMatch     = "search_match"
+	NotificationWatchedListed   = "watched_listed"
+	NotificationWatchedRepriced = "watched_repriced"
+	NotificationWatchedSold     = "watched_sold"
+	NotificationTradeProposed   = "trade_proposed"
+	NotificationListingExpired  = "listing_expired"
+	NotificationContractExpired = "contract_expired"
+)
+
+// Notification DB model, an entry in the inbox of a user
+type Notification struct {
+	gorm.Model
+	UserID     uint
+	Kind       string
+	Message    string
+	PlayerID   uint
+	TransferID uint
+	Read       bool
+}
+
+type ShowNotification struct {
+	ID         uint      `json:"id"`
+	Kind       string    `json:"kind" example:"watched_listed"`
+	Message    string    `json:"message"`
+	PlayerID   uint      `json:"player_id,omitempty"`
+	TransferID uint      `json:"transfer_id,omitempty"`
+	Read       bool      `json:"read"`
+	CreatedAt  time.Time `json:"created_at"`
+} //@name ShowNotification
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	OfferPending   = "pending"
+	OfferCountered = "countered"
+	OfferAccepted  = "accepted"
+	OfferRejected  = "rejected"
+	OfferWithdrawn = "withdrawn"
+	OfferExpired   = "expired"
+)
+
+// Offer DB model, a bid below the ask of a transfer that can be negotiated
+type Offer struct {
+	gorm.Model
+	TransferID uint
+	// The team making the offer
+	TeamID uint
+	Team   Team
+	// Last amount proposed by the buyer
+	Amount int
+	// Last amount proposed by the seller
+	CounterAmount int
+	Status        string
+	ExpiresAt     time.Time
+}
+
+// Returns a bool that tells if the offer is still being negotiated
+func (o Offer) IsOpen() bool {
+	return o.Status == OfferPending || o.Status == OfferCountered
+}
+
+// Returns the price the offer would be closed at if it was accepted now
+func (o Offer) Price() int {
+	if o.Status == OfferCountered {
+		return o.CounterAmount
+	}
+	return o.Amount
+}
+
+type ShowOffer struct {
+	ID            uint      `json:"id"`
+	TransferID    uint      `json:"transfer_id"`
+	TeamID        uint      `json:"team_id"`
+	Amount        int       `json:"amount"`
+	CounterAmount int       `json:"counter_amount,omitempty"`
+	Status        string    `json:"status" example:"pending"`
+	ExpiresAt     time.Time `json:"expires_at"`
+} //@name ShowOffer
+
+type CreateOffer struct {
+	Amount int `json:"amount" binding:"required" example:"9000"`
+} //@name CreateOffer
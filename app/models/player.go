@@ -4,15 +4,31 @@ import (
 	"github.com/Pallinder/go-randomdata"
 	"github.com/jinzhu/gorm"
 	"math/rand"
+	"time"
 )
 
 const (
-	goalkeeper = iota
-	defender
-	midfielder
-	attacker
+	Goalkeeper = iota
+	Defender
+	Midfielder
+	Attacker
 )
 
+// Availability of a player for the next match
+const (
+	PlayerFit       = "fit"
+	PlayerInjured   = "injured"
+	PlayerSuspended = "suspended"
+)
+
+// Names of the positions as shown on the API
+var PositionNames = map[int]string{
+	Goalkeeper: "goalkeeper",
+	Defender:   "defender",
+	Midfielder: "midfielder",
+	Attacker:   "attacker",
+}
+
 // DB player model
 type Player struct {
 	gorm.Model
@@ -24,11 +40,88 @@ type Player struct {
 	Position    int
 	TeamID      uint
 	Team        Team
+	// Price any other team can pay to buy the player at once, 0 if it has none
+	ReleaseClause int
+	PlayerAttributes
+	// Rating derived from the attributes that matter for the position, kept in sync with SetAttributes
+	Overall int `gorm:"index"`
+	// Time the player recovers from an injury, nil when it was never injured
+	InjuredUntil *time.Time
+	// Matches left to serve of a suspension
+	SuspendedMatches int
+	// Picked by the manager for the next match
+	InLineup bool
+	// Contract with the team, free agents have none
+	WeeklyWage       int
+	ContractStartsAt *time.Time
+	ContractEndsAt   *time.Time `gorm:"index"`
+	// Wages were paid by the team up to this time
+	WagesPaidUntil *time.Time
+	// Prospects in the academy of the team are not part of the squad until they are promoted
+	InAcademy bool `gorm:"index"`
+}
+
+// Get the availability of a player at a time, an injury shows over a suspension
+func (p Player) Availability(now time.Time) string {
+	if p.InjuredUntil != nil && p.InjuredUntil.After(now) {
+		return PlayerInjured
+	}
+	if p.SuspendedMatches > 0 {
+		return PlayerSuspended
+	}
+	return PlayerFit
+}
+
+// Returns a bool that tells if a player can play at a time
+func (p Player) IsAvailable(now time.Time) bool {
+	return p.Availability(now) == PlayerFit
+}
+
+// Skills of a player, every attribute goes from MinAttribute to MaxAttribute
+type PlayerAttributes struct {
+	Pace        int `json:"pace" example:"72" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	Shooting    int `json:"shooting" example:"65" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	Passing     int `json:"passing" example:"70" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	Defending   int `json:"defending" example:"48" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	Goalkeeping int `json:"goalkeeping" example:"12" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	Physical    int `json:"physical" example:"68" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+	// Overall rating the player can grow up to
+	Potential int `json:"potential" example:"80" validate:"min=1,max=99" minimum:"1" maximum:"99"`
+}
+
+const (
+	MinAttribute = 1
+	MaxAttribute = 99
+)
+
+// Weight in percent of every attribute in the overall rating of each position
+var overallWeights = map[int]PlayerAttributes{
+	Goalkeeper: {Goalkeeping: 80, Passing: 10, Physical: 10},
+	Defender:   {Defending: 50, Physical: 20, Pace: 15, Passing: 15},
+	Midfielder: {Passing: 40, Pace: 15, Shooting: 15, Defending: 15, Physical: 15},
+	Attacker:   {Shooting: 45, Pace: 25, Passing: 15, Physical: 15},
+}
+
+// Get the overall rating of the attributes for a position
+func (a PlayerAttributes) OverallRating(position int) int {
+	w := overallWeights[position]
+	total := a.Pace*w.Pace + a.Shooting*w.Shooting + a.Passing*w.Passing +
+		a.Defending*w.Defending + a.Goalkeeping*w.Goalkeeping + a.Physical*w.Physical
+	return clampAttribute((total + 50) / 100)
+}
+
+// Set the attributes of a player and update its overall rating, call it again after the position changes
+func (p *Player) SetAttributes(a PlayerAttributes) {
+	p.PlayerAttributes = a
+	p.Overall = a.OverallRating(p.Position)
+	if p.Potential < p.Overall {
+		p.Potential = p.Overall
+	}
 }
 
 // Create a player with random characteristics
 func RandomPlayer(position int) Player {
-	return Player{
+	p := Player{
 		MarketValue: 1000000,
 		FirstName:   randomdata.FirstName(randomdata.RandomGender),
 		LastName:    randomdata.LastName(),
@@ -36,6 +129,8 @@ func RandomPlayer(position int) Player {
 		Country:     randomdata.Country(randomdata.FullCountry),
 		Position:    position,
 	}
+	p.SetAttributes(RandomAttributes(position, p.Age))
+	return p
 }
 
 // Get a random age between 18 and 40
@@ -43,6 +138,52 @@ func randomAge() int {
 	return rand.Intn(40-18) + 18
 }
 
+// Generate attributes for a player of a position and age. Players get a random quality, the attributes
+// their position relies on are close to it and the rest are lower, only goalkeepers are good at goalkeeping.
+// Younger players have more room to grow.
+func RandomAttributes(position int, age int) PlayerAttributes {
+	quality := 65 + rand.NormFloat64()*8
+	around := func(mean float64) int {
+		return clampAttribute(int(mean + rand.NormFloat64()*6))
+	}
+	a := PlayerAttributes{
+		Pace:        around(quality - 15),
+		Shooting:    around(quality - 20),
+		Passing:     around(quality - 10),
+		Defending:   around(quality - 20),
+		Goalkeeping: around(15),
+		Physical:    around(quality - 5),
+	}
+	switch position {
+	case Goalkeeper:
+		a.Goalkeeping = around(quality + 5)
+		a.Pace, a.Shooting, a.Defending = around(35), around(20), around(30)
+	case Defender:
+		a.Defending = around(quality + 5)
+	case Midfielder:
+		a.Passing = around(quality + 5)
+	case Attacker:
+		a.Shooting, a.Pace = around(quality+5), around(quality)
+	}
+	growth := 0
+	if age < 30 {
+		growth = rand.Intn((30-age)*2 + 1)
+	}
+	a.Potential = clampAttribute(a.OverallRating(position) + growth)
+	return a
+}
+
+// Keep an attribute within its bounds
+func clampAttribute(v int) int {
+	if v < MinAttribute {
+		return MinAttribute
+	}
+	if v > MaxAttribute {
+		return MaxAttribute
+	}
+	return v
+}
+
 type BasePlayer struct {
 	FirstName   string `json:"first_name" example:"Audrey"`
 	LastName    string `json:"last_name" example:"Hepburn"`
@@ -56,6 +197,21 @@ type BasePlayer struct {
 type ShowPlayer struct {
 	BasePlayer
 	ID uint `json:"id"`
+	// Set to 'loaned_in' or 'loaned_out' when the player is listed on a team and is on loan
+	Loan string `json:"loan,omitempty" example:"loaned_in"`
+	// Price any other team can pay to buy the player at once
+	ReleaseClause int `json:"release_clause,omitempty" example:"5000000"`
+	PlayerAttributes
+	// Rating derived from the attributes that matter for the position
+	Overall int `json:"overall" example:"71"`
+	// Can be 'fit', 'injured' or 'suspended'
+	Availability     string     `json:"availability" example:"injured"`
+	InjuredUntil     *time.Time `json:"injured_until,omitempty"`
+	SuspendedMatches int        `json:"suspended_matches,omitempty"`
+	// Set when the player is picked for the next match
+	InLineup bool `json:"in_lineup,omitempty"`
+	// Set when the player belongs to no team and can be signed from the free agent pool
+	FreeAgent bool `json:"free_agent,omitempty"`
 } //@name ShowPlayer
 
 type CreatePlayer struct {
@@ -66,9 +222,29 @@ type CreatePlayer struct {
 	MarketValue int32  `json:"market_value" example:"25000" binding:"required"`
 	// This is the position identifier 0 for goalkeeper, 1 for defender, 2 for goalkeeper, 3 for attacker
 	Position int `json:"position" example:"1" binding:"required" validate:"min=0,max=3" minimum:"0" maximum:"3"`
+	// Random attributes for the position are generated when they are all left out
+	PlayerAttributes
 } //@name CreatePlayer
 
 type UpdatePlayer struct {
 	BasePlayer
 	Team int `json:"team"`
+	// Set to 0 to remove the release clause
+	ReleaseClause int `json:"release_clause" example:"5000000"`
+	// Only administrators can change the attributes
+	PlayerAttributes
 } //@name UpdatePlayer
+
+type UpdateAvailability struct {
+	// Can be 'fit', 'injured' or 'suspended'. Fit clears both the injury and the suspension
+	Status string `json:"status" binding:"required" example:"injured"`
+	// Time the player recovers, required when the status is 'injured'
+	InjuredUntil *time.Time `json:"injured_until" example:"2021-05-01T18:00:00Z"`
+	// Matches the player misses, required when the status is 'suspended'
+	SuspendedMatches int `json:"suspended_matches" example:"2"`
+} //@name UpdateAvailability
+
+type UpdateLineup struct {
+	// Players picked for the next match, at most eleven
+	PlayerIDs []uint `json:"player_ids"`
+} //@name UpdateLineup
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	LineItemMarketTax = "market_tax"
+	LineItemSellOn    = "sell_on"
+	LineItemSellerNet = "seller_net"
+	// Paid by the seller when it listed the player, on top of the split of the price
+	LineItemListingFee = "listing_fee"
+)
+
+// SaleLineItem DB model, a part of the price of a sale and who received it
+type SaleLineItem struct {
+	gorm.Model
+	CompletedTransferID uint
+	Kind                string
+	Amount              int
+	// The team receiving the amount, 0 when it goes to the league treasury
+	TeamID uint
+}
+
+type ShowSaleLineItem struct {
+	Kind   string `json:"kind" example:"market_tax"`
+	Amount int    `json:"amount" example:"500"`
+	// The team receiving the amount, missing when it goes to the league treasury
+	TeamID uint `json:"team_id,omitempty"`
+} //@name ShowSaleLineItem
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// SavedSearch DB model, a transfer market filter a user gets alerted about
+type SavedSearch struct {
+	gorm.Model
+	UserID     uint
+	Name       string
+	Country    string
+	TeamName   string
+	PlayerName string
+	MinAge     int
+	MaxAge     int
+	MinValue   int
+	MaxValue   int
+	ValueType  string
+}
+
+type ShowSavedSearch struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name" example:"Young strikers"`
+	Country    string `json:"country,omitempty"`
+	TeamName   string `json:"team_name,omitempty"`
+	PlayerName string `json:"player_name,omitempty"`
+	MinAge     *int   `json:"min_age,omitempty"`
+	MaxAge     *int   `json:"max_age,omitempty"`
+	MinValue   *int   `json:"min_value,omitempty"`
+	MaxValue   *int   `json:"max_value,omitempty"`
+	ValueType  string `json:"value_type,omitempty" example:"ask"`
+} //@name ShowSavedSearch
+
+type CreateSavedSearch struct {
+	Name       string `json:"name" binding:"required" example:"Young strikers"`
+	Country    string `json:"country"`
+	TeamName   string `json:"team_name"`
+	PlayerName string `json:"player_name"`
+	MinAge     *int   `json:"min_age"`
+	MaxAge     *int   `json:"max_age" example:"23"`
+	MinValue   *int   `json:"min_value"`
+	MaxValue   *int   `json:"max_value" example:"2000000"`
+	// Type of value to filter by. Can be 'market' or 'ask'. Defaults to 'ask'
+	ValueType string `json:"value_type" example:"ask"`
+} //@name CreateSavedSearch
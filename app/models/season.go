@@ -0,0 +1,43 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Season DB model, the report of a season rollover. Seasons are named after the year they start in
+// and only rolled over once.
+type Season struct {
+	gorm.Model
+	Number int `gorm:"uniqueIndex"`
+	// Players that got a year older and revalued
+	PlayersAged int
+	// Players past the retirement age that left the league
+	PlayersRetired int
+	// Listings of the retired players that were taken off the market
+	ListingsWithdrawn int
+	// Young players created to replace the retired ones
+	PlayersGenerated int
+	// Total market value of the players before and after the rollover
+	ValueBefore int64
+	ValueAfter  int64
+}
+
+type ShowSeason struct {
+	ID                uint      `json:"id"`
+	Number            int       `json:"season" example:"2026"`
+	PlayersAged       int       `json:"players_aged" example:"480"`
+	PlayersRetired    int       `json:"players_retired" example:"12"`
+	ListingsWithdrawn int       `json:"listings_withdrawn" example:"2"`
+	PlayersGenerated  int       `json:"players_generated" example:"12"`
+	ValueBefore       int64     `json:"value_before" example:"480000000"`
+	ValueAfter        int64     `json:"value_after" example:"471500000"`
+	RolledOverAt      time.Time `json:"rolled_over_at"`
+	// Set when the season had been rolled over before and nothing changed
+	AlreadyRolledOver bool `json:"already_rolled_over,omitempty"`
+} //@name ShowSeason
+
+type CreateSeasonRollover struct {
+	// Season that starts with the rollover, named after the year it starts in
+	Season int `json:"season" binding:"required" example:"2026"`
+} //@name CreateSeasonRollover
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// SquadRules DB model, limits on the size and composition of a squad. A limit of 0 means there is no limit.
+type SquadRules struct {
+	gorm.Model
+	MinSquadSize   int
+	MaxSquadSize   int
+	MinGoalkeepers int
+	MaxGoalkeepers int
+	MinDefenders   int
+	MaxDefenders   int
+	MinMidfielders int
+	MaxMidfielders int
+	MinAttackers   int
+	MaxAttackers   int
+}
+
+// A squad rule that a change would break
+type SquadRuleViolation struct {
+	Rule    string
+	Message string
+}
+
+// A named limit over the amount of players in a squad
+type squadLimit struct {
+	name  string
+	count func(players []Player) int
+	min   int
+	max   int
+}
+
+// Check if changing a squad from before to after breaks a rule. Rules already broken by
+// the squad are only reported if the change makes them worse.
+func (r SquadRules) Check(before []Player, after []Player) *SquadRuleViolation {
+	for _, l := range r.limits() {
+		prev, next := l.count(before), l.count(after)
+		if l.min > 0 && next < l.min && next < prev {
+			return &SquadRuleViolation{
+				Rule:    "min_" + l.name,
+				Message: fmt.Sprintf("Squad would have %v %v, the minimum is %v", next, l.name, l.min),
+			}
+		}
+		if l.max > 0 && next > l.max && next > prev {
+			return &SquadRuleViolation{
+				Rule:    "max_" + l.name,
+				Message: fmt.Sprintf("Squad would have %v %v, the maximum is %v", next, l.name, l.max),
+			}
+		}
+	}
+	return nil
+}
+
+// Get every limit of the rules
+func (r SquadRules) limits() []squadLimit {
+	return []squadLimit{
+		{name: "squad_size", count: func(players []Player) int { return len(players) }, min: r.MinSquadSize, max: r.MaxSquadSize},
+		{name: "goalkeepers", count: countPosition(Goalkeeper), min: r.MinGoalkeepers, max: r.MaxGoalkeepers},
+		{name: "defenders", count: countPosition(Defender), min: r.MinDefenders, max: r.MaxDefenders},
+		{name: "midfielders", count: countPosition(Midfielder), min: r.MinMidfielders, max: r.MaxMidfielders},
+		{name: "attackers", count: countPosition(Attacker), min: r.MinAttackers, max: r.MaxAttackers},
+	}
+}
+
+// Get a function that counts the players of a position
+func countPosition(position int) func(players []Player) int {
+	return func(players []Player) int {
+		count := 0
+		for _, p := range players {
+			if p.Position == position {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+type ShowSquadRules struct {
+	MinSquadSize   int `json:"min_squad_size" example:"16"`
+	MaxSquadSize   int `json:"max_squad_size" example:"25"`
+	MinGoalkeepers int `json:"min_goalkeepers" example:"2"`
+	MaxGoalkeepers int `json:"max_goalkeepers" example:"4"`
+	MinDefenders   int `json:"min_defenders" example:"5"`
+	MaxDefenders   int `json:"max_defenders" example:"9"`
+	MinMidfielders int `json:"min_midfielders" example:"5"`
+	MaxMidfielders int `json:"max_midfielders" example:"9"`
+	MinAttackers   int `json:"min_attackers" example:"3"`
+	MaxAttackers   int `json:"max_attackers" example:"7"`
+} //@name SquadRules
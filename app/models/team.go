@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/Pallinder/go-randomdata"
 	"github.com/jinzhu/gorm"
+	"time"
 )
 
 const (
@@ -22,6 +23,8 @@ type Team struct {
 	Budget  int
 	UserID  uint
 	User    User
+	// Bot teams have no owner and trade on the market on their own
+	IsBot bool
 }
 
 // Create a team with a random name, default budget and random players
@@ -34,27 +37,44 @@ func RandomTeam() (Team, []Player) {
 	players := make([]Player, teamSize)
 	i := 0
 	for j := i; i < j+goalKeeperCount; i++ {
-		players[i] = RandomPlayer(goalkeeper)
+		players[i] = RandomPlayer(Goalkeeper)
 	}
 	for j := i; i < j+defenderCount; i++ {
-		players[i] = RandomPlayer(defender)
+		players[i] = RandomPlayer(Defender)
 	}
 	for j := i; i < j+midfielderCount; i++ {
-		players[i] = RandomPlayer(midfielder)
+		players[i] = RandomPlayer(Midfielder)
 	}
 	for j := i; i < j+attackerCount; i++ {
-		players[i] = RandomPlayer(attacker)
+		players[i] = RandomPlayer(Attacker)
+	}
+	now := time.Now()
+	for i := range players {
+		players[i].SignRandomContract(now)
 	}
 	return team, players
 }
 
+// Amount of players of each position a random team starts with
+func SquadComposition() map[int]int {
+	return map[int]int{
+		Goalkeeper: goalKeeperCount,
+		Defender:   defenderCount,
+		Midfielder: midfielderCount,
+		Attacker:   attackerCount,
+	}
+}
+
 type ShowTeam struct {
-	ID          uint         `json:"id"`
-	Name        string       `json:"name"`
-	Country     string       `json:"country"`
-	Budget      int          `json:"budget"`
-	MarketValue int          `json:"market_value"`
-	Players     []ShowPlayer `json:"players"`
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Country     string `json:"country"`
+	Budget      int    `json:"budget"`
+	MarketValue int    `json:"market_value"`
+	// Weekly wages of the players in the team
+	WageBill int          `json:"wage_bill"`
+	IsBot    bool         `json:"is_bot"`
+	Players  []ShowPlayer `json:"players"`
 } //@name ShowTeam
 
 type CreateTeam struct {
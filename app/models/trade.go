@@ -0,0 +1,68 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	TradePending   = "pending"
+	TradeExecuted  = "executed"
+	TradeRejected  = "rejected"
+	TradeWithdrawn = "withdrawn"
+)
+
+// Trade DB model, a proposal to exchange players between two teams with optional cash
+type Trade struct {
+	gorm.Model
+	ProposerTeamID uint
+	ReceiverTeamID uint
+	// Cash the proposer pays the receiver, negative when the receiver pays the proposer
+	Cash             int
+	ProposerAccepted bool
+	ReceiverAccepted bool
+	Status           string
+	Players          []TradePlayer
+}
+
+// TradePlayer DB model, a player included in a trade
+type TradePlayer struct {
+	gorm.Model
+	TradeID  uint
+	PlayerID uint
+	// The team the player leaves
+	FromTeamID uint
+}
+
+// Get the ids of the players a team gives away in the trade
+func (t Trade) PlayersFrom(teamId uint) []uint {
+	ids := make([]uint, 0)
+	for _, p := range t.Players {
+		if p.FromTeamID == teamId {
+			ids = append(ids, p.PlayerID)
+		}
+	}
+	return ids
+}
+
+type ShowTrade struct {
+	ID             uint `json:"id"`
+	ProposerTeamID uint `json:"proposer_team_id"`
+	ReceiverTeamID uint `json:"receiver_team_id"`
+	// Players going from the proposer to the receiver
+	OfferedPlayerIDs []uint `json:"offered_player_ids"`
+	// Players going from the receiver to the proposer
+	RequestedPlayerIDs []uint `json:"requested_player_ids"`
+	Cash               int    `json:"cash" example:"10000"`
+	ProposerAccepted   bool   `json:"proposer_accepted"`
+	ReceiverAccepted   bool   `json:"receiver_accepted"`
+	Status             string `json:"status" example:"pending"`
+} //@name ShowTrade
+
+type CreateTrade struct {
+	// The team receiving the proposal
+	TeamID             uint   `json:"team_id" binding:"required"`
+	OfferedPlayerIDs   []uint `json:"offered_player_ids"`
+	RequestedPlayerIDs []uint `json:"requested_player_ids"`
+	// Cash the proposer pays, negative when asking the other team for cash
+	Cash int `json:"cash" example:"10000"`
+} //@name CreateTrade
@@ -1,6 +1,14 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	TransferModeFixed   = "fixed"
+	TransferModeAuction = "auction"
+)
 
 // Transfer DB model
 type Transfer struct {
@@ -8,12 +16,54 @@ type Transfer struct {
 	PlayerID uint
 	Player   Player
 	Ask      int
+	// Either TransferModeFixed or TransferModeAuction
+	Mode         string `gorm:"default:fixed"`
+	ReservePrice int
+	EndsAt       *time.Time
+	// Fixed price listings are taken off the market at this time, nil if they never expire
+	ExpiresAt *time.Time
+	// Fee the team paid the league treasury to list the player
+	ListingFee int
+	Bids       []Bid
+}
+
+// Returns a bool that tells if the transfer is sold through bids
+func (t Transfer) IsAuction() bool {
+	return t.Mode == TransferModeAuction
+}
+
+// Returns a bool that tells if the auction no longer accepts bids
+func (t Transfer) HasEnded(now time.Time) bool {
+	return t.EndsAt != nil && !now.Before(*t.EndsAt)
+}
+
+// Returns a bool that tells if the listing is no longer on the market
+func (t Transfer) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Returns the highest bid that still holds funds or nil if there is none
+func (t Transfer) HighestBid() *Bid {
+	var highest *Bid
+	for i := range t.Bids {
+		if t.Bids[i].Active && (highest == nil || t.Bids[i].Amount > highest.Amount) {
+			highest = &t.Bids[i]
+		}
+	}
+	return highest
 }
 
 type ShowTransfer struct {
-	ID     uint       `json:"id"`
-	Player ShowPlayer `json:"player"`
-	Ask    int        `json:"ask"`
+	ID           uint       `json:"id"`
+	Player       ShowPlayer `json:"player"`
+	Ask          int        `json:"ask"`
+	Mode         string     `json:"mode" example:"fixed"`
+	ReservePrice int        `json:"reserve_price,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	HighestBid   int        `json:"highest_bid,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// Set when the player is injured
+	Injured bool `json:"injured,omitempty"`
 } //@name ShowTransfer
 
 type UpdateTransfer struct {
@@ -22,5 +72,32 @@ type UpdateTransfer struct {
 
 type CreateTransfer struct {
 	PlayerID uint `json:"player_id" binding:"required"`
-	Ask      int  `json:"ask" binding:"required"`
+	// Required when the mode is 'fixed'
+	Ask int `json:"ask"`
+	// Can be 'fixed' or 'auction'. Defaults to 'fixed'
+	Mode string `json:"mode" example:"auction"`
+	// Minimum bid accepted when the mode is 'auction'
+	ReservePrice int `json:"reserve_price" example:"10000"`
+	// Time at which the auction closes, required when the mode is 'auction'
+	EndsAt *time.Time `json:"ends_at" example:"2021-05-01T18:00:00Z"`
+	// Time at which a fixed price listing is taken off the market. Defaults to the league listing lifetime
+	ExpiresAt *time.Time `json:"expires_at" example:"2021-05-01T18:00:00Z"`
 } //@name CreateTransfer
+
+type CreateBulkTransfers struct {
+	// Players of the same team to list, each with its own ask
+	Transfers []CreateTransfer `json:"transfers" binding:"required"`
+} //@name CreateBulkTransfers
+
+type DeleteBulkTransfers struct {
+	TransferIDs []uint `json:"transfer_ids" binding:"required"`
+} //@name DeleteBulkTransfers
+
+// The outcome of one item of a bulk request, the error is empty when the item is valid
+type BulkTransferResult struct {
+	PlayerID   uint   `json:"player_id,omitempty"`
+	TransferID uint   `json:"transfer_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// The squad rule the item breaks
+	Rule string `json:"rule,omitempty" example:"min_goalkeepers"`
+} //@name BulkTransferResult
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TransferEventListed    = "listed"
+	TransferEventRepriced  = "repriced"
+	TransferEventSold      = "sold"
+	TransferEventWithdrawn = "withdrawn"
+	TransferEventExpired   = "expired"
+)
+
+type ShowTransferEvent struct {
+	// Send it back as the Last-Event-ID header or the last_event_id parameter to resume after reconnecting
+	ID   uint64 `json:"id"`
+	Kind string `json:"kind" example:"sold"`
+	// The listing as it was before the change
+	Transfer    ShowTransfer `json:"transfer"`
+	Price       int          `json:"price,omitempty"`
+	BuyerTeamID uint         `json:"buyer_team_id,omitempty"`
+	At          time.Time    `json:"at"`
+} //@name ShowTransferEvent
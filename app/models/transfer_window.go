@@ -0,0 +1,38 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// TransferWindow DB model, a period of time where the market is open
+type TransferWindow struct {
+	gorm.Model
+	Name     string
+	OpensAt  time.Time
+	ClosesAt time.Time
+}
+
+// Returns a bool that tells if the window is open at a given time
+func (w TransferWindow) IsOpen(now time.Time) bool {
+	return !now.Before(w.OpensAt) && now.Before(w.ClosesAt)
+}
+
+type ShowTransferWindow struct {
+	ID       uint      `json:"id"`
+	Name     string    `json:"name" example:"Winter 2021"`
+	OpensAt  time.Time `json:"opens_at"`
+	ClosesAt time.Time `json:"closes_at"`
+} //@name ShowTransferWindow
+
+type CreateTransferWindow struct {
+	Name     string    `json:"name" binding:"required" example:"Winter 2021"`
+	OpensAt  time.Time `json:"opens_at" binding:"required" example:"2021-01-01T00:00:00Z"`
+	ClosesAt time.Time `json:"closes_at" binding:"required" example:"2021-02-01T00:00:00Z"`
+} //@name CreateTransferWindow
+
+type UpdateTransferWindow struct {
+	Name     string    `json:"name"`
+	OpensAt  time.Time `json:"opens_at"`
+	ClosesAt time.Time `json:"closes_at"`
+} //@name UpdateTransferWindow
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	TreasuryListingFee = "listing_fee"
+	TreasuryMarketTax  = "market_tax"
+	TreasurySigningFee = "signing_fee"
+)
+
+// TreasuryEntry DB model, money a team paid to the league treasury
+type TreasuryEntry struct {
+	gorm.Model
+	Kind   string
+	Amount int
+	// The team that paid
+	TeamID              uint
+	TransferID          uint
+	CompletedTransferID uint
+}
+
+type ShowTreasury struct {
+	Balance int `json:"balance" example:"150000"`
+	// Total collected by kind of entry
+	Totals map[string]int `json:"totals"`
+} //@name ShowTreasury
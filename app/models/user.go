@@ -10,6 +10,10 @@ type User struct {
 	Email           string
 	PasswordHash    []byte
 	PermissionLevel int
+	// Address the account was registered from
+	SignupIP string
+	// Frozen accounts can not make authenticated requests
+	Frozen bool
 }
 
 // Returns a bool that represents if the user has admin privileges.
@@ -18,13 +22,15 @@ func (u User) IsAdmin() bool {
 }
 
 type ShowUser struct {
-	ID    uint     `json:"id"`
-	Email string   `json:"email"`
-	Team  ShowTeam `json:"team"`
+	ID     uint     `json:"id"`
+	Email  string   `json:"email"`
+	Frozen bool     `json:"frozen"`
+	Team   ShowTeam `json:"team"`
 } //@name ShowUser
 
 type UpdateUser struct {
-	Email string `json:"email"`
+	Email  string `json:"email"`
+	Frozen bool   `json:"frozen"`
 } //@name UpdateUser
 
 type CreateUser struct {
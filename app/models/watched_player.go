@@ -0,0 +1,22 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// WatchedPlayer DB model, a player a user gets alerted about when listed, re-priced or sold
+type WatchedPlayer struct {
+	gorm.Model
+	UserID   uint
+	PlayerID uint
+}
+
+type ShowWatchedPlayer struct {
+	PlayerID  uint      `json:"player_id"`
+	WatchedAt time.Time `json:"watched_at"`
+} //@name ShowWatchedPlayer
+
+type CreateWatchedPlayer struct {
+	PlayerID uint `json:"player_id" binding:"required"`
+} //@name CreateWatchedPlayer
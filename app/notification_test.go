@@ -0,0 +1,92 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestWatchedPlayerNotifications(t *testing.T) {
+	setupTest()
+	seller, players := getTokenAndPlayerIds(t, false)
+	scout := getUserToken(t, "scout@test.com")
+	_, err := doPostRequest("me/watchlist", scout, map[string]interface{}{
+		"player_id": players[0],
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transferId := createTransferUsing(t, 10000, seller, players[0])
+	_, err = doPatchRequest("transfers/"+strconv.Itoa(transferId), seller, map[string]interface{}{
+		"ask": 8000,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("me/notifications", scout, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications := resp["notifications"].([]interface{})
+	tests.AssertEqual(t, len(notifications), 2)
+	tests.AssertEqual(t, resp["unread"], 2)
+	// Most recent first
+	tests.AssertEqual(t, notifications[0].(map[string]interface{})["kind"], "watched_repriced")
+	tests.AssertEqual(t, notifications[1].(map[string]interface{})["kind"], "watched_listed")
+
+	// The seller doesn't get notified about its own player
+	resp, err = doGetRequest("me/notifications", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["notifications"].([]interface{})), 0)
+}
+
+func TestSavedSearchNotifications(t *testing.T) {
+	setupTest()
+	seller, players := getTokenAndPlayerIds(t, false)
+	scout := getUserToken(t, "scout@test.com")
+	_, err := doPostRequest("me/searches", scout, map[string]interface{}{
+		"name":      "Cheap",
+		"max_value": 5000,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = createTransferUsing(t, 10000, seller, players[0])
+	_ = createTransferUsing(t, 4000, seller, players[1])
+
+	resp, err := doGetRequest("me/notifications?unread=true", scout, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications := resp["notifications"].([]interface{})
+	tests.AssertEqual(t, len(notifications), 1)
+	notification := notifications[0].(map[string]interface{})
+	tests.AssertEqual(t, notification["kind"], "search_match")
+	tests.AssertEqual(t, notification["player_id"], players[1])
+
+	id := strconv.Itoa(int(notification["id"].(float64)))
+	_, err = doPutRequest("me/notifications/"+id+"/read", scout, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = doGetRequest("me/notifications?unread=true", scout, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["notifications"].([]interface{})), 0)
+}
+
+func TestCantReadOthersNotifications(t *testing.T) {
+	setupTest()
+	token := getUserToken(t, "scout@test.com")
+	_, err := doPutRequest("me/notifications/1/read", token, map[string]interface{}{}, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestNegotiateOffer(t *testing.T) {
+	setupTest()
+	seller, _, transferId := createTransfer(t, 10000)
+	buyer := getUserToken(t, "hola@test.com")
+	offers := "transfers/" + strconv.Itoa(transferId) + "/offers"
+
+	resp, err := doPostRequest(offers, buyer, map[string]interface{}{"amount": 7000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer := offers + "/" + strconv.Itoa(int(resp["id"].(float64)))
+
+	// The buyer can't accept its own offer
+	_, err = doPutRequest(offer+"/accept", buyer, map[string]interface{}{}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(offer+"/counter", seller, map[string]interface{}{"amount": 9000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(offer+"/accept", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp1, err := doGetRequest("me/team", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2, err := doGetRequest("me/team", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp1["budget"], models.DefaultTeamBudget+9000)
+	tests.AssertEqual(t, resp2["budget"], models.DefaultTeamBudget-9000)
+
+	_, err = doGetRequest("transfers/"+strconv.Itoa(transferId), seller, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCantOfferAboveAsk(t *testing.T) {
+	setupTest()
+	_, _, transferId := createTransfer(t, 10000)
+	buyer := getUserToken(t, "hola@test.com")
+	_, err := doPostRequest("transfers/"+strconv.Itoa(transferId)+"/offers", buyer, map[string]interface{}{"amount": 10000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawnOfferCantBeAccepted(t *testing.T) {
+	setupTest()
+	seller, _, transferId := createTransfer(t, 10000)
+	buyer := getUserToken(t, "hola@test.com")
+	offers := "transfers/" + strconv.Itoa(transferId) + "/offers"
+
+	resp, err := doPostRequest(offers, buyer, map[string]interface{}{"amount": 7000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer := offers + "/" + strconv.Itoa(int(resp["id"].(float64)))
+	_, err = doPutRequest(offer+"/withdraw", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(offer+"/accept", seller, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doGetRequest(offers, seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := resp["offers"].([]interface{})
+	tests.AssertEqual(t, len(arr), 1)
+	tests.AssertEqual(t, arr[0].(map[string]interface{})["status"], models.OfferWithdrawn)
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"./models"
 	"gorm.io/gorm/utils/tests"
 	"net/http"
 	"strconv"
@@ -60,6 +61,56 @@ func TestPostPlayer(t *testing.T) {
 	}
 }
 
+func TestTriggerReleaseClause(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	_, err := doPatchRequest("economic-rules", admin, map[string]interface{}{
+		"min_release_clause_multiple": 1.5,
+		"max_release_clause_multiple": 3,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seller, players := getTokenAndPlayerIds(t, false)
+	player := players[0]
+	_, err = doPatchRequest("players/"+strconv.Itoa(player), seller, map[string]interface{}{"release_clause": 1000000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patchPlayer(t, seller, player, map[string]interface{}{"release_clause": 2000000})
+	tests.AssertEqual(t, getPlayer(t, seller, player)["release_clause"], 2000000)
+
+	// Neither the owner nor anyone buying a player without a clause can trigger it
+	_, err = doPostRequest("players/"+strconv.Itoa(player)+"/release-clause", seller, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buyer := getUserToken(t, "hola@test.com")
+	_, err = doPostRequest("players/"+strconv.Itoa(players[1])+"/release-clause", buyer, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = doPostRequest("players/"+strconv.Itoa(player)+"/release-clause", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sellerTeam, err := doGetRequest("me/team", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buyerTeam, err := doGetRequest("me/team", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, sellerTeam["budget"], models.DefaultTeamBudget+2000000)
+	tests.AssertEqual(t, buyerTeam["budget"], models.DefaultTeamBudget-2000000)
+	tests.AssertEqual(t, hasPlayer(buyerTeam, player), true)
+	// The clause does not carry over to the new owner
+	tests.AssertEqual(t, getPlayer(t, buyer, player)["release_clause"], nil)
+}
+
 func getTeamIdFromUser(t *testing.T, token string) int {
 	resp, err := doGetRequest("me", token, http.StatusOK)
 
@@ -139,5 +190,15 @@ func getPlayerPayload() map[string]interface{} {
 		"position":     1,
 		"country":      "united states",
 		"market_value": 10203012,
+		"pace":         70,
+		"shooting":     50,
+		"passing":      60,
+		"defending":    80,
+		"goalkeeping":  10,
+		"physical":     75,
+		"potential":    85,
+		// Overall rating of a defender with the attributes above
+		"overall":      75,
+		"availability": "fit",
 	}
 }
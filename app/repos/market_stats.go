@@ -0,0 +1,220 @@
+package repos
+
+import (
+	"../models"
+	"fmt"
+	"gorm.io/gorm"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A range of ages market stats are grouped by
+type AgeBand struct {
+	Label string
+	Min   int
+	Max   int
+}
+
+// Age bands market stats are grouped by, they cover every age
+var AgeBands = []AgeBand{
+	{Label: "21-", Min: math.MinInt32, Max: 21},
+	{Label: "22-25", Min: 22, Max: 25},
+	{Label: "26-29", Min: 26, Max: 29},
+	{Label: "30-33", Min: 30, Max: 33},
+	{Label: "34+", Min: 34, Max: math.MaxInt32},
+}
+
+// Get the label of the age band an age belongs to
+func AgeBandOf(age int) string {
+	for _, b := range AgeBands {
+		if age >= b.Min && age <= b.Max {
+			return b.Label
+		}
+	}
+	return ""
+}
+
+// Aggregated numbers over a group of values
+type PriceStats struct {
+	Count   int
+	Average float64
+	Median  float64
+}
+
+// Stats of the asks of the open listings and the prices of the sales that share a key
+type MarketGroupStats struct {
+	Key   string
+	Asks  PriceStats
+	Sales PriceStats
+}
+
+// Average price paid over the market value before the sale on a day, 100 means sales at market value
+type PriceIndexPoint struct {
+	Day   time.Time
+	Index float64
+	Sales int
+}
+
+// Aggregated numbers over the open listings and the sales that match some filters
+type MarketStats struct {
+	Asks       PriceStats
+	Sales      PriceStats
+	ByPosition []MarketGroupStats
+	ByAgeBand  []MarketGroupStats
+	ByCountry  []MarketGroupStats
+	// Share of the sales over the sales and the listings still open
+	SellThroughRate float64
+	// Hours between listing a player and selling it
+	HoursToSale PriceStats
+	PriceIndex  []PriceIndexPoint
+}
+
+// The listings and sales market stats are computed over
+type MarketStatsQuery struct {
+	Filters TransferFilters
+	// Only sales completed after this time are taken into account
+	Since time.Time
+	// Leave out the listings expired at this time, every listing is taken into account when zero
+	ActiveAt time.Time
+}
+
+// Compute the stats of a group of values
+func priceStatsOf(values []float64) PriceStats {
+	if len(values) == 0 {
+		return PriceStats{}
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return PriceStats{Count: len(sorted), Average: sum / float64(len(sorted)), Median: median}
+}
+
+// Merge the stats of the asks and the sales by key, sorted by key
+func mergeGroupStats(asks map[string]PriceStats, sales map[string]PriceStats) []MarketGroupStats {
+	keys := make([]string, 0)
+	for k := range asks {
+		keys = append(keys, k)
+	}
+	for k := range sales {
+		if _, ok := asks[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	groups := make([]MarketGroupStats, 0)
+	for _, k := range keys {
+		groups = append(groups, MarketGroupStats{Key: k, Asks: asks[k], Sales: sales[k]})
+	}
+	return groups
+}
+
+// Rate of sales over the sales and the open listings, 0 when there are none
+func sellThroughRate(sales, listings int) float64 {
+	if sales+listings == 0 {
+		return 0
+	}
+	return float64(sales) / float64(sales+listings)
+}
+
+// A listing or a sale with the player it was about, used to compute market stats in memory
+type marketEntry struct {
+	player models.Player
+	price  float64
+}
+
+// Key functions market stats are grouped by
+var marketGroupKeys = []func(p models.Player) string{
+	func(p models.Player) string { return positionKey(p.Position) },
+	func(p models.Player) string { return AgeBandOf(p.Age) },
+	func(p models.Player) string { return p.Country },
+}
+
+// Compute the stats of the values of some entries grouped by a key function
+func groupPriceStats(entries []marketEntry, key func(p models.Player) string) map[string]PriceStats {
+	values := make(map[string][]float64)
+	for _, e := range entries {
+		k := key(e.player)
+		values[k] = append(values[k], e.price)
+	}
+	stats := make(map[string]PriceStats)
+	for k, v := range values {
+		stats[k] = priceStatsOf(v)
+	}
+	return stats
+}
+
+// Get the values of some entries
+func entryPrices(entries []marketEntry) []float64 {
+	values := make([]float64, 0)
+	for _, e := range entries {
+		values = append(values, e.price)
+	}
+	return values
+}
+
+// Format a position as the key it is grouped by
+func positionKey(position int) string {
+	if name, ok := models.PositionNames[position]; ok {
+		return name
+	}
+	return strconv.Itoa(position)
+}
+
+// SQL expression with the key a player is grouped by on its position
+func positionKeySQL() string {
+	positions := make([]int, 0)
+	for position := range models.PositionNames {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	sql := "CASE players.position"
+	for _, position := range positions {
+		sql += fmt.Sprintf(" WHEN %d THEN '%s'", position, models.PositionNames[position])
+	}
+	return sql + " ELSE CAST(players.position AS TEXT) END"
+}
+
+// SQL expression with the key a player is grouped by on its age
+func ageBandKeySQL() string {
+	sql := "CASE"
+	for _, b := range AgeBands {
+		sql += fmt.Sprintf(" WHEN players.age BETWEEN %d AND %d THEN '%s'", b.Min, b.Max, b.Label)
+	}
+	return sql + " END"
+}
+
+// Compute the stats of a value over the rows of a query grouped by a key, both SQL expressions.
+// Without a key every row is aggregated under the empty key.
+func aggregatePrices(db *gorm.DB, key, value string) map[string]PriceStats {
+	var rows []struct {
+		Key     string
+		Count   int
+		Average float64
+		Median  float64
+	}
+	columns := "COUNT(*) AS count, COALESCE(AVG(" + value + "), 0) AS average, " +
+		"COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY " + value + "), 0) AS median"
+	if key == "" {
+		db.Select("'' AS key, " + columns).Scan(&rows)
+	} else {
+		db.Select(key + " AS key, " + columns).Group("key").Scan(&rows)
+	}
+
+	stats := make(map[string]PriceStats)
+	for _, r := range rows {
+		if r.Count > 0 {
+			stats[r.Key] = PriceStats{Count: r.Count, Average: r.Average, Median: r.Median}
+		}
+	}
+	return stats
+}
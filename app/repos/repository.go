@@ -5,16 +5,23 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 )
 import "../models"
 
+// Players within this many years of age are considered comparable when valuing a sale
+const comparableAgeRange = 2
+
 // Repository pattern to handle abstraction of the data source
 type Repository interface {
 	CreateUser(email string, hash []byte, permission int) (models.User, error)
+	CreateBotTeam() (models.Team, error)
 	GetUserByEmail(email string) (models.User, error)
 	GetUserById(id uint) (models.User, error)
 	GetTeam(id uint) (models.Team, error)
+	GetBotTeams() []models.Team
 	GetPlayer(playerId uint) (models.Player, error)
 	GetPlayers(teamId uint) []models.Player
 	GetUserTeam(user models.User) (models.Team, error)
@@ -22,11 +29,55 @@ type Repository interface {
 	Update(model interface{}) error
 	Delete(model interface{}) error
 	GetTransfers() []models.Transfer
+	QueryTransfers(query TransferQuery) ([]models.Transfer, int)
 	GetTransfer(id uint) (models.Transfer, error)
-	RunInTransaction(code func() error) error
+	GetExpiredTransfers(now time.Time) []models.Transfer
+	GetEndedAuctions(now time.Time) []models.Transfer
+	RunInTransaction(code func(tx Repository) error) error
+	Lock(model interface{}, id uint) error
 	DeleteTeam(team *models.Team) error
 	DeletePlayer(player *models.Player) error
 	GetTransferWithPlayer(player *models.Player) (models.Transfer, error)
+	DeleteTransfer(transfer *models.Transfer) error
+	GetBids(transferId uint) []models.Bid
+	GetActiveBidsOfTeam(teamId uint) []models.Bid
+	GetOffers(transferId uint) []models.Offer
+	GetOffer(id uint) (models.Offer, error)
+	GetPlayerHistory(playerId uint) []models.CompletedTransfer
+	GetTeamHistory(teamId uint) []models.CompletedTransfer
+	GetCompletedTransfers(offset, limit int) ([]models.CompletedTransfer, int)
+	GetComparableSales(player models.Player, limit int) []models.CompletedTransfer
+	GetLoan(id uint) (models.Loan, error)
+	GetActiveLoanOfPlayer(playerId uint) (models.Loan, error)
+	GetActiveLoans(teamId uint) []models.Loan
+	GetExpiredLoans(now time.Time) []models.Loan
+	GetTransferWindows() []models.TransferWindow
+	GetTransferWindow(id uint) (models.TransferWindow, error)
+	GetSquadRules() models.SquadRules
+	GetSavedSearches(userId uint) []models.SavedSearch
+	GetAllSavedSearches() []models.SavedSearch
+	GetSavedSearch(id uint) (models.SavedSearch, error)
+	GetWatchedPlayers(userId uint) []models.WatchedPlayer
+	GetPlayerWatchers(playerId uint) []models.WatchedPlayer
+	GetWatchedPlayer(userId, playerId uint) (models.WatchedPlayer, error)
+	GetNotifications(userId uint, unreadOnly bool) []models.Notification
+	GetNotification(id uint) (models.Notification, error)
+	GetTrade(id uint) (models.Trade, error)
+	GetTradesOfTeam(teamId uint) []models.Trade
+	GetEconomicRules() models.EconomicRules
+	GetTreasuryTotals() map[string]int
+	GetMarketStats(query MarketStatsQuery) MarketStats
+	GetCompletedTransfersSince(since time.Time) []models.CompletedTransfer
+	GetFlags(status string) []models.Flag
+	GetFlag(id uint) (models.Flag, error)
+	GetFlagByKey(key string) (models.Flag, error)
+	GetAllPlayers() []models.Player
+	GetSeasons() []models.Season
+	GetSeason(number int) (models.Season, error)
+	GetFreeAgents(filters TransferFilters) []models.Player
+	GetTeams() []models.Team
+	GetAcademyPlayers(teamId uint) []models.Player
+	GetAcademyIntake(season int) (models.AcademyIntake, error)
 }
 
 // Create an user on a given repository
@@ -36,55 +87,112 @@ func doCreateUser(u Repository, email string, hash []byte, permission int) (mode
 		PasswordHash:    hash,
 		PermissionLevel: permission,
 	}
-	return user, u.RunInTransaction(func() error {
-		err := u.Create(&user)
+	return user, u.RunInTransaction(func(tx Repository) error {
+		err := tx.Create(&user)
 		if err != nil {
 			return err
 		}
 
 		team, players := models.RandomTeam()
 		team.UserID = user.ID
-		err = u.Create(&team)
+		return doCreateTeamWithPlayers(tx, &team, players)
+	})
+}
+
+// Create a random team managed by a bot on a given repository. Its owner is a user without
+// password so nobody can log in as it.
+func doCreateBotTeam(u Repository) (models.Team, error) {
+	team, players := models.RandomTeam()
+	team.IsBot = true
+	return team, u.RunInTransaction(func(tx Repository) error {
+		user := models.User{}
+		err := tx.Create(&user)
 		if err != nil {
 			return err
 		}
-
-		for i := range players {
-			players[i].TeamID = team.ID
-			err = u.Create(&players[i])
-			if err != nil {
-				return err
-			}
+		user.Email = fmt.Sprintf("bot-%v@bots.invalid", user.ID)
+		err = tx.Update(&user)
+		if err != nil {
+			return err
 		}
-		return nil
+
+		team.UserID = user.ID
+		return doCreateTeamWithPlayers(tx, &team, players)
 	})
 }
 
+// Create a team and its players on a given repository
+func doCreateTeamWithPlayers(u Repository, team *models.Team, players []models.Player) error {
+	err := u.Create(team)
+	if err != nil {
+		return err
+	}
+
+	for i := range players {
+		players[i].TeamID = team.ID
+		err = u.Create(&players[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Delete a team on a given repository
 func doDeleteTeam(u Repository, team *models.Team) error {
-	return u.RunInTransaction(func() error {
-		players := u.GetPlayers(team.ID)
+	return u.RunInTransaction(func(tx Repository) error {
+		players := append(tx.GetPlayers(team.ID), tx.GetAcademyPlayers(team.ID)...)
 		for _, p := range players {
-			err := u.DeletePlayer(&p)
+			err := tx.DeletePlayer(&p)
 			if err != nil {
 				return err
 			}
 		}
-		return u.Delete(team)
+		return tx.Delete(team)
 	})
 }
 
 // Delete a player on a given repository
 func doDeletePlayer(u Repository, player *models.Player) error {
-	return u.RunInTransaction(func() error {
-		transfer, err := u.GetTransferWithPlayer(player)
+	return u.RunInTransaction(func(tx Repository) error {
+		transfer, err := tx.GetTransferWithPlayer(player)
 		if err == nil {
 			// Transfer exists, delete it
-			if err := u.Delete(&transfer); err != nil {
+			if err := tx.DeleteTransfer(&transfer); err != nil {
+				return err
+			}
+		}
+		for _, w := range tx.GetPlayerWatchers(player.ID) {
+			if err := tx.Delete(&w); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(player)
+	})
+}
+
+// Delete a transfer and its bids on a given repository, offers still being negotiated get rejected
+func doDeleteTransfer(u Repository, transfer *models.Transfer) error {
+	return u.RunInTransaction(func(tx Repository) error {
+		bids := tx.GetBids(transfer.ID)
+		for _, b := range bids {
+			err := tx.Delete(&b)
+			if err != nil {
+				return err
+			}
+		}
+		offers := tx.GetOffers(transfer.ID)
+		for _, o := range offers {
+			if !o.IsOpen() {
+				continue
+			}
+			o.Status = models.OfferRejected
+			err := tx.Update(&o)
+			if err != nil {
 				return err
 			}
 		}
-		return u.Delete(player)
+		return tx.Delete(transfer)
 	})
 }
 
@@ -98,6 +206,11 @@ func (u RepositorySQL) CreateUser(email string, hash []byte, permission int) (mo
 	return doCreateUser(u, email, hash, permission)
 }
 
+// Create a new team managed by a bot
+func (u RepositorySQL) CreateBotTeam() (models.Team, error) {
+	return doCreateBotTeam(u)
+}
+
 // Get an user by email
 func (u RepositorySQL) GetUserByEmail(email string) (models.User, error) {
 	var user models.User
@@ -128,13 +241,34 @@ func (u RepositorySQL) GetTeam(id uint) (models.Team, error) {
 	return team, res.Error
 }
 
-// Get a players from a specific team
+// Get every team managed by a bot
+func (u RepositorySQL) GetBotTeams() []models.Team {
+	var teams []models.Team
+	u.Db.Where("is_bot = ?", true).Order("id").Find(&teams)
+	return teams
+}
+
+// Get a players from a specific team, the prospects in its academy are left out
 func (u RepositorySQL) GetPlayers(teamId uint) []models.Player {
 	var players []models.Player
-	u.Db.Preload(clause.Associations).Where(&models.Player{TeamID: teamId}).Find(&players)
+	u.Db.Preload(clause.Associations).Where("team_id = ? AND in_academy = ?", teamId, false).Find(&players)
+	return players
+}
+
+// Get the prospects in the academy of a team sorted by id
+func (u RepositorySQL) GetAcademyPlayers(teamId uint) []models.Player {
+	var players []models.Player
+	u.Db.Preload(clause.Associations).Where("team_id = ? AND in_academy = ?", teamId, true).Order("id").Find(&players)
 	return players
 }
 
+// Get every team of the league sorted by id
+func (u RepositorySQL) GetTeams() []models.Team {
+	var teams []models.Team
+	u.Db.Order("id").Find(&teams)
+	return teams
+}
+
 // Get a player by id
 func (u RepositorySQL) GetPlayer(playerId uint) (models.Player, error) {
 	var player models.Player
@@ -177,30 +311,119 @@ func (u RepositorySQL) GetUserTeam(user models.User) (models.Team, error) {
 // Get all existing transfers
 func (u RepositorySQL) GetTransfers() []models.Transfer {
 	var transfers []models.Transfer
-	u.Db.Preload("Player.Team").Where("1 = 1").Find(&transfers)
+	u.Db.Preload("Player.Team").Preload("Bids").Where("1 = 1").Find(&transfers)
+	return transfers
+}
+
+// Leave the listings expired at a time out of a query over the transfers table, nothing is left out when zero
+func whereTransferActive(db *gorm.DB, at time.Time) *gorm.DB {
+	if at.IsZero() {
+		return db
+	}
+	db = db.Where("(transfers.expires_at IS NULL OR transfers.expires_at > ?)", at)
+	return db.Where("(transfers.mode <> ? OR transfers.ends_at IS NULL OR transfers.ends_at > ?)", models.TransferModeAuction, at)
+}
+
+// Add the conditions of some filters to a query joining the players and teams tables, ask is the column with the price
+func whereTransferFilters(db *gorm.DB, f TransferFilters, ask string) *gorm.DB {
+	if f.PlayerName != "" {
+		db = db.Where("LOWER(players.first_name || ' ' || players.last_name) LIKE ?", likePattern(f.PlayerName))
+	}
+	if f.TeamName != "" {
+		db = db.Where("LOWER(teams.name) LIKE ?", likePattern(f.TeamName))
+	}
+	if f.Country != "" {
+		db = db.Where("LOWER(players.country) LIKE ?", likePattern(f.Country))
+	}
+	if f.MinOverallFilter != 0 {
+		db = db.Where("players.overall >= ?", f.MinOverallFilter)
+	}
+	if f.MaxOverallFilter != 0 {
+		db = db.Where("players.overall <= ?", f.MaxOverallFilter)
+	}
+	if !f.AvailableAt.IsZero() {
+		db = db.Where("(players.injured_until IS NULL OR players.injured_until <= ?) AND players.suspended_matches = 0", f.AvailableAt)
+	}
+	valueColumn := ask
+	if f.ValueType == "market" {
+		valueColumn = "players.market_value"
+	}
+	return db.Where(valueColumn+" BETWEEN ? AND ?", f.MinValueFilter, f.MaxValueFilter).
+		Where("players.age BETWEEN ? AND ?", f.MinAgeFilter, f.MaxAgeFilter)
+}
+
+// Get a page of the transfers that match a query and the total amount of transfers that match its filters
+func (u RepositorySQL) QueryTransfers(query TransferQuery) ([]models.Transfer, int) {
+	db := u.Db.Model(&models.Transfer{}).
+		Joins("JOIN players ON players.id = transfers.player_id").
+		Joins("LEFT JOIN teams ON teams.id = players.team_id")
+	db = whereTransferFilters(db, query.Filters, "transfers.ask")
+	db = whereTransferActive(db, query.ActiveAt)
+
+	var total int64
+	db.Count(&total)
+
+	column, direction, comparison := query.sortColumn(), "asc", ">"
+	if query.Descending {
+		direction, comparison = "desc", "<"
+	}
+	if query.After != nil {
+		var value interface{} = query.After.Value
+		if query.Sort == SortByCreatedAt || query.Sort == "" {
+			value = time.Unix(0, query.After.Value)
+		}
+		db = db.Where("("+column+" "+comparison+" ?) OR ("+column+" = ? AND transfers.id "+comparison+" ?)", value, value, query.After.ID)
+	}
+	db = db.Order(column + " " + direction).Order("transfers.id " + direction)
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var transfers []models.Transfer
+	db.Select("transfers.*").Preload("Player.Team").Preload("Bids").Find(&transfers)
+	return transfers, int(total)
+}
+
+// Get a case insensitive LIKE pattern that matches strings containing a value
+func likePattern(value string) string {
+	value = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(strings.ToLower(value))
+	return "%" + value + "%"
+}
+
+// Get the listings expired at a time
+func (u RepositorySQL) GetExpiredTransfers(now time.Time) []models.Transfer {
+	var transfers []models.Transfer
+	u.Db.Preload("Player.Team").Where("expires_at <= ?", now).Order("id").Find(&transfers)
+	return transfers
+}
+
+// Get the auctions that stopped taking bids at a time
+func (u RepositorySQL) GetEndedAuctions(now time.Time) []models.Transfer {
+	var transfers []models.Transfer
+	u.Db.Preload("Player.Team").Where("mode = ? AND ends_at <= ?", models.TransferModeAuction, now).Order("id").Find(&transfers)
 	return transfers
 }
 
 // Get a transfer by id
 func (u RepositorySQL) GetTransfer(id uint) (models.Transfer, error) {
 	var transfer models.Transfer
-	res := u.Db.Preload("Player.Team").Find(&transfer, id)
+	res := u.Db.Preload("Player.Team").Preload("Bids").Find(&transfer, id)
 	if res.Error == nil && transfer.CreatedAt == (time.Time{}) {
 		return transfer, fmt.Errorf("record not found")
 	}
 	return transfer, res.Error
 }
 
-// Run the function inside a transaction and rollback in case of error
-func (u RepositorySQL) RunInTransaction(code func() error) error {
-	tx := u.Db.Begin()
-	err := code()
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	tx.Commit()
-	return nil
+// Run the function inside a transaction with a repository bound to it and rollback in case of error
+func (u RepositorySQL) RunInTransaction(code func(tx Repository) error) error {
+	return u.Db.Transaction(func(tx *gorm.DB) error {
+		return code(RepositorySQL{Db: tx})
+	})
+}
+
+// Load a model by id and lock its row with SELECT ... FOR UPDATE until the transaction ends
+func (u RepositorySQL) Lock(model interface{}, id uint) error {
+	return u.Db.Clauses(clause.Locking{Strength: "UPDATE"}).First(model, id).Error
 }
 
 // Delete a given team
@@ -223,9 +446,379 @@ func (u RepositorySQL) GetTransferWithPlayer(player *models.Player) (models.Tran
 	return transfer, res.Error
 }
 
+// Delete a given transfer
+func (u RepositorySQL) DeleteTransfer(transfer *models.Transfer) error {
+	return doDeleteTransfer(u, transfer)
+}
+
+// Get the bids of a transfer sorted from highest to lowest
+func (u RepositorySQL) GetBids(transferId uint) []models.Bid {
+	var bids []models.Bid
+	u.Db.Where(&models.Bid{TransferID: transferId}).Order("amount desc").Find(&bids)
+	return bids
+}
+
+// Get the bids of a team that are still holding funds
+func (u RepositorySQL) GetActiveBidsOfTeam(teamId uint) []models.Bid {
+	var bids []models.Bid
+	u.Db.Where("team_id = ? AND active = ?", teamId, true).Find(&bids)
+	return bids
+}
+
+// Get the offers made on a transfer
+func (u RepositorySQL) GetOffers(transferId uint) []models.Offer {
+	var offers []models.Offer
+	u.Db.Preload("Team").Where(&models.Offer{TransferID: transferId}).Order("created_at").Find(&offers)
+	return offers
+}
+
+// Get an offer by id
+func (u RepositorySQL) GetOffer(id uint) (models.Offer, error) {
+	var offer models.Offer
+	res := u.Db.Preload("Team").Find(&offer, id)
+	if res.Error == nil && offer.CreatedAt == (time.Time{}) {
+		return offer, fmt.Errorf("record not found")
+	}
+	return offer, res.Error
+}
+
+// Get the completed sales of a player, most recent first
+func (u RepositorySQL) GetPlayerHistory(playerId uint) []models.CompletedTransfer {
+	var history []models.CompletedTransfer
+	u.Db.Preload("LineItems").Where(&models.CompletedTransfer{PlayerID: playerId}).Order("created_at desc").Find(&history)
+	return history
+}
+
+// Get the completed sales a team took part of as seller or buyer, most recent first
+func (u RepositorySQL) GetTeamHistory(teamId uint) []models.CompletedTransfer {
+	var history []models.CompletedTransfer
+	u.Db.Preload("LineItems").Where("seller_team_id = ? OR buyer_team_id = ?", teamId, teamId).Order("created_at desc").Find(&history)
+	return history
+}
+
+// Get the most recent sales of other players with the same position and a similar age
+func (u RepositorySQL) GetComparableSales(player models.Player, limit int) []models.CompletedTransfer {
+	var sales []models.CompletedTransfer
+	u.Db.Joins("JOIN players ON players.id = completed_transfers.player_id").
+		Where("players.id <> ? AND players.position = ? AND players.age BETWEEN ? AND ?",
+			player.ID, player.Position, player.Age-comparableAgeRange, player.Age+comparableAgeRange).
+		Order("completed_transfers.created_at desc").Limit(limit).
+		Select("completed_transfers.*").Find(&sales)
+	return sales
+}
+
+// Get a page of all the completed sales, most recent first, and the total amount of them
+func (u RepositorySQL) GetCompletedTransfers(offset, limit int) ([]models.CompletedTransfer, int) {
+	var history []models.CompletedTransfer
+	var total int64
+	u.Db.Model(&models.CompletedTransfer{}).Count(&total)
+	u.Db.Preload("LineItems").Order("created_at desc").Offset(offset).Limit(limit).Find(&history)
+	return history, int(total)
+}
+
+// Get a loan by id
+func (u RepositorySQL) GetLoan(id uint) (models.Loan, error) {
+	var loan models.Loan
+	res := u.Db.Preload("Player.Team").Find(&loan, id)
+	if res.Error == nil && loan.CreatedAt == (time.Time{}) {
+		return loan, fmt.Errorf("record not found")
+	}
+	return loan, res.Error
+}
+
+// Get the loan a player is currently on
+func (u RepositorySQL) GetActiveLoanOfPlayer(playerId uint) (models.Loan, error) {
+	var loan models.Loan
+	res := u.Db.Where(&models.Loan{PlayerID: playerId, Status: models.LoanActive}).Find(&loan)
+	if res.Error == nil && loan.CreatedAt == (time.Time{}) {
+		return loan, fmt.Errorf("record not found")
+	}
+	return loan, res.Error
+}
+
+// Get the active loans where a team is the parent or the borrower
+func (u RepositorySQL) GetActiveLoans(teamId uint) []models.Loan {
+	var loans []models.Loan
+	u.Db.Preload("Player").Where("status = ? AND (parent_team_id = ? OR borrower_team_id = ?)", models.LoanActive, teamId, teamId).Find(&loans)
+	return loans
+}
+
+// Get the active loans whose end time already passed
+func (u RepositorySQL) GetExpiredLoans(now time.Time) []models.Loan {
+	var loans []models.Loan
+	u.Db.Preload("Player").Where("status = ? AND ends_at <= ?", models.LoanActive, now).Find(&loans)
+	return loans
+}
+
+// Get every transfer window sorted by opening time
+func (u RepositorySQL) GetTransferWindows() []models.TransferWindow {
+	var windows []models.TransferWindow
+	u.Db.Order("opens_at").Find(&windows)
+	return windows
+}
+
+// Get a transfer window by id
+func (u RepositorySQL) GetTransferWindow(id uint) (models.TransferWindow, error) {
+	var window models.TransferWindow
+	res := u.Db.Find(&window, id)
+	if res.Error == nil && window.CreatedAt == (time.Time{}) {
+		return window, fmt.Errorf("record not found")
+	}
+	return window, res.Error
+}
+
+// Get the squad rules of the league, rules without limits are returned if none were saved
+func (u RepositorySQL) GetSquadRules() models.SquadRules {
+	var rules models.SquadRules
+	u.Db.Order("id").Limit(1).Find(&rules)
+	return rules
+}
+
+// Get the saved searches of a user
+func (u RepositorySQL) GetSavedSearches(userId uint) []models.SavedSearch {
+	var searches []models.SavedSearch
+	u.Db.Where(&models.SavedSearch{UserID: userId}).Order("id").Find(&searches)
+	return searches
+}
+
+// Get the saved searches of every user
+func (u RepositorySQL) GetAllSavedSearches() []models.SavedSearch {
+	var searches []models.SavedSearch
+	u.Db.Order("id").Find(&searches)
+	return searches
+}
+
+// Get a saved search by id
+func (u RepositorySQL) GetSavedSearch(id uint) (models.SavedSearch, error) {
+	var search models.SavedSearch
+	res := u.Db.Find(&search, id)
+	if res.Error == nil && search.CreatedAt == (time.Time{}) {
+		return search, fmt.Errorf("record not found")
+	}
+	return search, res.Error
+}
+
+// Get the players a user watches
+func (u RepositorySQL) GetWatchedPlayers(userId uint) []models.WatchedPlayer {
+	var watched []models.WatchedPlayer
+	u.Db.Where(&models.WatchedPlayer{UserID: userId}).Order("id").Find(&watched)
+	return watched
+}
+
+// Get the users watching a player
+func (u RepositorySQL) GetPlayerWatchers(playerId uint) []models.WatchedPlayer {
+	var watchers []models.WatchedPlayer
+	u.Db.Where(&models.WatchedPlayer{PlayerID: playerId}).Find(&watchers)
+	return watchers
+}
+
+// Get the watch entry of a user for a player
+func (u RepositorySQL) GetWatchedPlayer(userId, playerId uint) (models.WatchedPlayer, error) {
+	var watched models.WatchedPlayer
+	res := u.Db.Where(&models.WatchedPlayer{UserID: userId, PlayerID: playerId}).Find(&watched)
+	if res.Error == nil && watched.CreatedAt == (time.Time{}) {
+		return watched, fmt.Errorf("record not found")
+	}
+	return watched, res.Error
+}
+
+// Get the notifications of a user, most recent first
+func (u RepositorySQL) GetNotifications(userId uint, unreadOnly bool) []models.Notification {
+	var notifications []models.Notification
+	db := u.Db.Where(&models.Notification{UserID: userId})
+	if unreadOnly {
+		db = db.Where("read = ?", false)
+	}
+	db.Order("created_at desc").Order("id desc").Find(&notifications)
+	return notifications
+}
+
+// Get a notification by id
+func (u RepositorySQL) GetNotification(id uint) (models.Notification, error) {
+	var notification models.Notification
+	res := u.Db.Find(&notification, id)
+	if res.Error == nil && notification.CreatedAt == (time.Time{}) {
+		return notification, fmt.Errorf("record not found")
+	}
+	return notification, res.Error
+}
+
+// Get a trade by id with its players
+func (u RepositorySQL) GetTrade(id uint) (models.Trade, error) {
+	var trade models.Trade
+	res := u.Db.Preload("Players").Find(&trade, id)
+	if res.Error == nil && trade.CreatedAt == (time.Time{}) {
+		return trade, fmt.Errorf("record not found")
+	}
+	return trade, res.Error
+}
+
+// Get the trades a team proposed or received, most recent first
+func (u RepositorySQL) GetTradesOfTeam(teamId uint) []models.Trade {
+	var trades []models.Trade
+	u.Db.Preload("Players").Where("proposer_team_id = ? OR receiver_team_id = ?", teamId, teamId).
+		Order("created_at desc").Find(&trades)
+	return trades
+}
+
+// Get the economic rules of the league, the zero value when they were never set
+func (u RepositorySQL) GetEconomicRules() models.EconomicRules {
+	var rules models.EconomicRules
+	u.Db.Order("id").Limit(1).Find(&rules)
+	return rules
+}
+
+// Get the money collected by the league treasury by kind of entry
+func (u RepositorySQL) GetTreasuryTotals() map[string]int {
+	var rows []struct {
+		Kind  string
+		Total int
+	}
+	u.Db.Model(&models.TreasuryEntry{}).Select("kind, SUM(amount) AS total").Group("kind").Scan(&rows)
+	totals := make(map[string]int)
+	for _, r := range rows {
+		totals[r.Kind] = r.Total
+	}
+	return totals
+}
+
+// Get aggregated numbers over the open listings and the sales that match a query
+func (u RepositorySQL) GetMarketStats(query MarketStatsQuery) MarketStats {
+	listings := func() *gorm.DB {
+		db := u.Db.Model(&models.Transfer{}).
+			Joins("JOIN players ON players.id = transfers.player_id").
+			Joins("LEFT JOIN teams ON teams.id = players.team_id")
+		db = whereTransferFilters(db, query.Filters, "transfers.ask")
+		return whereTransferActive(db, query.ActiveAt)
+	}
+	sales := func() *gorm.DB {
+		db := u.Db.Model(&models.CompletedTransfer{}).
+			Joins("JOIN players ON players.id = completed_transfers.player_id").
+			Joins("LEFT JOIN teams ON teams.id = completed_transfers.seller_team_id").
+			Where("completed_transfers.created_at >= ?", query.Since)
+		return whereTransferFilters(db, query.Filters, "completed_transfers.price")
+	}
+
+	stats := MarketStats{
+		Asks:  aggregatePrices(listings(), "", "transfers.ask")[""],
+		Sales: aggregatePrices(sales(), "", "completed_transfers.price")[""],
+		HoursToSale: aggregatePrices(sales().Where("completed_transfers.listed_at IS NOT NULL"), "",
+			"EXTRACT(EPOCH FROM completed_transfers.created_at - completed_transfers.listed_at) / 3600")[""],
+	}
+	stats.SellThroughRate = sellThroughRate(stats.Sales.Count, stats.Asks.Count)
+
+	groups := make([][]MarketGroupStats, 0)
+	for _, key := range []string{positionKeySQL(), ageBandKeySQL(), "players.country"} {
+		groups = append(groups, mergeGroupStats(
+			aggregatePrices(listings(), key, "transfers.ask"),
+			aggregatePrices(sales(), key, "completed_transfers.price"),
+		))
+	}
+	stats.ByPosition, stats.ByAgeBand, stats.ByCountry = groups[0], groups[1], groups[2]
+
+	var days []struct {
+		Day        time.Time
+		PriceIndex float64
+		Sales      int
+	}
+	sales().Where("completed_transfers.value_before > 0").
+		Select("date_trunc('day', completed_transfers.created_at AT TIME ZONE 'UTC') AS day, " +
+			"AVG(completed_transfers.price * 100.0 / completed_transfers.value_before) AS price_index, COUNT(*) AS sales").
+		Group("day").Order("day").Scan(&days)
+	stats.PriceIndex = make([]PriceIndexPoint, 0)
+	for _, d := range days {
+		stats.PriceIndex = append(stats.PriceIndex, PriceIndexPoint{Day: d.Day, Index: d.PriceIndex, Sales: d.Sales})
+	}
+	return stats
+}
+
+// Get the completed sales made since a time, most recent first
+func (u RepositorySQL) GetCompletedTransfersSince(since time.Time) []models.CompletedTransfer {
+	var history []models.CompletedTransfer
+	u.Db.Where("created_at >= ?", since).Order("created_at desc").Order("id desc").Find(&history)
+	return history
+}
+
+// Get the flags with a status, every flag when it is empty, most recent first
+func (u RepositorySQL) GetFlags(status string) []models.Flag {
+	var flags []models.Flag
+	db := u.Db
+	if status != "" {
+		db = db.Where(&models.Flag{Status: status})
+	}
+	db.Order("created_at desc").Order("id desc").Find(&flags)
+	return flags
+}
+
+// Get a flag by id
+func (u RepositorySQL) GetFlag(id uint) (models.Flag, error) {
+	var flag models.Flag
+	res := u.Db.Find(&flag, id)
+	if res.Error == nil && flag.CreatedAt == (time.Time{}) {
+		return flag, fmt.Errorf("record not found")
+	}
+	return flag, res.Error
+}
+
+// Get the flag raised for a finding
+func (u RepositorySQL) GetFlagByKey(key string) (models.Flag, error) {
+	var flag models.Flag
+	res := u.Db.Where(&models.Flag{Key: key}).Find(&flag)
+	if res.Error == nil && flag.CreatedAt == (time.Time{}) {
+		return flag, fmt.Errorf("record not found")
+	}
+	return flag, res.Error
+}
+
+// Get every player of the league sorted by id
+func (u RepositorySQL) GetAllPlayers() []models.Player {
+	var players []models.Player
+	u.Db.Order("id").Find(&players)
+	return players
+}
+
+// Get the players in the free agent pool that match some filters sorted by id, the signing fee is their ask
+func (u RepositorySQL) GetFreeAgents(filters TransferFilters) []models.Player {
+	var players []models.Player
+	db := u.Db.Model(&models.Player{}).
+		Joins("LEFT JOIN teams ON teams.id = players.team_id").
+		Where("players.team_id = 0")
+	db = whereTransferFilters(db, filters, fmt.Sprintf("players.market_value / %d", models.SigningFeeDivisor))
+	db.Order("players.id").Find(&players)
+	return players
+}
+
+// Get the rolled over seasons from newest to oldest
+func (u RepositorySQL) GetSeasons() []models.Season {
+	var seasons []models.Season
+	u.Db.Order("number desc").Find(&seasons)
+	return seasons
+}
+
+// Get a rolled over season by its number
+func (u RepositorySQL) GetSeason(number int) (models.Season, error) {
+	var season models.Season
+	res := u.Db.Where(&models.Season{Number: number}).Find(&season)
+	if res.Error == nil && season.CreatedAt == (time.Time{}) {
+		return season, fmt.Errorf("record not found")
+	}
+	return season, res.Error
+}
+
+// Get the youth intake of a season
+func (u RepositorySQL) GetAcademyIntake(season int) (models.AcademyIntake, error) {
+	var intake models.AcademyIntake
+	res := u.Db.Where(&models.AcademyIntake{Season: season}).Find(&intake)
+	if res.Error == nil && intake.CreatedAt == (time.Time{}) {
+		return intake, fmt.Errorf("record not found")
+	}
+	return intake, res.Error
+}
+
 // Repository implementation with models on memory
 type RepositoryMemory struct {
 	Models []interface{}
+	lastId uint
 }
 
 // Create a new memory repository
@@ -240,6 +833,11 @@ func (u *RepositoryMemory) CreateUser(email string, hash []byte, permission int)
 	return doCreateUser(u, email, hash, permission)
 }
 
+// Create a new team managed by a bot
+func (u *RepositoryMemory) CreateBotTeam() (models.Team, error) {
+	return doCreateBotTeam(u)
+}
+
 // Get user by email
 func (u *RepositoryMemory) GetUserByEmail(email string) (models.User, error) {
 	var t models.User
@@ -264,6 +862,15 @@ func (u *RepositoryMemory) GetTeam(id uint) (models.Team, error) {
 	return m, err
 }
 
+// Get every team managed by a bot
+func (u *RepositoryMemory) GetBotTeams() []models.Team {
+	teams := make([]models.Team, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.Team).IsBot
+	}, &teams)
+	return teams
+}
+
 // Get player by id
 func (u *RepositoryMemory) GetPlayer(playerId uint) (models.Player, error) {
 	var m models.Player
@@ -271,16 +878,39 @@ func (u *RepositoryMemory) GetPlayer(playerId uint) (models.Player, error) {
 	return m, err
 }
 
-// Get the players of a team
+// Get the players of a team, the prospects in its academy are left out
 func (u *RepositoryMemory) GetPlayers(teamId uint) []models.Player {
 	ps := make([]models.Player, 0)
 	u.getAllByFuncOfType(func(m interface{}) bool {
 		p := m.(models.Player)
-		return p.TeamID == teamId
-	}, ps)
+		return p.TeamID == teamId && !p.InAcademy
+	}, &ps)
+	return ps
+}
+
+// Get the prospects in the academy of a team sorted by id
+func (u *RepositoryMemory) GetAcademyPlayers(teamId uint) []models.Player {
+	ps := make([]models.Player, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		p := m.(models.Player)
+		return p.TeamID == teamId && p.InAcademy
+	}, &ps)
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].ID < ps[j].ID
+	})
 	return ps
 }
 
+// Get every team of the league sorted by id
+func (u *RepositoryMemory) GetTeams() []models.Team {
+	teams := make([]models.Team, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &teams)
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].ID < teams[j].ID
+	})
+	return teams
+}
+
 // Get the team of a user
 func (u *RepositoryMemory) GetUserTeam(user models.User) (models.Team, error) {
 	var t models.Team
@@ -291,11 +921,18 @@ func (u *RepositoryMemory) GetUserTeam(user models.User) (models.Team, error) {
 	return t, err
 }
 
-// Add a new model
+// Add a new model, assigning it an id and a creation date like the database would
 func (u *RepositoryMemory) Create(model interface{}) error {
 	var m interface{}
-	if reflect.ValueOf(model).Kind() == reflect.Ptr {
-		m = reflect.ValueOf(model).Elem().Interface()
+	if rv := reflect.ValueOf(model); rv.Kind() == reflect.Ptr {
+		if id := rv.Elem().FieldByName("ID"); id.IsValid() && id.Uint() == 0 {
+			u.lastId += 1
+			id.SetUint(uint64(u.lastId))
+		}
+		if createdAt := rv.Elem().FieldByName("CreatedAt"); createdAt.IsValid() && createdAt.Interface().(time.Time).IsZero() {
+			createdAt.Set(reflect.ValueOf(time.Now()))
+		}
+		m = rv.Elem().Interface()
 	} else {
 		m = model
 	}
@@ -303,23 +940,97 @@ func (u *RepositoryMemory) Create(model interface{}) error {
 	return nil
 }
 
-// Update a model
+// Update a model, replacing the stored model of the same type and id
 func (u *RepositoryMemory) Update(model interface{}) error {
-	panic("update not implemented")
+	i := u.indexOf(model)
+	if i == -1 {
+		return u.Create(model)
+	}
+	u.Models[i] = reflect.Indirect(reflect.ValueOf(model)).Interface()
+	return nil
 }
 
 // Delete a model
 func (u *RepositoryMemory) Delete(model interface{}) error {
-	panic("delete not implemented")
+	i := u.indexOf(model)
+	if i == -1 {
+		return fmt.Errorf("not found")
+	}
+	u.Models = append(u.Models[:i], u.Models[i+1:]...)
+	return nil
+}
+
+// Get the position of the stored model with the same type and id, -1 if there is none
+func (u *RepositoryMemory) indexOf(model interface{}) int {
+	rv := reflect.Indirect(reflect.ValueOf(model))
+	for i, m := range u.Models {
+		if reflect.TypeOf(m) == rv.Type() && modelId(m) == modelId(rv.Interface()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get the id of a model
+func modelId(m interface{}) uint {
+	id := reflect.ValueOf(m).FieldByName("ID")
+	if !id.IsValid() {
+		return 0
+	}
+	return uint(id.Uint())
 }
 
 // Get all transfers
 func (u *RepositoryMemory) GetTransfers() []models.Transfer {
 	a := make([]models.Transfer, 0)
-	u.getAllByFuncOfType(func(m interface{}) bool { return true }, a)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &a)
 	return a
 }
 
+// Get a page of the transfers that match a query and the total amount of transfers that match its filters
+func (u *RepositoryMemory) QueryTransfers(query TransferQuery) ([]models.Transfer, int) {
+	matches := make([]models.Transfer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		t := m.(models.Transfer)
+		return query.Filters.Matches(t) && isActiveAt(t, query.ActiveAt)
+	}, &matches)
+	total := len(matches)
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return query.less(query.CursorOf(matches[i]), query.CursorOf(matches[j]))
+	})
+	page := make([]models.Transfer, 0)
+	for _, t := range matches {
+		if query.After != nil && !query.less(*query.After, query.CursorOf(t)) {
+			continue
+		}
+		if query.Limit > 0 && len(page) == query.Limit {
+			break
+		}
+		page = append(page, t)
+	}
+	return page, total
+}
+
+// Get the listings expired at a time
+func (u *RepositoryMemory) GetExpiredTransfers(now time.Time) []models.Transfer {
+	transfers := make([]models.Transfer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.Transfer).IsExpired(now)
+	}, &transfers)
+	return transfers
+}
+
+// Get the auctions that stopped taking bids at a time
+func (u *RepositoryMemory) GetEndedAuctions(now time.Time) []models.Transfer {
+	transfers := make([]models.Transfer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		t := m.(models.Transfer)
+		return t.IsAuction() && t.HasEnded(now)
+	}, &transfers)
+	return transfers
+}
+
 // Get transfer by id
 func (u *RepositoryMemory) GetTransfer(id uint) (models.Transfer, error) {
 	var m models.Transfer
@@ -328,8 +1039,13 @@ func (u *RepositoryMemory) GetTransfer(id uint) (models.Transfer, error) {
 }
 
 // Run code in a transaction (dummy)
-func (u *RepositoryMemory) RunInTransaction(code func() error) error {
-	return code()
+func (u *RepositoryMemory) RunInTransaction(code func(tx Repository) error) error {
+	return code(u)
+}
+
+// Load a model by id, there is nothing to lock in memory
+func (u *RepositoryMemory) Lock(model interface{}, id uint) error {
+	return u.getByIdOfType(id, model)
 }
 
 // Delete a team
@@ -352,11 +1068,452 @@ func (u *RepositoryMemory) GetTransferWithPlayer(player *models.Player) (models.
 	return t, err
 }
 
+// Delete a transfer
+func (u *RepositoryMemory) DeleteTransfer(transfer *models.Transfer) error {
+	return doDeleteTransfer(u, transfer)
+}
+
+// Get the bids of a transfer sorted from highest to lowest
+func (u *RepositoryMemory) GetBids(transferId uint) []models.Bid {
+	bids := make([]models.Bid, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.Bid).TransferID == transferId
+	}, &bids)
+	sort.Slice(bids, func(i, j int) bool {
+		return bids[i].Amount > bids[j].Amount
+	})
+	return bids
+}
+
+// Get the bids of a team that are still holding funds
+func (u *RepositoryMemory) GetActiveBidsOfTeam(teamId uint) []models.Bid {
+	bids := make([]models.Bid, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		b := m.(models.Bid)
+		return b.TeamID == teamId && b.Active
+	}, &bids)
+	return bids
+}
+
+// Get the offers made on a transfer
+func (u *RepositoryMemory) GetOffers(transferId uint) []models.Offer {
+	offers := make([]models.Offer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.Offer).TransferID == transferId
+	}, &offers)
+	return offers
+}
+
+// Get an offer by id
+func (u *RepositoryMemory) GetOffer(id uint) (models.Offer, error) {
+	var m models.Offer
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get the completed sales of a player, most recent first
+func (u *RepositoryMemory) GetPlayerHistory(playerId uint) []models.CompletedTransfer {
+	return u.getCompletedTransfers(func(t models.CompletedTransfer) bool {
+		return t.PlayerID == playerId
+	})
+}
+
+// Get the completed sales a team took part of as seller or buyer, most recent first
+func (u *RepositoryMemory) GetTeamHistory(teamId uint) []models.CompletedTransfer {
+	return u.getCompletedTransfers(func(t models.CompletedTransfer) bool {
+		return t.SellerTeamID == teamId || t.BuyerTeamID == teamId
+	})
+}
+
+// Get the most recent sales of other players with the same position and a similar age
+func (u *RepositoryMemory) GetComparableSales(player models.Player, limit int) []models.CompletedTransfer {
+	sales := u.getCompletedTransfers(func(t models.CompletedTransfer) bool {
+		if t.PlayerID == player.ID {
+			return false
+		}
+		p, err := u.GetPlayer(t.PlayerID)
+		return err == nil && p.Position == player.Position &&
+			p.Age >= player.Age-comparableAgeRange && p.Age <= player.Age+comparableAgeRange
+	})
+	if len(sales) > limit {
+		sales = sales[:limit]
+	}
+	return sales
+}
+
+// Get a page of all the completed sales, most recent first, and the total amount of them
+func (u *RepositoryMemory) GetCompletedTransfers(offset, limit int) ([]models.CompletedTransfer, int) {
+	history := u.getCompletedTransfers(func(t models.CompletedTransfer) bool { return true })
+	total := len(history)
+	if offset > total {
+		offset = total
+	}
+	if offset+limit < total {
+		return history[offset : offset+limit], total
+	}
+	return history[offset:], total
+}
+
+// Get the completed sales that match a function, most recent first
+func (u *RepositoryMemory) getCompletedTransfers(f func(t models.CompletedTransfer) bool) []models.CompletedTransfer {
+	history := make([]models.CompletedTransfer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return f(m.(models.CompletedTransfer))
+	}, &history)
+	// Models are stored in insertion order, reverse them so ties keep the newest first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+	for i := range history {
+		items := make([]models.SaleLineItem, 0)
+		u.getAllByFuncOfType(func(m interface{}) bool {
+			return m.(models.SaleLineItem).CompletedTransferID == history[i].ID
+		}, &items)
+		history[i].LineItems = items
+	}
+	return history
+}
+
+// Get a loan by id
+func (u *RepositoryMemory) GetLoan(id uint) (models.Loan, error) {
+	var m models.Loan
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get the loan a player is currently on
+func (u *RepositoryMemory) GetActiveLoanOfPlayer(playerId uint) (models.Loan, error) {
+	var l models.Loan
+	err := u.getByFuncOfType(func(m interface{}) bool {
+		loan := m.(models.Loan)
+		return loan.PlayerID == playerId && loan.Status == models.LoanActive
+	}, &l)
+	return l, err
+}
+
+// Get the active loans where a team is the parent or the borrower
+func (u *RepositoryMemory) GetActiveLoans(teamId uint) []models.Loan {
+	loans := make([]models.Loan, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		loan := m.(models.Loan)
+		return loan.Status == models.LoanActive && (loan.ParentTeamID == teamId || loan.BorrowerTeamID == teamId)
+	}, &loans)
+	return loans
+}
+
+// Get the active loans whose end time already passed
+func (u *RepositoryMemory) GetExpiredLoans(now time.Time) []models.Loan {
+	loans := make([]models.Loan, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		loan := m.(models.Loan)
+		return loan.Status == models.LoanActive && !loan.EndsAt.After(now)
+	}, &loans)
+	return loans
+}
+
+// Get every transfer window sorted by opening time
+func (u *RepositoryMemory) GetTransferWindows() []models.TransferWindow {
+	windows := make([]models.TransferWindow, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &windows)
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].OpensAt.Before(windows[j].OpensAt)
+	})
+	return windows
+}
+
+// Get a transfer window by id
+func (u *RepositoryMemory) GetTransferWindow(id uint) (models.TransferWindow, error) {
+	var m models.TransferWindow
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get the squad rules of the league, rules without limits are returned if none were saved
+func (u *RepositoryMemory) GetSquadRules() models.SquadRules {
+	var rules models.SquadRules
+	_ = u.getByFuncOfType(func(m interface{}) bool { return true }, &rules)
+	return rules
+}
+
+// Get the saved searches of a user
+func (u *RepositoryMemory) GetSavedSearches(userId uint) []models.SavedSearch {
+	searches := make([]models.SavedSearch, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.SavedSearch).UserID == userId
+	}, &searches)
+	return searches
+}
+
+// Get the saved searches of every user
+func (u *RepositoryMemory) GetAllSavedSearches() []models.SavedSearch {
+	searches := make([]models.SavedSearch, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &searches)
+	return searches
+}
+
+// Get a saved search by id
+func (u *RepositoryMemory) GetSavedSearch(id uint) (models.SavedSearch, error) {
+	var m models.SavedSearch
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get the players a user watches
+func (u *RepositoryMemory) GetWatchedPlayers(userId uint) []models.WatchedPlayer {
+	watched := make([]models.WatchedPlayer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.WatchedPlayer).UserID == userId
+	}, &watched)
+	return watched
+}
+
+// Get the users watching a player
+func (u *RepositoryMemory) GetPlayerWatchers(playerId uint) []models.WatchedPlayer {
+	watchers := make([]models.WatchedPlayer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.WatchedPlayer).PlayerID == playerId
+	}, &watchers)
+	return watchers
+}
+
+// Get the watch entry of a user for a player
+func (u *RepositoryMemory) GetWatchedPlayer(userId, playerId uint) (models.WatchedPlayer, error) {
+	var t models.WatchedPlayer
+	err := u.getByFuncOfType(func(m interface{}) bool {
+		w := m.(models.WatchedPlayer)
+		return w.UserID == userId && w.PlayerID == playerId
+	}, &t)
+	return t, err
+}
+
+// Get the notifications of a user, most recent first
+func (u *RepositoryMemory) GetNotifications(userId uint, unreadOnly bool) []models.Notification {
+	notifications := make([]models.Notification, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		n := m.(models.Notification)
+		return n.UserID == userId && (!unreadOnly || !n.Read)
+	}, &notifications)
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].ID > notifications[j].ID
+	})
+	return notifications
+}
+
+// Get a notification by id
+func (u *RepositoryMemory) GetNotification(id uint) (models.Notification, error) {
+	var m models.Notification
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get a trade by id with its players
+func (u *RepositoryMemory) GetTrade(id uint) (models.Trade, error) {
+	var m models.Trade
+	err := u.getByIdOfType(id, &m)
+	if err == nil {
+		m.Players = u.getTradePlayers(m.ID)
+	}
+	return m, err
+}
+
+// Get the trades a team proposed or received, most recent first
+func (u *RepositoryMemory) GetTradesOfTeam(teamId uint) []models.Trade {
+	trades := make([]models.Trade, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		t := m.(models.Trade)
+		return t.ProposerTeamID == teamId || t.ReceiverTeamID == teamId
+	}, &trades)
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].ID > trades[j].ID
+	})
+	for i := range trades {
+		trades[i].Players = u.getTradePlayers(trades[i].ID)
+	}
+	return trades
+}
+
+// Get the players included in a trade
+func (u *RepositoryMemory) getTradePlayers(tradeId uint) []models.TradePlayer {
+	players := make([]models.TradePlayer, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return m.(models.TradePlayer).TradeID == tradeId
+	}, &players)
+	return players
+}
+
+// Get the economic rules of the league, the zero value when they were never set
+func (u *RepositoryMemory) GetEconomicRules() models.EconomicRules {
+	var rules models.EconomicRules
+	_ = u.getByFuncOfType(func(m interface{}) bool { return true }, &rules)
+	return rules
+}
+
+// Get the money collected by the league treasury by kind of entry
+func (u *RepositoryMemory) GetTreasuryTotals() map[string]int {
+	entries := make([]models.TreasuryEntry, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &entries)
+	totals := make(map[string]int)
+	for _, e := range entries {
+		totals[e.Kind] += e.Amount
+	}
+	return totals
+}
+
+// Get aggregated numbers over the open listings and the sales that match a query
+func (u *RepositoryMemory) GetMarketStats(query MarketStatsQuery) MarketStats {
+	filters := query.Filters
+	listings := make([]marketEntry, 0)
+	for _, t := range u.GetTransfers() {
+		player, err := u.GetPlayer(t.PlayerID)
+		if err != nil {
+			continue
+		}
+		player.Team, _ = u.GetTeam(player.TeamID)
+		t.Player = player
+		if filters.Matches(t) && isActiveAt(t, query.ActiveAt) {
+			listings = append(listings, marketEntry{player: player, price: float64(t.Ask)})
+		}
+	}
+
+	sales := make([]marketEntry, 0)
+	hours := make([]float64, 0)
+	days := make(map[time.Time][]float64)
+	for _, s := range u.getCompletedTransfers(func(t models.CompletedTransfer) bool {
+		return !t.CreatedAt.Before(query.Since)
+	}) {
+		player, err := u.GetPlayer(s.PlayerID)
+		if err != nil {
+			continue
+		}
+		player.Team, _ = u.GetTeam(s.SellerTeamID)
+		if !filters.Matches(models.Transfer{Player: player, Ask: s.Price}) {
+			continue
+		}
+		sales = append(sales, marketEntry{player: player, price: float64(s.Price)})
+		if s.ListedAt != nil {
+			hours = append(hours, s.CreatedAt.Sub(*s.ListedAt).Hours())
+		}
+		if s.ValueBefore > 0 {
+			created := s.CreatedAt.UTC()
+			day := time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC)
+			days[day] = append(days[day], float64(s.Price)*100/float64(s.ValueBefore))
+		}
+	}
+
+	stats := MarketStats{
+		Asks:            priceStatsOf(entryPrices(listings)),
+		Sales:           priceStatsOf(entryPrices(sales)),
+		HoursToSale:     priceStatsOf(hours),
+		SellThroughRate: sellThroughRate(len(sales), len(listings)),
+	}
+	groups := make([][]MarketGroupStats, 0)
+	for _, key := range marketGroupKeys {
+		groups = append(groups, mergeGroupStats(groupPriceStats(listings, key), groupPriceStats(sales, key)))
+	}
+	stats.ByPosition, stats.ByAgeBand, stats.ByCountry = groups[0], groups[1], groups[2]
+
+	stats.PriceIndex = make([]PriceIndexPoint, 0)
+	for day, ratios := range days {
+		index := priceStatsOf(ratios)
+		stats.PriceIndex = append(stats.PriceIndex, PriceIndexPoint{Day: day, Index: index.Average, Sales: index.Count})
+	}
+	sort.Slice(stats.PriceIndex, func(i, j int) bool { return stats.PriceIndex[i].Day.Before(stats.PriceIndex[j].Day) })
+	return stats
+}
+
+// Get the completed sales made since a time, most recent first
+func (u *RepositoryMemory) GetCompletedTransfersSince(since time.Time) []models.CompletedTransfer {
+	return u.getCompletedTransfers(func(t models.CompletedTransfer) bool {
+		return !t.CreatedAt.Before(since)
+	})
+}
+
+// Get the flags with a status, every flag when it is empty, most recent first
+func (u *RepositoryMemory) GetFlags(status string) []models.Flag {
+	flags := make([]models.Flag, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		return status == "" || m.(models.Flag).Status == status
+	}, &flags)
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].ID > flags[j].ID
+	})
+	return flags
+}
+
+// Get a flag by id
+func (u *RepositoryMemory) GetFlag(id uint) (models.Flag, error) {
+	var m models.Flag
+	err := u.getByIdOfType(id, &m)
+	return m, err
+}
+
+// Get the flag raised for a finding
+func (u *RepositoryMemory) GetFlagByKey(key string) (models.Flag, error) {
+	var f models.Flag
+	err := u.getByFuncOfType(func(m interface{}) bool {
+		return m.(models.Flag).Key == key
+	}, &f)
+	return f, err
+}
+
+// Get the players in the free agent pool that match some filters sorted by id, the signing fee is their ask
+func (u *RepositoryMemory) GetFreeAgents(filters TransferFilters) []models.Player {
+	players := make([]models.Player, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool {
+		p := m.(models.Player)
+		return p.IsFreeAgent() && filters.MatchesPlayer(p, p.SigningFee())
+	}, &players)
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
+
+// Get every player of the league sorted by id
+func (u *RepositoryMemory) GetAllPlayers() []models.Player {
+	players := make([]models.Player, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &players)
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
+
+// Get the rolled over seasons from newest to oldest
+func (u *RepositoryMemory) GetSeasons() []models.Season {
+	seasons := make([]models.Season, 0)
+	u.getAllByFuncOfType(func(m interface{}) bool { return true }, &seasons)
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].Number > seasons[j].Number
+	})
+	return seasons
+}
+
+// Get a rolled over season by its number
+func (u *RepositoryMemory) GetSeason(number int) (models.Season, error) {
+	var s models.Season
+	err := u.getByFuncOfType(func(m interface{}) bool {
+		return m.(models.Season).Number == number
+	}, &s)
+	return s, err
+}
+
+// Get the youth intake of a season
+func (u *RepositoryMemory) GetAcademyIntake(season int) (models.AcademyIntake, error) {
+	var intake models.AcademyIntake
+	err := u.getByFuncOfType(func(m interface{}) bool {
+		return m.(models.AcademyIntake).Season == season
+	}, &intake)
+	return intake, err
+}
+
 // Get model with an id and a specific type
 func (u *RepositoryMemory) getByIdOfType(id uint, t interface{}) error {
 	return u.getByFuncOfType(func(m interface{}) bool {
-		mo := m.(gorm.Model)
-		return mo.ID == id
+		return modelId(m) == id
 	}, t)
 }
 
@@ -1,7 +1,10 @@
 package repos
 
 import (
+	"../models"
+	"math"
 	"testing"
+	"time"
 )
 
 func TestRepositoryMemoryGetTeam(t *testing.T) {
@@ -24,3 +27,200 @@ func TestRepositoryMemoryGetUser(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRepositoryMemoryCompletedTransfersPage(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	for i := 0; i < 5; i++ {
+		_ = repo.Create(&models.CompletedTransfer{PlayerID: uint(i), Price: i})
+	}
+
+	page, total := repo.GetCompletedTransfers(1, 2)
+	if total != 5 || len(page) != 2 {
+		t.Fatalf("unexpected page of %v out of %v", len(page), total)
+	}
+	// Most recent first
+	if page[0].Price != 3 || page[1].Price != 2 {
+		t.Errorf("unexpected order %v, %v", page[0].Price, page[1].Price)
+	}
+
+	page, _ = repo.GetCompletedTransfers(4, 2)
+	if len(page) != 1 {
+		t.Errorf("unexpected last page size %v", len(page))
+	}
+	page, _ = repo.GetCompletedTransfers(10, 2)
+	if len(page) != 0 {
+		t.Errorf("unexpected page past the end %v", len(page))
+	}
+}
+
+func TestRepositoryMemoryQueryTransfers(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	for i := 0; i < 5; i++ {
+		_ = repo.Create(&models.Transfer{
+			Ask:    1000 * (5 - i),
+			Player: models.Player{Age: 20 + i},
+		})
+	}
+
+	query := TransferQuery{
+		Filters: TransferFilters{MaxAgeFilter: 23, MaxValueFilter: math.MaxInt32},
+		Sort:    SortByAsk,
+		Limit:   2,
+	}
+	page, total := repo.QueryTransfers(query)
+	if total != 4 || len(page) != 2 {
+		t.Fatalf("unexpected page of %v out of %v", len(page), total)
+	}
+	if page[0].Ask != 2000 || page[1].Ask != 3000 {
+		t.Errorf("unexpected order %v, %v", page[0].Ask, page[1].Ask)
+	}
+
+	cursor, err := DecodeTransferCursor(query.CursorOf(page[1]).Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.After = &cursor
+	page, _ = repo.QueryTransfers(query)
+	if len(page) != 2 || page[0].Ask != 4000 || page[1].Ask != 5000 {
+		t.Errorf("unexpected next page %v", page)
+	}
+}
+
+func TestRepositoryMemoryUpdateAndDelete(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	player := models.Player{FirstName: "tito"}
+	_ = repo.Create(&player)
+	if player.ID == 0 {
+		t.Fatal("id was not assigned")
+	}
+
+	player.FirstName = "juan"
+	_ = repo.Update(&player)
+	stored, err := repo.GetPlayer(player.ID)
+	if err != nil || stored.FirstName != "juan" {
+		t.Errorf("player was not updated %v", stored.FirstName)
+	}
+
+	_ = repo.Delete(&player)
+	if _, err := repo.GetPlayer(player.ID); err == nil {
+		t.Error("player was not deleted")
+	}
+}
+
+func TestRepositoryMemoryComparableSales(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	similar := models.Player{Age: 25, Position: models.Defender}
+	older := models.Player{Age: 30, Position: models.Defender}
+	attacker := models.Player{Age: 25, Position: models.Attacker}
+	for _, p := range []*models.Player{&similar, &older, &attacker} {
+		_ = repo.Create(p)
+		_ = repo.Create(&models.CompletedTransfer{PlayerID: p.ID})
+	}
+
+	sales := repo.GetComparableSales(models.Player{Age: 24, Position: models.Defender}, 10)
+	if len(sales) != 1 || sales[0].PlayerID != similar.ID {
+		t.Errorf("unexpected comparable sales %v", sales)
+	}
+}
+
+func TestRepositoryMemoryMarketStats(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	defender := models.Player{Position: models.Defender, Age: 24, Country: "Spain", MarketValue: 1000}
+	attacker := models.Player{Position: models.Attacker, Age: 31, Country: "Italy", MarketValue: 2000}
+	_ = repo.Create(&defender)
+	_ = repo.Create(&attacker)
+	_ = repo.Create(&models.Transfer{PlayerID: defender.ID, Ask: 1500})
+
+	listedAt := func(hours int) *time.Time {
+		at := time.Now().Add(-time.Duration(hours) * time.Hour)
+		return &at
+	}
+	_ = repo.Create(&models.CompletedTransfer{PlayerID: attacker.ID, Price: 3000, ValueBefore: 2000, ListedAt: listedAt(2)})
+	_ = repo.Create(&models.CompletedTransfer{PlayerID: defender.ID, Price: 1000, ValueBefore: 1000, ListedAt: listedAt(4)})
+	old := models.CompletedTransfer{PlayerID: defender.ID, Price: 9000, ValueBefore: 1000}
+	old.CreatedAt = time.Now().AddDate(0, 0, -60)
+	_ = repo.Create(&old)
+
+	all := TransferFilters{MinAgeFilter: -1, MinValueFilter: -1, MaxAgeFilter: math.MaxInt32, MaxValueFilter: math.MaxInt32}
+	stats := repo.GetMarketStats(MarketStatsQuery{Filters: all, Since: time.Now().AddDate(0, 0, -30)})
+	if stats.Asks != (PriceStats{Count: 1, Average: 1500, Median: 1500}) {
+		t.Errorf("unexpected ask stats %v", stats.Asks)
+	}
+	if stats.Sales != (PriceStats{Count: 2, Average: 2000, Median: 2000}) {
+		t.Errorf("unexpected sale stats %v", stats.Sales)
+	}
+	if math.Abs(stats.SellThroughRate-2.0/3) > 1e-9 {
+		t.Errorf("unexpected sell-through rate %v", stats.SellThroughRate)
+	}
+	if math.Abs(stats.HoursToSale.Average-3) > 0.01 {
+		t.Errorf("unexpected time to sale %v", stats.HoursToSale)
+	}
+	if len(stats.ByPosition) != 2 || stats.ByPosition[0].Key != "attacker" || stats.ByPosition[1].Asks.Count != 1 {
+		t.Errorf("unexpected position groups %v", stats.ByPosition)
+	}
+	if len(stats.ByAgeBand) != 2 || stats.ByAgeBand[0].Key != "22-25" || stats.ByAgeBand[1].Key != "30-33" {
+		t.Errorf("unexpected age groups %v", stats.ByAgeBand)
+	}
+	if len(stats.PriceIndex) != 1 || stats.PriceIndex[0].Index != 125 || stats.PriceIndex[0].Sales != 2 {
+		t.Errorf("unexpected price index %v", stats.PriceIndex)
+	}
+
+	spain := all
+	spain.Country = "spa"
+	stats = repo.GetMarketStats(MarketStatsQuery{Filters: spain, Since: time.Now().AddDate(0, 0, -30)})
+	if stats.Sales.Count != 1 || len(stats.ByCountry) != 1 || stats.ByCountry[0].Key != "Spain" {
+		t.Errorf("unexpected filtered stats %v", stats)
+	}
+}
+
+func TestPriceStatsMedian(t *testing.T) {
+	if s := priceStatsOf([]float64{5, 1, 3}); s.Median != 3 || s.Average != 3 {
+		t.Errorf("unexpected stats %v", s)
+	}
+	if s := priceStatsOf([]float64{4, 1, 3, 2}); s.Median != 2.5 {
+		t.Errorf("unexpected median %v", s.Median)
+	}
+	if s := priceStatsOf(nil); s != (PriceStats{}) {
+		t.Errorf("unexpected empty stats %v", s)
+	}
+}
+
+func TestRepositoryMemoryQueryTransfersLeavesExpiredOut(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	_ = repo.Create(&models.Transfer{Ask: 1000, ExpiresAt: &past})
+	_ = repo.Create(&models.Transfer{Ask: 2000, ExpiresAt: &future})
+	_ = repo.Create(&models.Transfer{Ask: 3000})
+
+	query := TransferQuery{
+		Filters:  TransferFilters{MinAgeFilter: -1, MinValueFilter: -1, MaxAgeFilter: math.MaxInt32, MaxValueFilter: math.MaxInt32},
+		ActiveAt: time.Now(),
+	}
+	if _, total := repo.QueryTransfers(query); total != 2 {
+		t.Errorf("expected 2 active transfers, got %v", total)
+	}
+	if expired := repo.GetExpiredTransfers(time.Now()); len(expired) != 1 || expired[0].Ask != 1000 {
+		t.Errorf("unexpected expired transfers %v", expired)
+	}
+}
+
+func TestRepositoryMemoryGetFreeAgents(t *testing.T) {
+	repo := CreateRepositoryMemory()
+	_ = repo.Create(&models.Player{TeamID: 1, MarketValue: 1000000})
+	_ = repo.Create(&models.Player{MarketValue: 1000000})
+	_ = repo.Create(&models.Player{MarketValue: 5000000})
+
+	filters := TransferFilters{MinAgeFilter: -1, MinValueFilter: -1, MaxAgeFilter: math.MaxInt32, MaxValueFilter: math.MaxInt32}
+	if agents := repo.GetFreeAgents(filters); len(agents) != 2 {
+		t.Errorf("expected 2 free agents, got %v", len(agents))
+	}
+	// The signing fee is compared unless the market value is asked for
+	filters.MaxValueFilter = 100000
+	if agents := repo.GetFreeAgents(filters); len(agents) != 1 || agents[0].MarketValue != 1000000 {
+		t.Errorf("unexpected free agents %v", agents)
+	}
+	filters.ValueType = "market"
+	if agents := repo.GetFreeAgents(filters); len(agents) != 0 {
+		t.Errorf("unexpected free agents %v", agents)
+	}
+}
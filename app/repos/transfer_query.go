@@ -0,0 +1,151 @@
+package repos
+
+import (
+	"../models"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	SortByAsk         = "ask"
+	SortByMarketValue = "market_value"
+	SortByAge         = "age"
+	SortByCreatedAt   = "created_at"
+)
+
+// A group of filters to apply to transfers
+type TransferFilters struct {
+	Country        string
+	TeamName       string
+	PlayerName     string
+	MinAgeFilter   int
+	MinValueFilter int
+	MaxAgeFilter   int
+	MaxValueFilter int
+	ValueType      string
+	// Bounds of the player's overall rating, zero leaves them out
+	MinOverallFilter int
+	MaxOverallFilter int
+	// Only players available at this time, availability is not checked when zero
+	AvailableAt time.Time
+}
+
+// Returns a bool that tells if the transfer matches with the filter
+func (f *TransferFilters) Matches(transfer models.Transfer) bool {
+	return f.MatchesPlayer(transfer.Player, transfer.Ask)
+}
+
+// Returns a bool that tells if a player asked for a price matches with the filter
+func (f *TransferFilters) MatchesPlayer(player models.Player, ask int) bool {
+	value := ask
+	if f.ValueType == "market" {
+		value = int(player.MarketValue)
+	}
+	return strings.Contains(strings.ToLower(player.FirstName+" "+player.LastName), strings.ToLower(f.PlayerName)) &&
+		strings.Contains(strings.ToLower(player.Team.Name), strings.ToLower(f.TeamName)) &&
+		strings.Contains(strings.ToLower(player.Country), strings.ToLower(f.Country)) &&
+		value >= f.MinValueFilter && player.Age >= f.MinAgeFilter &&
+		value <= f.MaxValueFilter && player.Age <= f.MaxAgeFilter &&
+		(f.MinOverallFilter == 0 || player.Overall >= f.MinOverallFilter) &&
+		(f.MaxOverallFilter == 0 || player.Overall <= f.MaxOverallFilter) &&
+		(f.AvailableAt.IsZero() || player.IsAvailable(f.AvailableAt))
+}
+
+// Position of the last transfer of a page
+type TransferCursor struct {
+	Value int64 `json:"v"`
+	ID    uint  `json:"id"`
+}
+
+// Encode the cursor into an opaque string
+func (c TransferCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode a cursor created with Encode
+func DecodeTransferCursor(s string) (TransferCursor, error) {
+	var c TransferCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// A filtered, sorted and paginated query over the open transfers
+type TransferQuery struct {
+	Filters TransferFilters
+	// One of SortByAsk, SortByMarketValue, SortByAge or SortByCreatedAt
+	Sort       string
+	Descending bool
+	// Maximum amount of transfers to return, 0 means there is no limit
+	Limit int
+	// Only return the transfers that come after this cursor
+	After *TransferCursor
+	// Leave out the listings expired at this time, every listing is returned when zero
+	ActiveAt time.Time
+}
+
+// Returns a bool that tells if the sort key is known
+func ValidTransferSort(sort string) bool {
+	switch sort {
+	case SortByAsk, SortByMarketValue, SortByAge, SortByCreatedAt:
+		return true
+	}
+	return false
+}
+
+// Get the cursor pointing to a transfer in this query's order
+func (q TransferQuery) CursorOf(t models.Transfer) TransferCursor {
+	return TransferCursor{Value: q.sortValue(t), ID: t.ID}
+}
+
+// Get the value a transfer is sorted by
+func (q TransferQuery) sortValue(t models.Transfer) int64 {
+	switch q.Sort {
+	case SortByAsk:
+		return int64(t.Ask)
+	case SortByMarketValue:
+		return int64(t.Player.MarketValue)
+	case SortByAge:
+		return int64(t.Player.Age)
+	}
+	return t.CreatedAt.UnixNano()
+}
+
+// Returns a bool that tells if a transfer goes before another one in this query's order
+func (q TransferQuery) less(a, b TransferCursor) bool {
+	if a.Value == b.Value {
+		if q.Descending {
+			return a.ID > b.ID
+		}
+		return a.ID < b.ID
+	}
+	if q.Descending {
+		return a.Value > b.Value
+	}
+	return a.Value < b.Value
+}
+
+// Get the column a query sorts by
+func (q TransferQuery) sortColumn() string {
+	switch q.Sort {
+	case SortByAsk:
+		return "transfers.ask"
+	case SortByMarketValue:
+		return "players.market_value"
+	case SortByAge:
+		return "players.age"
+	}
+	return "transfers.created_at"
+}
+
+// Returns a bool that tells if a listing is still on the market at a time, every listing is when zero.
+// Ended auctions are off the market while they wait to be settled.
+func isActiveAt(transfer models.Transfer, at time.Time) bool {
+	return at.IsZero() || !(transfer.IsExpired(at) || (transfer.IsAuction() && transfer.HasEnded(at)))
+}
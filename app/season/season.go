@@ -0,0 +1,51 @@
+package season
+
+import (
+	"../models"
+	"math/rand"
+	"time"
+)
+
+// Settings of the season rollover
+type Config struct {
+	// Players older than this retire when a new season starts, nobody retires when zero
+	RetirementAge int
+	// Bounds of the age of the players generated to replace the retired ones
+	MinYouthAge int
+	MaxYouthAge int
+	// Month new seasons start in, seasons are only rolled over by administrators when zero
+	StartMonth time.Month
+}
+
+// The settings used by the league unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		RetirementAge: 37,
+		MinYouthAge:   17,
+		MaxYouthAge:   20,
+	}
+}
+
+// Get the season a time belongs to, seasons are named after the year they start in
+func Of(t time.Time, startMonth time.Month) int {
+	if startMonth == 0 || t.Month() >= startMonth {
+		return t.Year()
+	}
+	return t.Year() - 1
+}
+
+// Returns a bool that tells if a player that turned an age retires
+func (c Config) Retires(age int) bool {
+	return c.RetirementAge > 0 && age > c.RetirementAge
+}
+
+// Create a young player of a position to replace a retired one
+func Youth(position int, c Config) models.Player {
+	player := models.RandomPlayer(position)
+	player.Age = c.MinYouthAge
+	if c.MaxYouthAge > c.MinYouthAge {
+		player.Age += rand.Intn(c.MaxYouthAge - c.MinYouthAge + 1)
+	}
+	player.SetAttributes(models.RandomAttributes(position, player.Age))
+	return player
+}
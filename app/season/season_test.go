@@ -0,0 +1,44 @@
+package season
+
+import (
+	"../models"
+	"testing"
+	"time"
+)
+
+func TestOf(t *testing.T) {
+	june := time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	if s := Of(june, time.July); s != 2025 {
+		t.Errorf("expected 2025, got %v", s)
+	}
+	if s := Of(july, time.July); s != 2026 {
+		t.Errorf("expected 2026, got %v", s)
+	}
+	if s := Of(june, 0); s != 2026 {
+		t.Errorf("expected the calendar year without a start month, got %v", s)
+	}
+}
+
+func TestRetires(t *testing.T) {
+	c := Config{RetirementAge: 37}
+	if c.Retires(37) || !c.Retires(38) {
+		t.Error("only players past the retirement age retire")
+	}
+	if (Config{}).Retires(60) {
+		t.Error("nobody retires without a retirement age")
+	}
+}
+
+func TestYouth(t *testing.T) {
+	c := DefaultConfig()
+	for i := 0; i < 50; i++ {
+		p := Youth(models.Attacker, c)
+		if p.Age < c.MinYouthAge || p.Age > c.MaxYouthAge || p.Position != models.Attacker {
+			t.Fatalf("unexpected player %+v", p)
+		}
+		if p.Potential < p.Overall {
+			t.Fatalf("the potential is below the overall rating %+v", p.PlayerAttributes)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"testing"
+)
+
+func TestRolloverSeasonOnlyOnce(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	token, players := getTokenAndPlayerIds(t, false)
+	patchPlayer(t, admin, players[0], map[string]interface{}{"age": 25})
+
+	_, err := doPostRequest("admin/seasons/rollover", token, map[string]interface{}{"season": 2026}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := doPostRequest("admin/seasons/rollover", admin, map[string]interface{}{"season": 2026}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["season"], 2026)
+	tests.AssertEqual(t, resp["already_rolled_over"], nil)
+
+	// Rolling over the same season again does nothing
+	resp, err = doPostRequest("admin/seasons/rollover", admin, map[string]interface{}{"season": 2026}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["already_rolled_over"], true)
+	_, err = doPostRequest("admin/seasons/rollover", admin, map[string]interface{}{"season": 2025}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests.AssertEqual(t, getPlayer(t, token, players[0])["age"], 26)
+
+	resp, err = doGetRequest("admin/seasons", admin, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["seasons"].([]interface{})), 1)
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestSquadRulesBlockSales(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	seller, players := getTokenAndPlayerIds(t, false)
+	// Teams are created with 3 goalkeepers listed first
+	goalkeeper := players[0]
+	transferId := createTransferUsing(t, 10000, seller, goalkeeper)
+
+	_, err := doPatchRequest("squad-rules", admin, map[string]interface{}{
+		"min_goalkeepers": 3,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buyer := getUserToken(t, "hola@test.com")
+	resp, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["rule"], "min_goalkeepers")
+
+	resp, err = doGetRequest("squad-rules", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["min_goalkeepers"], 3)
+	tests.AssertEqual(t, resp["max_goalkeepers"], 0)
+}
+
+func TestInvalidSquadRules(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	_, err := doPatchRequest("squad-rules", admin, map[string]interface{}{
+		"min_attackers": 5,
+		"max_attackers": 2,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
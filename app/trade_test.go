@@ -0,0 +1,137 @@
+package app
+
+import (
+	"./models"
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestTradeExecutesWhenBothTeamsAccept(t *testing.T) {
+	setupTest()
+	proposer, proposerPlayers := getTokenAndPlayerIds(t, false)
+	receiver := getUserToken(t, "hola@test.com")
+	receiverPlayers := getPlayersFromToken(t, receiver)
+	receiverTeam := getTeamIdFromUser(t, receiver)
+	transferId := createTransferUsing(t, 10000, receiver, receiverPlayers[0])
+
+	resp, err := doPostRequest("trades", proposer, map[string]interface{}{
+		"team_id":              receiverTeam,
+		"offered_player_ids":   []int{proposerPlayers[0]},
+		"requested_player_ids": []int{receiverPlayers[0]},
+		"cash":                 5000,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tradeId := strconv.Itoa(int(resp["id"].(float64)))
+
+	_, err = doPutRequest("trades/"+tradeId+"/accept", receiver, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doGetRequest("trades/"+tradeId, proposer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["status"], models.TradeExecuted)
+
+	proposerResp, err := doGetRequest("me/team", proposer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiverResp, err := doGetRequest("me/team", receiver, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, proposerResp["budget"], models.DefaultTeamBudget-5000)
+	tests.AssertEqual(t, receiverResp["budget"], models.DefaultTeamBudget+5000)
+	tests.AssertEqual(t, hasPlayer(proposerResp, receiverPlayers[0]), true)
+	tests.AssertEqual(t, hasPlayer(receiverResp, proposerPlayers[0]), true)
+
+	// The open transfer of the traded player was cancelled
+	_, err = doGetRequest("transfers/"+strconv.Itoa(transferId), proposer, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCantTradePlayersOfOtherTeams(t *testing.T) {
+	setupTest()
+	proposer, _ := getTokenAndPlayerIds(t, false)
+	receiver := getUserToken(t, "hola@test.com")
+	receiverPlayers := getPlayersFromToken(t, receiver)
+
+	_, err := doPostRequest("trades", proposer, map[string]interface{}{
+		"team_id":            getTeamIdFromUser(t, receiver),
+		"offered_player_ids": []int{receiverPlayers[0]},
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnlyTheReceiverCanRejectATrade(t *testing.T) {
+	setupTest()
+	proposer, proposerPlayers := getTokenAndPlayerIds(t, false)
+	receiver := getUserToken(t, "hola@test.com")
+
+	resp, err := doPostRequest("trades", proposer, map[string]interface{}{
+		"team_id":            getTeamIdFromUser(t, receiver),
+		"offered_player_ids": []int{proposerPlayers[0]},
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tradeId := strconv.Itoa(int(resp["id"].(float64)))
+
+	_, err = doPutRequest("trades/"+tradeId+"/reject", proposer, map[string]interface{}{}, http.StatusUnauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("trades/"+tradeId+"/reject", receiver, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest("trades/"+tradeId+"/accept", receiver, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCantSellPlayersThroughTrades(t *testing.T) {
+	setupTest()
+	proposer, proposerPlayers := getTokenAndPlayerIds(t, false)
+	receiver := getUserToken(t, "hola@test.com")
+	receiverPlayers := getPlayersFromToken(t, receiver)
+	receiverTeam := getTeamIdFromUser(t, receiver)
+
+	_, err := doPostRequest("trades", proposer, map[string]interface{}{
+		"team_id":              receiverTeam,
+		"requested_player_ids": []int{receiverPlayers[0]},
+		"cash":                 5000,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest("trades", proposer, map[string]interface{}{
+		"team_id":            receiverTeam,
+		"offered_player_ids": []int{proposerPlayers[0]},
+		"cash":               -5000,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Returns a bool that tells if a team payload has a player
+func hasPlayer(team map[string]interface{}, playerId int) bool {
+	for _, m := range team["players"].([]interface{}) {
+		if int(m.(map[string]interface{})["id"].(float64)) == playerId {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,17 @@
 package app
 
 import (
+	"./controller"
 	"./models"
+	"./repos"
 	"fmt"
 	"gorm.io/gorm/utils/tests"
 	"math/rand"
 	"net/http"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestGetTransfer(t *testing.T) {
@@ -42,6 +46,46 @@ func TestGetAllTransfers(t *testing.T) {
 	tests.AssertEqual(t, len(arr), 10)
 }
 
+func TestListTransfersSortedAndPaginated(t *testing.T) {
+	setupTest()
+	token, players := getTokenAndPlayerIds(t, false)
+	for i := 0; i < 5; i++ {
+		_ = createTransferUsing(t, 10000+i*1000, token, players[i])
+	}
+
+	asks := make([]float64, 0)
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		resp, err := doGetRequest("transfers?sort=ask&order=desc&limit=2"+cursor, token, http.StatusOK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests.AssertEqual(t, resp["total"], 5)
+		for _, v := range resp["transfers"].([]interface{}) {
+			asks = append(asks, v.(map[string]interface{})["ask"].(float64))
+		}
+		if next, ok := resp["next_cursor"]; ok {
+			cursor = "&cursor=" + next.(string)
+		}
+	}
+	tests.AssertEqual(t, asks, []float64{14000, 13000, 12000, 11000, 10000})
+
+	resp, err := doGetRequest("transfers?min_value=11000&max_value=12000", token, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, len(resp["transfers"].([]interface{})), 2)
+	tests.AssertEqual(t, resp["total"], 2)
+}
+
+func TestListTransfersWithInvalidSortFails(t *testing.T) {
+	setupTest()
+	_, err := doGetRequest("transfers?sort=name", "", http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCreateTransfer(t *testing.T) {
 	setupTest()
 	_, _, _ = createTransfer(t, 10000)
@@ -120,6 +164,56 @@ func TestCantBuyTransferIfNotEnoughMoney(t *testing.T) {
 	}
 }
 
+func TestConcurrentPurchasesHaveOneWinner(t *testing.T) {
+	setupTest()
+	ask := 10000
+	seller, _, transferId := createTransfer(t, ask)
+	buyers := make([]string, 5)
+	for i := range buyers {
+		buyers[i] = getUserToken(t, fmt.Sprintf("buyer%v@test.com", i))
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, len(buyers))
+	start := make(chan struct{})
+	for _, token := range buyers {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			<-start
+			_, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", token, map[string]interface{}{}, http.StatusOK)
+			results <- err
+		}(token)
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	wins := 0
+	for err := range results {
+		if err == nil {
+			wins++
+			continue
+		}
+		// Losers either conflict on the locked transfer or find it already sold
+		if err.Error() != "unexpected status code 409" && err.Error() != "unexpected status code 404" {
+			t.Error(err)
+		}
+	}
+	tests.AssertEqual(t, wins, 1)
+
+	// Money is conserved across every team involved
+	total := 0
+	for _, token := range append(buyers, seller) {
+		resp, err := doGetRequest("me/team", token, http.StatusOK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += int(resp["budget"].(float64))
+	}
+	tests.AssertEqual(t, total, (len(buyers)+1)*models.DefaultTeamBudget)
+}
+
 func TestEditTransfer(t *testing.T) {
 	setupTest()
 	token, _, transferId := createTransfer(t, 10000)
@@ -165,6 +259,124 @@ func TestDeleteTransfer(t *testing.T) {
 	}
 }
 
+func TestBidOnAuction(t *testing.T) {
+	setupTest()
+	_, _, transferId := createAuction(t, 10000, time.Now().Add(time.Hour))
+	bidder := getUserToken(t, "hola@test.com")
+	bids := "transfers/" + strconv.Itoa(transferId) + "/bids"
+
+	_, err := doPostRequest(bids, bidder, map[string]interface{}{"amount": 5000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest(bids, bidder, map[string]interface{}{"amount": models.DefaultTeamBudget + 1}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest(bids, bidder, map[string]interface{}{"amount": 12000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest(bids, bidder, map[string]interface{}{"amount": 12000}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("transfers/"+strconv.Itoa(transferId), bidder, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["mode"], models.TransferModeAuction)
+	tests.AssertEqual(t, resp["highest_bid"], 12000)
+}
+
+func TestCantBuyAuctionDirectly(t *testing.T) {
+	setupTest()
+	_, _, transferId := createAuction(t, 10000, time.Now().Add(time.Hour))
+	token2 := getUserToken(t, "hola@test.com")
+	_, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", token2, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCloseAuctionSellsToHighestBidder(t *testing.T) {
+	setupTest()
+	seller, playerId, transferId := createAuction(t, 10000, time.Now().Add(time.Hour))
+	bidder1 := getUserToken(t, "hola@test.com")
+	bidder2 := getUserToken(t, "chau@test.com")
+	bids := "transfers/" + strconv.Itoa(transferId) + "/bids"
+	closeRes := "transfers/" + strconv.Itoa(transferId) + "/close"
+
+	_, err := doPostRequest(bids, bidder1, map[string]interface{}{"amount": 11000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPostRequest(bids, bidder2, map[string]interface{}{"amount": 15000}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doPutRequest(closeRes, bidder1, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endAuction(t, transferId)
+	resp, err := doPutRequest(closeRes, bidder1, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["sold"], true)
+
+	resp1, err := doGetRequest("me/team", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2, err := doGetRequest("me/team", bidder1, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp3, err := doGetRequest("me/team", bidder2, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp1["budget"], models.DefaultTeamBudget+15000)
+	tests.AssertEqual(t, resp2["budget"], models.DefaultTeamBudget)
+	tests.AssertEqual(t, resp3["budget"], models.DefaultTeamBudget-15000)
+
+	isIn := false
+	for _, m := range resp3["players"].([]interface{}) {
+		p := m.(map[string]interface{})
+		if int(p["id"].(float64)) == playerId {
+			isIn = true
+			break
+		}
+	}
+	if !isIn {
+		t.Fatal("player is not in the winning team")
+	}
+
+	_, err = doGetRequest("transfers/"+strconv.Itoa(transferId), seller, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateAuctionFails(t *testing.T) {
+	setupTest()
+	seller, _, transferId := createAuction(t, 10000, time.Now().Add(time.Hour))
+
+	_, err := doPatchRequest("transfers/"+strconv.Itoa(transferId), seller, map[string]interface{}{"ask": 1}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := doGetRequest("transfers/"+strconv.Itoa(transferId), seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["ask"], float64(10000))
+}
+
 func createTransfer(t *testing.T, ask int) (string, int, int) {
 	token, players := getTokenAndPlayerIds(t, false)
 	resp, err := doPostRequest("transfers", token, map[string]interface{}{
@@ -187,3 +399,140 @@ func createTransferUsing(t *testing.T, ask int, token string, playerId int) int
 	}
 	return int(resp["id"].(float64))
 }
+
+func createAuction(t *testing.T, reserve int, endsAt time.Time) (string, int, int) {
+	token, players := getTokenAndPlayerIds(t, false)
+	resp, err := doPostRequest("transfers", token, map[string]interface{}{
+		"player_id":     players[0],
+		"mode":          models.TransferModeAuction,
+		"reserve_price": reserve,
+		"ends_at":       endsAt.Format(time.RFC3339),
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, players[0], int(resp["id"].(float64))
+}
+
+// Move the end time of an auction to the past
+func endAuction(t *testing.T, transferId int) {
+	res := app.db.Table("transfers").Where("id = ?", transferId).Update("ends_at", time.Now().Add(-time.Minute))
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+}
+
+func TestExpiredListingsLeaveTheMarket(t *testing.T) {
+	setupTest()
+	seller, players := getTokenAndPlayerIds(t, false)
+	resp, err := doPostRequest("transfers", seller, map[string]interface{}{
+		"player_id":  players[0],
+		"ask":        10000,
+		"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferRes := "transfers/" + strconv.Itoa(int(resp["id"].(float64)))
+
+	res := app.db.Table("transfers").Where("player_id = ?", players[0]).Update("expires_at", time.Now().Add(-time.Minute))
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	// Gone before the worker runs
+	resp, err = doGetRequest("transfers", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["total"], 0)
+	_, err = doGetRequest(transferRes, seller, http.StatusNotFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = controller.NewController(repos.RepositorySQL{Db: app.db}).ExpireListings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = doGetRequest("me/notifications", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications := resp["notifications"].([]interface{})
+	tests.AssertEqual(t, len(notifications), 1)
+	tests.AssertEqual(t, notifications[0].(map[string]interface{})["kind"], "listing_expired")
+
+	// The player can be listed again
+	createTransferUsing(t, 10000, seller, players[0])
+}
+
+func TestListingWithPastExpiryFails(t *testing.T) {
+	setupTest()
+	seller, players := getTokenAndPlayerIds(t, false)
+	_, err := doPostRequest("transfers", seller, map[string]interface{}{
+		"player_id":  players[0],
+		"ask":        10000,
+		"expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBulkTransfers(t *testing.T) {
+	setupTest()
+	token, players := getTokenAndPlayerIds(t, false)
+	resp, err := doPostRequest("transfers/bulk", token, map[string]interface{}{
+		"transfers": []map[string]interface{}{
+			{"player_id": players[0], "ask": 10000},
+			{"player_id": players[1], "ask": 20000},
+		},
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := resp["results"].([]interface{})
+	tests.AssertEqual(t, len(results), 2)
+	ids := make([]int, 0)
+	for _, r := range results {
+		id := int(r.(map[string]interface{})["transfer_id"].(float64))
+		if _, err := doGetRequest("transfers/"+strconv.Itoa(id), token, http.StatusOK); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	_, err = doRequest("transfers/bulk", token, "DELETE", map[string]interface{}{
+		"transfer_ids": ids,
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if _, err := doGetRequest("transfers/"+strconv.Itoa(id), token, http.StatusNotFound); err != nil {
+			t.Fatal("found deleted transfer")
+		}
+	}
+}
+
+func TestBulkTransfersAreAllOrNothing(t *testing.T) {
+	setupTest()
+	token, players := getTokenAndPlayerIds(t, false)
+	_ = createTransferUsing(t, 10000, token, players[1])
+	resp, err := doPostRequest("transfers/bulk", token, map[string]interface{}{
+		"transfers": []map[string]interface{}{
+			{"player_id": players[0], "ask": 10000},
+			{"player_id": players[1], "ask": 10000},
+		},
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := resp["results"].([]interface{})
+	tests.AssertEqual(t, len(results), 2)
+	tests.AssertEqual(t, results[1].(map[string]interface{})["error"], "Player already has an open transfer")
+
+	// The valid item was not listed either
+	_ = createTransferUsing(t, 10000, token, players[0])
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"gorm.io/gorm/utils/tests"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCantTradeOutsideTransferWindow(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	seller, _, transferId := createTransfer(t, 10000)
+
+	createTransferWindow(t, admin, time.Now().Add(24*time.Hour), time.Now().Add(48*time.Hour))
+
+	_, err := doPatchRequest("transfers/"+strconv.Itoa(transferId), seller, map[string]interface{}{
+		"ask": 20000,
+	}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buyer := getUserToken(t, "hola@test.com")
+	_, err = doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusBadRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("transfers", seller, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["market_open"], false)
+}
+
+func TestTradeInsideTransferWindow(t *testing.T) {
+	setupTest()
+	admin := getAdminUserToken(t, "admin@test.com")
+	closesAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	createTransferWindow(t, admin, time.Now().Add(-time.Hour), closesAt)
+
+	_, _, transferId := createTransfer(t, 10000)
+	buyer := getUserToken(t, "hola@test.com")
+	_, err := doPutRequest("transfers/"+strconv.Itoa(transferId)+"/buy", buyer, map[string]interface{}{}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := doGetRequest("transfers", buyer, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, resp["market_open"], true)
+	windowClose, err := time.Parse(time.RFC3339, resp["window_closes_at"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests.AssertEqual(t, windowClose, closesAt)
+}
+
+func createTransferWindow(t *testing.T, token string, opensAt time.Time, closesAt time.Time) int {
+	resp, err := doPostRequest("transfer-windows", token, map[string]interface{}{
+		"name":      "test window",
+		"opens_at":  opensAt.Format(time.RFC3339),
+		"closes_at": closesAt.Format(time.RFC3339),
+	}, http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(resp["id"].(float64))
+}
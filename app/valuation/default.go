@@ -0,0 +1,107 @@
+package valuation
+
+import (
+	"../models"
+	"math"
+)
+
+const (
+	// Bounds of how much a single sale can move the value of a player
+	minDemand = 0.8
+	maxDemand = 2.0
+	// Weight of the comparable sales against the sale itself
+	comparablesWeight = 0.3
+)
+
+// Deterministic model that moves the value of a player towards the price
+// it was sold for, weighted by recent comparable sales, its age and its position
+type DefaultModel struct {
+	positionFactors map[int]float64
+}
+
+// Create a default model
+func NewDefaultModel() *DefaultModel {
+	return &DefaultModel{
+		positionFactors: map[int]float64{
+			models.Goalkeeper: 0.95,
+			models.Defender:   1.0,
+			models.Midfielder: 1.0,
+			models.Attacker:   1.05,
+		},
+	}
+}
+
+// Get the new value of the player
+func (m *DefaultModel) Value(sale Sale) int32 {
+	value := float64(sale.Player.MarketValue)
+	if value <= 0 {
+		return int32(sale.Price)
+	}
+
+	demand := float64(sale.Price) / value
+	if ratio, ok := comparablesDemand(sale.Comparables); ok {
+		demand = demand*(1-comparablesWeight) + ratio*comparablesWeight
+	}
+	demand = math.Max(minDemand, math.Min(maxDemand, demand))
+
+	positionFactor, ok := m.positionFactors[sale.Player.Position]
+	if !ok {
+		positionFactor = 1
+	}
+
+	newValue := value * demand * ageFactor(sale.Player.Age) * positionFactor
+	return int32(math.Max(1, math.Min(math.MaxInt32, math.Round(newValue))))
+}
+
+// Get the new value of a player that just turned its age. Young players gain value until
+// they reach their peak, it stays flat while they are at it and then it decays.
+func (m *DefaultModel) Revalue(player models.Player) int32 {
+	newValue := float64(player.MarketValue) * ageCurve(player.Age)
+	return int32(math.Max(1, math.Min(math.MaxInt32, math.Round(newValue))))
+}
+
+// Get the average ratio between price and value of the comparable sales
+func comparablesDemand(sales []models.CompletedTransfer) (float64, bool) {
+	total, count := 0.0, 0
+	for _, s := range sales {
+		if s.ValueBefore <= 0 {
+			continue
+		}
+		total += float64(s.Price) / float64(s.ValueBefore)
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// Young players gain value and veterans lose it
+func ageFactor(age int) float64 {
+	switch {
+	case age <= 23:
+		return 1.1
+	case age <= 29:
+		return 1.0
+	case age <= 33:
+		return 0.95
+	}
+	return 0.85
+}
+
+// Change of the market value of a player that turned an age
+func ageCurve(age int) float64 {
+	switch {
+	case age <= 21:
+		return 1.15
+	case age <= 24:
+		return 1.08
+	case age <= 28:
+		return 1.0
+	case age <= 31:
+		return 0.92
+	case age <= 34:
+		return 0.82
+	}
+	return 0.7
+}
@@ -0,0 +1,35 @@
+package valuation
+
+import (
+	"../models"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Model that raises the value of a player by a random percentage between 10% and 100%
+type RandomModel struct {
+	rng   *rand.Rand
+	mutex sync.Mutex
+}
+
+// Create a random model from a seed
+func NewRandomModel(seed int64) *RandomModel {
+	return &RandomModel{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Get the new value of the player, ignoring the sale context
+func (m *RandomModel) Value(sale Sale) int32 {
+	m.mutex.Lock()
+	multiplier := 1.1 + m.rng.Float64()*0.9
+	m.mutex.Unlock()
+	return int32(float64(sale.Player.MarketValue) * multiplier)
+}
+
+// Get the new value of a player that just turned its age, changed by a random percentage between -30% and 15%
+func (m *RandomModel) Revalue(player models.Player) int32 {
+	m.mutex.Lock()
+	multiplier := 0.7 + m.rng.Float64()*0.45
+	m.mutex.Unlock()
+	return int32(math.Max(1, math.Round(float64(player.MarketValue)*multiplier)))
+}
@@ -0,0 +1,38 @@
+package valuation
+
+import (
+	"../models"
+	"fmt"
+)
+
+const (
+	DefaultModelName = "default"
+	RandomModelName  = "random"
+)
+
+// Everything known about a sale when computing the new value of the player
+type Sale struct {
+	// The player before the sale, with its current market value
+	Player models.Player
+	// The price the player was sold for
+	Price int
+	// Recent sales of players with the same position and a similar age
+	Comparables []models.CompletedTransfer
+}
+
+// A model that computes the market value of a player after a sale and after it turns a year older
+type Model interface {
+	Value(sale Sale) int32
+	Revalue(player models.Player) int32
+}
+
+// Create a valuation model by name, the seed is used by the models that need randomness
+func New(name string, seed int64) (Model, error) {
+	switch name {
+	case "", DefaultModelName:
+		return NewDefaultModel(), nil
+	case RandomModelName:
+		return NewRandomModel(seed), nil
+	}
+	return nil, fmt.Errorf("unknown valuation model %v", name)
+}
@@ -0,0 +1,98 @@
+package valuation
+
+import (
+	"../models"
+	"testing"
+)
+
+func TestNewSelectsModelByName(t *testing.T) {
+	if m, err := New("", 0); err != nil || m == nil {
+		t.Errorf("expected the default model, got %v", err)
+	}
+	if _, ok := mustNew(t, RandomModelName).(*RandomModel); !ok {
+		t.Error("expected the random model")
+	}
+	if _, err := New("unknown", 0); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
+
+func TestRandomModelIsSeeded(t *testing.T) {
+	sale := Sale{Player: models.Player{MarketValue: 1000000}}
+	a, b := NewRandomModel(42), NewRandomModel(42)
+	for i := 0; i < 5; i++ {
+		va, vb := a.Value(sale), b.Value(sale)
+		if va != vb {
+			t.Fatalf("same seed gave %v and %v", va, vb)
+		}
+		if va < 1100000 || va > 2000000 {
+			t.Errorf("value %v out of range", va)
+		}
+	}
+}
+
+func TestDefaultModelFollowsThePrice(t *testing.T) {
+	m := NewDefaultModel()
+	player := models.Player{MarketValue: 1000000, Age: 26, Position: models.Defender}
+
+	if v := m.Value(Sale{Player: player, Price: 1500000}); v != 1500000 {
+		t.Errorf("expected the value to follow the price, got %v", v)
+	}
+	if v := m.Value(Sale{Player: player, Price: 100}); v != 800000 {
+		t.Errorf("expected the drop to be bounded, got %v", v)
+	}
+	if v := m.Value(Sale{Player: player, Price: 10000000}); v != 2000000 {
+		t.Errorf("expected the rise to be bounded, got %v", v)
+	}
+}
+
+func TestDefaultModelUsesContext(t *testing.T) {
+	m := NewDefaultModel()
+	player := models.Player{MarketValue: 1000000, Age: 26, Position: models.Defender}
+	comparables := []models.CompletedTransfer{{Price: 2000000, ValueBefore: 1000000}}
+
+	base := m.Value(Sale{Player: player, Price: 1000000})
+	withComparables := m.Value(Sale{Player: player, Price: 1000000, Comparables: comparables})
+	if withComparables != 1300000 {
+		t.Errorf("expected comparable sales to raise the value, got %v", withComparables)
+	}
+
+	young, old := player, player
+	young.Age, old.Age = 20, 36
+	if m.Value(Sale{Player: young, Price: 1000000}) <= base || m.Value(Sale{Player: old, Price: 1000000}) >= base {
+		t.Error("expected age to change the value")
+	}
+
+	attacker := player
+	attacker.Position = models.Attacker
+	if m.Value(Sale{Player: attacker, Price: 1000000}) <= base {
+		t.Error("expected attackers to be valued higher")
+	}
+}
+
+func TestDefaultModelRevaluesOnTheAgeCurve(t *testing.T) {
+	m := NewDefaultModel()
+	revalue := func(value int32, age int) int32 {
+		return m.Revalue(models.Player{MarketValue: value, Age: age})
+	}
+	if v := revalue(1000, 20); v <= 1000 {
+		t.Errorf("young players should gain value, got %v", v)
+	}
+	if v := revalue(1000, 26); v != 1000 {
+		t.Errorf("players at their peak should keep their value, got %v", v)
+	}
+	if v := revalue(1000, 35); v >= revalue(1000, 30) {
+		t.Errorf("older players should lose more value, got %v", v)
+	}
+	if v := revalue(1, 40); v != 1 {
+		t.Errorf("values never go below 1, got %v", v)
+	}
+}
+
+func mustNew(t *testing.T, name string) Model {
+	m, err := New(name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}